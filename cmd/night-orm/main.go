@@ -0,0 +1,19 @@
+// Command night-orm manages migrations, seeds and models of a PostgreSQL database.
+//
+//	night-orm migrate up|down|status|create
+//	night-orm db diff|seed
+//	night-orm gen models
+//
+// The connection string is read from -database-url or DATABASE_URL. db diff needs the
+// models, so it only works from a binary that registers them, as described in package cli.
+package main
+
+import (
+	"os"
+
+	"github.com/rodolfocoding/night-orm/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(os.Args[1:]))
+}
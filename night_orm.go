@@ -2,33 +2,140 @@ package night_orm
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+
 	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/memory"
 	"github.com/rodolfocoding/night-orm/pkg/postgres"
 )
 
 // ORM é a interface principal que define as operações básicas do ORM
 type ORM = core.ORM
 
-// Model é a interface que todos os modelos devem implementar
+// Model representa um modelo: qualquer estrutura com tags "db"
 type Model = core.Model
 
-// ModelWithPrimaryKey é uma interface para modelos com chave primária
+// TableNamer é implementado pelos modelos que definem explicitamente o nome da tabela
+type TableNamer = core.TableNamer
+
+// SchemaNamer é implementado pelos modelos que definem explicitamente o esquema da tabela
+type SchemaNamer = core.SchemaNamer
+
+// ModelWithPrimaryKey é uma interface para modelos que definem a tabela e a chave primária
 type ModelWithPrimaryKey = core.ModelWithPrimaryKey
 
 // Transaction representa uma transação de banco de dados
 type Transaction = core.Transaction
 
-// NewPostgresORM cria uma nova instância do ORM para PostgreSQL
-func NewPostgresORM() ORM {
-	return postgres.NewPostgresORM()
+// Migrator é implementado pelos ORMs capazes de criar e alterar tabelas a partir dos modelos
+type Migrator = core.Migrator
+
+// Association manipula as associações de uma relação many_to_many
+type Association = core.Association
+
+// Logger recebe um evento para cada instrução executada pelo ORM
+type Logger = core.Logger
+
+// QueryEvent descreve uma instrução executada pelo ORM
+type QueryEvent = core.QueryEvent
+
+// NewSlogLogger cria um Logger que registra as instruções em um *slog.Logger
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return core.NewSlogLogger(logger)
+}
+
+// Instrumentation inicia um Span em torno de cada chamada ao ORM e às transações
+type Instrumentation = core.Instrumentation
+
+// Operation descreve uma chamada instrumentada ao ORM ou a uma transação
+type Operation = core.Operation
+
+// Span acompanha uma operação instrumentada
+type Span = core.Span
+
+// Middleware intercepta as operações do ORM e das transações antes da sua execução
+type Middleware = core.Middleware
+
+// Op descreve uma operação interceptada pelos middlewares
+type Op = core.Op
+
+// Handler executa uma operação; é o próximo elemento da cadeia de middlewares
+type Handler = core.Handler
+
+// Erros retornados por todas as implementações do ORM, verificáveis com errors.Is
+var (
+	ErrNotFound    = core.ErrNotFound
+	ErrDuplicate   = core.ErrDuplicate
+	ErrForeignKey  = core.ErrForeignKey
+	ErrNotNull     = core.ErrNotNull
+	ErrDeadlock    = core.ErrDeadlock
+	ErrLockTimeout = core.ErrLockTimeout
+)
+
+// NewPostgresORM cria uma nova instância do ORM para PostgreSQL com as opções informadas
+func NewPostgresORM(opts ...postgres.Option) ORM {
+	return postgres.NewPostgresORM(opts...)
+}
+
+// NewPostgresORMFromDB cria uma instância do ORM para PostgreSQL sobre um pool de conexões
+// existente; Close só fecha o pool com a opção postgres.WithClosePool(true)
+func NewPostgresORMFromDB(db *sql.DB, opts ...postgres.Option) ORM {
+	return postgres.NewPostgresORMFromDB(db, opts...)
+}
+
+// NewPostgresORMFromConnector cria uma instância do ORM para PostgreSQL sobre um pool
+// aberto com o conector informado, como um que renova as credenciais a cada conexão
+func NewPostgresORMFromConnector(connector driver.Connector, opts ...postgres.Option) ORM {
+	return postgres.NewPostgresORMFromConnector(connector, opts...)
 }
 
 // Connect é uma função auxiliar para conectar ao banco de dados PostgreSQL
-func Connect(ctx context.Context, connectionString string) (ORM, error) {
-	orm := NewPostgresORM()
+func Connect(ctx context.Context, connectionString string, opts ...postgres.Option) (ORM, error) {
+	orm := NewPostgresORM(opts...)
 	err := orm.Connect(ctx, connectionString)
 	if err != nil {
 		return nil, err
 	}
 	return orm, nil
 }
+
+// NewMemoryORM cria uma nova instância do ORM em memória, para testes unitários do código
+// que depende do ORM sem um banco de dados
+func NewMemoryORM(opts ...memory.Option) ORM {
+	return memory.NewMemoryORM(opts...)
+}
+
+// WithPreload retorna um contexto que instrui FindByID e FindAll a carregar as relações informadas
+func WithPreload(ctx context.Context, relations ...string) context.Context {
+	return core.WithPreload(ctx, relations...)
+}
+
+// WithSearchPath retorna um contexto que instrui Transaction e AutoMigrate a definir o
+// search_path da transação com os esquemas informados
+func WithSearchPath(ctx context.Context, schemas ...string) context.Context {
+	return core.WithSearchPath(ctx, schemas...)
+}
+
+// WithPrimary retorna um contexto que instrui as leituras a usar o banco primário em vez
+// das réplicas
+func WithPrimary(ctx context.Context) context.Context {
+	return core.WithPrimary(ctx)
+}
+
+// WithReplica retorna um contexto que permite enviar as consultas personalizadas de
+// Query às réplicas
+func WithReplica(ctx context.Context) context.Context {
+	return core.WithReplica(ctx)
+}
+
+// AutoMigrate cria as tabelas ausentes e adiciona as colunas e índices ausentes dos modelos
+func AutoMigrate(ctx context.Context, orm ORM, models ...Model) error {
+	migrator, ok := orm.(Migrator)
+	if !ok {
+		return fmt.Errorf("o ORM %T não suporta AutoMigrate", orm)
+	}
+	return migrator.AutoMigrate(ctx, models...)
+}
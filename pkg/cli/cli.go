@@ -0,0 +1,139 @@
+// Package cli implements the night-orm command line tool.
+//
+// The cmd/night-orm binary runs it without models, which covers migrations, seeds
+// and code generation. To compare your models with the database (db diff), build
+// your own binary that registers them first, with the ORM options naming their tables:
+//
+//	func main() {
+//		schema.Register(&models.User{}, &models.Post{})
+//		cli.SetORMOptions(postgres.WithDefaultSchema("app"))
+//		os.Exit(cli.Main(os.Args[1:]))
+//	}
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/postgres"
+)
+
+// ormOptions are the options of the ORM opened by the commands
+var ormOptions []postgres.Option
+
+// SetORMOptions sets the options of the ORM opened by the commands, such as the naming
+// strategy and default schema used by db diff to resolve the tables of the registered
+// models. Call it before Main.
+func SetORMOptions(opts ...postgres.Option) {
+	ormOptions = opts
+}
+
+// ErrUsage is returned when the command line is invalid; the usage is printed
+var ErrUsage = errors.New("invalid usage")
+
+// usage describes the available commands
+const usage = `Usage: night-orm <command> <subcommand> [flags]
+
+Commands:
+  migrate up       Apply all pending migrations, or those up to version -to
+  migrate down     Revert the last applied migrations (-n, default 1)
+  migrate status   List migrations and whether they were applied
+  migrate create   Create an empty migration pair: migrate create <name>
+  db diff          Compare the registered models with the database; needs a
+                   binary that calls schema.Register before cli.Main
+  db seed          Run the SQL seed files in one transaction
+  gen models       Generate Go models from the database tables
+  gen accessors    Generate model methods and field accessors (go generate)
+
+The connection string is read from -database-url or the DATABASE_URL variable.
+Run "night-orm <command> <subcommand> -h" for the flags of a subcommand.
+`
+
+// command runs a subcommand with its arguments
+type command func(ctx context.Context, args []string, stdout io.Writer) error
+
+// commands lists the subcommands by command name
+var commands = map[string]map[string]command{
+	"migrate": {
+		"up":     migrateUp,
+		"down":   migrateDown,
+		"status": migrateStatus,
+		"create": migrateCreate,
+	},
+	"db": {
+		"diff": dbDiff,
+		"seed": dbSeed,
+	},
+	"gen": {
+		"models":    genModels,
+		"accessors": genAccessors,
+	},
+}
+
+// Main runs the tool with the given arguments, printing errors to stderr, and
+// returns the process exit code
+func Main(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := Run(ctx, args, os.Stdout); err != nil {
+		if errors.Is(err, ErrUsage) {
+			if err != ErrUsage {
+				fmt.Fprintf(os.Stderr, "night-orm: %v\n\n", err)
+			}
+			fmt.Fprint(os.Stderr, usage)
+			return 2
+		}
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "night-orm: %v\n", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// Run executes the command selected by args, writing its output to stdout
+func Run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) < 2 {
+		return ErrUsage
+	}
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+	}
+	run, ok := subcommands[args[1]]
+	if !ok {
+		return fmt.Errorf("%w: unknown subcommand %q", ErrUsage, args[0]+" "+args[1])
+	}
+	return run(ctx, args[2:], stdout)
+}
+
+// newFlagSet returns the flag set of a subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("night-orm "+name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	return flags
+}
+
+// databaseURL registers the -database-url flag, defaulting to DATABASE_URL
+func databaseURL(flags *flag.FlagSet) *string {
+	return flags.String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (default $DATABASE_URL)")
+}
+
+// connect opens the database given by the connection string
+func connect(ctx context.Context, url string) (*postgres.PostgresORM, error) {
+	if strings.TrimSpace(url) == "" {
+		return nil, errors.New("missing connection string: set -database-url or DATABASE_URL")
+	}
+	orm := postgres.NewPostgresORM(ormOptions...)
+	if err := orm.Connect(ctx, url); err != nil {
+		return nil, err
+	}
+	return orm, nil
+}
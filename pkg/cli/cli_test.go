@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/migrate"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Usage", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"migrate"}, {"unknown", "up"}, {"migrate", "sideways"}} {
+			if err := Run(ctx, args, &bytes.Buffer{}); !errors.Is(err, ErrUsage) {
+				t.Errorf("Expected ErrUsage for %v, got %v", args, err)
+			}
+		}
+	})
+
+	t.Run("MigrateCreate", func(t *testing.T) {
+		dir := t.TempDir()
+		var out bytes.Buffer
+		if err := Run(ctx, []string{"migrate", "create", "-dir", dir, "create", "users"}, &out); err != nil {
+			t.Fatalf("Run returned error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "0001_create_users.up.sql")); err != nil {
+			t.Errorf("Expected up file to be created: %v", err)
+		}
+		if !strings.Contains(out.String(), "0001_create_users.down.sql") {
+			t.Errorf("Expected created files in output, got %q", out.String())
+		}
+	})
+
+	t.Run("MissingDatabaseURL", func(t *testing.T) {
+		t.Setenv("DATABASE_URL", "")
+		err := Run(ctx, []string{"migrate", "status"}, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "DATABASE_URL") {
+			t.Errorf("Expected missing connection string error, got %v", err)
+		}
+	})
+
+	t.Run("DiffWithoutModels", func(t *testing.T) {
+		err := Run(ctx, []string{"db", "diff", "-database-url", "postgres://localhost/db"}, &bytes.Buffer{})
+		if !errors.Is(err, ErrNoModels) {
+			t.Errorf("Expected ErrNoModels, got %v", err)
+		}
+	})
+
+	t.Run("SeedWithoutFiles", func(t *testing.T) {
+		err := Run(ctx, []string{"db", "seed", "-dir", t.TempDir()}, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "no .sql seed files") {
+			t.Errorf("Expected missing seeds error, got %v", err)
+		}
+	})
+}
+
+func TestPrintStatus(t *testing.T) {
+	appliedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var out bytes.Buffer
+	printStatus(&out, []migrate.Status{
+		{Version: 1, Name: "create_users", Applied: true, AppliedAt: appliedAt},
+		{Version: 2, Name: "add_email"},
+		{Version: 3, Name: "gone", Applied: true, AppliedAt: appliedAt, Missing: true},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header and 3 rows, got %q", out.String())
+	}
+	if !strings.Contains(lines[1], "applied") || !strings.Contains(lines[1], "2024-01-02T03:04:05Z") {
+		t.Errorf("Unexpected applied row %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "pending") || !strings.Contains(lines[3], "missing") {
+		t.Errorf("Unexpected status rows %q", lines[2:])
+	}
+}
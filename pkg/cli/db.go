@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rodolfocoding/night-orm/pkg/migrate"
+	"github.com/rodolfocoding/night-orm/pkg/schema"
+)
+
+// ErrNoModels is returned by db diff when no model was registered with schema.Register
+var ErrNoModels = errors.New("no models registered: build a binary that calls schema.Register before cli.Main")
+
+// dbDiff compares the registered models with the database, printing the proposed
+// migration or the JSON report and optionally writing the migration files
+func dbDiff(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("db diff")
+	url := databaseURL(flags)
+	asJSON := flags.Bool("json", false, "print the machine-readable drift report")
+	write := flags.String("write", "", "write the proposed migration pair with this name")
+	dir := flags.String("dir", "migrations", "directory of the migration files written by -write")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", flags.Name())
+		fmt.Fprintln(flags.Output(), "  Only works from a binary that calls schema.Register before cli.Main; the stock")
+		fmt.Fprintln(flags.Output(), "  night-orm binary has no models and fails with ErrNoModels.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	models := schema.Models()
+	if len(models) == 0 {
+		return ErrNoModels
+	}
+
+	orm, err := connect(ctx, *url)
+	if err != nil {
+		return err
+	}
+	defer orm.Close()
+
+	report, err := schema.DiffModelsWith(ctx, orm.Mapper(), orm.DB(), models...)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := report.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(data))
+	} else if report.HasDrift() {
+		fmt.Fprintf(stdout, "-- up\n%s\n-- down\n%s", report.UpSQL(), report.DownSQL())
+	} else {
+		fmt.Fprintln(stdout, "no drift between models and database")
+	}
+
+	if *write != "" && report.HasDrift() {
+		up, down, err := migrate.Write(*dir, *write, report.UpSQL(), report.DownSQL())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "created %s\ncreated %s\n", up, down)
+	}
+	return nil
+}
+
+// dbSeed runs the .sql files of the seed directory in name order, all in one transaction
+func dbSeed(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("db seed")
+	url := databaseURL(flags)
+	dir := flags.String("dir", "seeds", "directory of the SQL seed files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	files, err := seedFiles(*dir)
+	if err != nil {
+		return err
+	}
+
+	orm, err := connect(ctx, *url)
+	if err != nil {
+		return err
+	}
+	defer orm.Close()
+
+	tx, err := orm.Transaction(ctx)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error reading seed %s: %w", file, err)
+		}
+		if _, err := tx.Exec(ctx, string(content)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error running seed %s: %w", file, err)
+		}
+		fmt.Fprintf(stdout, "seeded %s\n", file)
+	}
+	return tx.Commit()
+}
+
+// seedFiles returns the .sql files of dir sorted by name
+func seedFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql seed files found in %s", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/gen"
+	"github.com/rodolfocoding/night-orm/pkg/migrate"
+)
+
+// genModels generates Go models from the database tables
+func genModels(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("gen models")
+	url := databaseURL(flags)
+	schemaName := flags.String("schema", "", "database schema to read (default current schema)")
+	pkg := flags.String("package", "models", "package name of the generated file")
+	out := flags.String("out", "", "file to write (default stdout)")
+	include := flags.String("include", "", "comma-separated glob patterns of the tables to generate")
+	exclude := flags.String("exclude", migrate.DefaultTable, "comma-separated glob patterns of the tables to skip")
+	types := typeFlag{}
+	flags.Var(types, "type", "Go type override as key=type, where key is table.column or a SQL type (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	orm, err := connect(ctx, *url)
+	if err != nil {
+		return err
+	}
+	defer orm.Close()
+
+	source, err := gen.Models(ctx, orm.DB(), gen.Options{
+		Package: *pkg,
+		Schema:  *schemaName,
+		Include: splitList(*include),
+		Exclude: splitList(*exclude),
+		Types:   types,
+	})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = stdout.Write(source)
+		return err
+	}
+	if err := os.WriteFile(*out, source, 0o644); err != nil {
+		return fmt.Errorf("error writing models: %w", err)
+	}
+	fmt.Fprintf(stdout, "created %s\n", *out)
+	return nil
+}
+
+// typeFlag collects repeated -type key=type flags
+type typeFlag map[string]string
+
+func (f typeFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f typeFlag) Set(value string) error {
+	key, goType, ok := strings.Cut(value, "=")
+	if !ok || key == "" || goType == "" {
+		return fmt.Errorf("expected key=type, got %q", value)
+	}
+	f[key] = goType
+	return nil
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// genAccessors writes the generated model methods and field accessors of a package,
+// meant to run from a //go:generate directive
+func genAccessors(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("gen accessors")
+	dir := flags.String("dir", ".", "directory of the package with the models")
+	types := flags.String("type", "", "comma-separated structs to generate (default every struct with db tags)")
+	out := flags.String("out", "night_orm_gen.go", "file to write, relative to -dir")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	source, err := gen.Accessors(*dir, gen.AccessorOptions{Types: splitList(*types)})
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(*dir, *out)
+	if err := os.WriteFile(path, source, 0o644); err != nil {
+		return fmt.Errorf("error writing accessors: %w", err)
+	}
+	fmt.Fprintf(stdout, "created %s\n", path)
+	return nil
+}
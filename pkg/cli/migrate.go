@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/migrate"
+)
+
+// migrateFlags are the flags shared by the migrate subcommands
+type migrateFlags struct {
+	databaseURL *string
+	dir         *string
+	table       *string
+}
+
+// newMigrateFlags registers the flags shared by the migrate subcommands
+func newMigrateFlags(name string) (*migrateFlags, *flag.FlagSet) {
+	flags := newFlagSet("migrate " + name)
+	return &migrateFlags{
+		databaseURL: databaseURL(flags),
+		dir:         flags.String("dir", "migrations", "directory of the migration files"),
+		table:       flags.String("table", migrate.DefaultTable, "table tracking applied migrations"),
+	}, flags
+}
+
+// migrator connects to the database and loads the migrations
+func (f *migrateFlags) migrator(ctx context.Context, run func(m *migrate.Migrator) error) error {
+	orm, err := connect(ctx, *f.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer orm.Close()
+
+	m, err := migrate.New(orm.DB(), os.DirFS(*f.dir), ".", migrate.WithTable(*f.table))
+	if err != nil {
+		return err
+	}
+	return run(m)
+}
+
+// migrateUp applies the pending migrations, or those up to -to when given
+func migrateUp(ctx context.Context, args []string, stdout io.Writer) error {
+	f, flags := newMigrateFlags("up")
+	to := flags.Uint64("to", 0, "apply the pending migrations up to this version only; use migrate down to revert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return f.migrator(ctx, func(m *migrate.Migrator) error {
+		if *to != 0 {
+			if err := m.UpTo(ctx, *to); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "migrations applied up to version %d\n", *to)
+			return nil
+		}
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "migrations applied")
+		return nil
+	})
+}
+
+// migrateDown reverts the last -n applied migrations
+func migrateDown(ctx context.Context, args []string, stdout io.Writer) error {
+	f, flags := newMigrateFlags("down")
+	n := flags.Int("n", 1, "number of migrations to revert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return f.migrator(ctx, func(m *migrate.Migrator) error {
+		if err := m.Down(ctx, *n); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "reverted up to %d migration(s)\n", *n)
+		return nil
+	})
+}
+
+// migrateStatus prints a table of the migrations and whether they were applied
+func migrateStatus(ctx context.Context, args []string, stdout io.Writer) error {
+	f, flags := newMigrateFlags("status")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return f.migrator(ctx, func(m *migrate.Migrator) error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(stdout, statuses)
+		return nil
+	})
+}
+
+// printStatus writes the migration statuses as an aligned table
+func printStatus(stdout io.Writer, statuses []migrate.Status) {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Missing {
+			state = "missing"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+}
+
+// migrateCreate writes an empty pair of migration files
+func migrateCreate(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("migrate create")
+	dir := flags.String("dir", "migrations", "directory of the migration files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("%w: migrate create requires a name", ErrUsage)
+	}
+
+	up, down, err := migrate.Write(*dir, strings.Join(flags.Args(), "_"), "", "")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "created %s\ncreated %s\n", up, down)
+	return nil
+}
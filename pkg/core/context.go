@@ -0,0 +1,66 @@
+package core
+
+import "context"
+
+type preloadKey struct{}
+
+// WithPreload retorna um contexto que instrui FindByID e FindAll a carregar as relações
+// informadas, incluindo caminhos aninhados como "Items.Product"
+func WithPreload(ctx context.Context, relations ...string) context.Context {
+	existing := PreloadFromContext(ctx)
+	merged := make([]string, 0, len(existing)+len(relations))
+	merged = append(merged, existing...)
+	merged = append(merged, relations...)
+	return context.WithValue(ctx, preloadKey{}, merged)
+}
+
+// PreloadFromContext retorna as relações registradas no contexto por WithPreload
+func PreloadFromContext(ctx context.Context) []string {
+	relations, _ := ctx.Value(preloadKey{}).([]string)
+	return relations
+}
+
+type searchPathKey struct{}
+
+// WithSearchPath retorna um contexto que instrui Transaction e AutoMigrate a definir o
+// search_path da transação com os esquemas informados, na ordem de busca
+func WithSearchPath(ctx context.Context, schemas ...string) context.Context {
+	return context.WithValue(ctx, searchPathKey{}, schemas)
+}
+
+// SearchPathFromContext retorna os esquemas registrados no contexto por WithSearchPath
+func SearchPathFromContext(ctx context.Context) []string {
+	schemas, _ := ctx.Value(searchPathKey{}).([]string)
+	return schemas
+}
+
+type primaryKey struct{}
+
+// WithPrimary retorna um contexto que instrui as leituras a usar o banco primário em vez
+// das réplicas, para ler as próprias escritas logo após realizá-las
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryFromContext informa se o contexto exige leituras no banco primário, conforme
+// registrado por WithPrimary
+func PrimaryFromContext(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+type replicaKey struct{}
+
+// WithReplica retorna um contexto que permite enviar as consultas SQL personalizadas de
+// Query às réplicas. Sem ele, Query usa sempre o banco primário, pois uma consulta que
+// começa com SELECT ainda pode alterar dados ao chamar funções.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey{}, true)
+}
+
+// ReplicaFromContext informa se o contexto permite consultas personalizadas nas réplicas,
+// conforme registrado por WithReplica
+func ReplicaFromContext(ctx context.Context) bool {
+	replica, _ := ctx.Value(replicaKey{}).(bool)
+	return replica
+}
@@ -0,0 +1,30 @@
+package core
+
+import "errors"
+
+// Erros retornados pelas implementações do ORM. Os erros do driver são traduzidos para
+// estes valores e podem ser verificados com errors.Is, independentemente do banco:
+//
+//	if errors.Is(err, core.ErrDuplicate) { ... }
+var (
+	// ErrNotFound indica que nenhum registro corresponde à chave primária informada
+	ErrNotFound = errors.New("registro não encontrado")
+
+	// ErrDuplicate indica a violação de uma restrição de chave primária ou de unicidade
+	ErrDuplicate = errors.New("registro já existe")
+
+	// ErrForeignKey indica a violação de uma chave estrangeira: o registro referenciado
+	// não existe ou ainda é referenciado por outros registros
+	ErrForeignKey = errors.New("violação de chave estrangeira")
+
+	// ErrNotNull indica que uma coluna obrigatória recebeu NULL
+	ErrNotNull = errors.New("coluna obrigatória sem valor")
+
+	// ErrDeadlock indica que a transação foi abortada por um deadlock ou conflito de
+	// serialização e pode ser repetida
+	ErrDeadlock = errors.New("transação abortada por conflito de concorrência")
+
+	// ErrLockTimeout indica que a espera por um lock excedeu o tempo limite; a instrução
+	// falhou, mas a transação pode continuar ativa, dependendo do banco
+	ErrLockTimeout = errors.New("tempo de espera por lock excedido")
+)
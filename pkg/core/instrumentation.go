@@ -0,0 +1,30 @@
+package core
+
+import "context"
+
+// Operation descreve uma chamada ao ORM ou a uma transação instrumentada
+type Operation struct {
+	// Name é o nome da operação, como "create", "find_by_id" ou "commit"
+	Name string
+	// Table é a tabela do modelo da operação, quando houver
+	Table string
+	// Statement é a instrução das operações Query e Exec; nas demais, as instruções são
+	// informadas ao Span à medida que são executadas
+	Statement string
+}
+
+// Instrumentation inicia um Span em torno de cada chamada ao ORM e às transações, para
+// rastreamento e métricas de latência
+type Instrumentation interface {
+	// StartSpan inicia o Span da operação e retorna o contexto usado pela operação, que
+	// pode carregar o Span como pai dos Spans aninhados
+	StartSpan(ctx context.Context, op Operation) (context.Context, Span)
+}
+
+// Span acompanha uma operação iniciada por Instrumentation.StartSpan
+type Span interface {
+	// SetStatement informa uma instrução executada pela operação
+	SetStatement(statement string)
+	// End encerra o Span com o erro da operação, se houver
+	End(err error)
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Redacted substitui, nos eventos do Logger, os argumentos das colunas marcadas com a
+// opção "sensitive" na tag "db"
+const Redacted = "[REDACTED]"
+
+// QueryEvent descreve uma instrução executada pelo ORM
+type QueryEvent struct {
+	// SQL é a instrução enviada ao banco de dados
+	SQL string
+	// Args são os argumentos da instrução, com os valores sensíveis substituídos por Redacted
+	Args []interface{}
+	// Duration é o tempo de execução da instrução
+	Duration time.Duration
+	// RowsAffected é o número de linhas afetadas, ou -1 para consultas
+	RowsAffected int64
+	// Err é o erro retornado pelo banco de dados, se houver
+	Err error
+	// Slow indica que a instrução excedeu o limite de consulta lenta configurado
+	Slow bool
+}
+
+// Logger recebe um evento para cada instrução executada pelo ORM e pelas transações
+type Logger interface {
+	LogQuery(ctx context.Context, event QueryEvent)
+}
+
+// SlogLogger adapta um *slog.Logger à interface Logger. As instruções são registradas
+// no nível Debug, as lentas no nível Warn e as que falharam no nível Error.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger cria um Logger que registra as instruções no logger informado, ou em
+// slog.Default() quando ele é nil
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger}
+}
+
+// LogQuery registra o evento com os atributos sql, args, duration, rows e error
+func (l *SlogLogger) LogQuery(ctx context.Context, event QueryEvent) {
+	level, message := slog.LevelDebug, "query"
+	switch {
+	case event.Err != nil:
+		level, message = slog.LevelError, "query failed"
+	case event.Slow:
+		level, message = slog.LevelWarn, "slow query"
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", event.SQL),
+		slog.Any("args", event.Args),
+		slog.Duration("duration", event.Duration),
+	}
+	if event.RowsAffected >= 0 {
+		attrs = append(attrs, slog.Int64("rows", event.RowsAffected))
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.Any("error", event.Err))
+	}
+	l.logger.LogAttrs(ctx, level, message, attrs...)
+}
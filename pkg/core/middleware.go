@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+)
+
+// OpKind identifica o tipo de uma operação interceptada pelos middlewares
+type OpKind string
+
+// Tipos das operações interceptadas
+const (
+	OpCreate OpKind = "create"
+	OpFind   OpKind = "find"
+	OpUpdate OpKind = "update"
+	OpDelete OpKind = "delete"
+	OpQuery  OpKind = "query"
+	OpExec   OpKind = "exec"
+)
+
+// Op descreve uma operação do ORM ou de uma transação, com a instrução já construída,
+// ao passar pela cadeia de middlewares
+type Op struct {
+	// Kind é o tipo da operação
+	Kind OpKind
+	// Table é a tabela do modelo, vazia nas operações Query e Exec
+	Table string
+	// Model é o modelo de Create, FindByID, Update e Delete, ou o ponteiro para a slice de
+	// destino de FindAll; é nil nas operações Query e Exec
+	Model interface{}
+	// SQL é a instrução que será executada; os middlewares podem reescrevê-la antes de
+	// chamar o próximo
+	SQL string
+	// Args são os argumentos da instrução, que também podem ser reescritos
+	Args []interface{}
+
+	// Rows recebe as linhas retornadas pelas operações Query
+	Rows *sql.Rows
+	// Result recebe o resultado das operações Exec, Update e Delete, e de Create nos
+	// modelos sem chave primária
+	Result sql.Result
+}
+
+// Handler executa uma operação; é o próximo elemento da cadeia de middlewares
+type Handler func(ctx context.Context, op *Op) error
+
+// Middleware intercepta uma operação antes da sua execução. Ele pode reescrever op.SQL
+// e op.Args, observar o resultado após chamar next, ou encerrar a operação sem chamar
+// next. Ao encerrá-la, o middleware preenche o modelo, op.Rows ou op.Result conforme o
+// tipo da operação; um Update ou Delete sem op.Result é considerado bem-sucedido.
+type Middleware func(ctx context.Context, op *Op, next Handler) error
+
+// Chain compõe os middlewares em torno do handler. O primeiro middleware é o mais
+// externo, então recebe a operação antes dos demais.
+func Chain(middleware []Middleware, handler Handler) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw, next := middleware[i], handler
+		handler = func(ctx context.Context, op *Op) error {
+			return mw(ctx, op, next)
+		}
+	}
+	return handler
+}
@@ -1,14 +1,27 @@
 package core
 
-// Model é a interface que todos os modelos devem implementar
-type Model interface {
+// Model representa um modelo: qualquer estrutura (ou ponteiro para estrutura) com tags "db".
+// O nome da tabela vem do método TableName, quando existir, da tag "table" de um campo
+// marcador ou da estratégia de nomes do ORM. O esquema vem de um nome qualificado como
+// "billing.invoices", do método Schema, da tag "schema" ou do esquema padrão do ORM.
+type Model interface{}
+
+// TableNamer é implementado pelos modelos que definem explicitamente o nome da tabela
+type TableNamer interface {
 	// TableName retorna o nome da tabela no banco de dados
 	TableName() string
 }
 
-// ModelWithPrimaryKey é uma interface para modelos com chave primária
+// SchemaNamer é implementado pelos modelos que definem explicitamente o esquema da tabela
+type SchemaNamer interface {
+	// Schema retorna o esquema do banco de dados ao qual a tabela pertence
+	Schema() string
+}
+
+// ModelWithPrimaryKey é uma interface para modelos que definem explicitamente a tabela e
+// a chave primária. Modelos sem esses métodos usam a coluna com a opção "primary" ou o campo ID.
 type ModelWithPrimaryKey interface {
-	Model
+	TableNamer
 	// PrimaryKey retorna o nome da coluna de chave primária
 	PrimaryKey() string
 	// PrimaryKeyValue retorna o valor da chave primária
@@ -9,58 +9,91 @@ import (
 type ORM interface {
 	// Connect estabelece uma conexão com o banco de dados
 	Connect(ctx context.Context, connectionString string) error
-	
+
 	// Close fecha a conexão com o banco de dados
 	Close() error
-	
+
 	// DB retorna a conexão subjacente com o banco de dados
 	DB() *sql.DB
-	
+
 	// Create insere um novo registro no banco de dados
 	Create(ctx context.Context, model Model) error
-	
+
 	// FindByID busca um registro pelo ID
-	FindByID(ctx context.Context, model ModelWithPrimaryKey, id interface{}) error
-	
+	FindByID(ctx context.Context, model Model, id interface{}) error
+
 	// FindAll busca todos os registros de um modelo
 	FindAll(ctx context.Context, model Model, dest interface{}) error
-	
+
 	// Update atualiza um registro existente
-	Update(ctx context.Context, model ModelWithPrimaryKey) error
-	
+	Update(ctx context.Context, model Model) error
+
 	// Delete remove um registro do banco de dados
-	Delete(ctx context.Context, model ModelWithPrimaryKey) error
-	
+	Delete(ctx context.Context, model Model) error
+
 	// Query executa uma consulta SQL personalizada
 	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
-	
+
 	// Exec executa um comando SQL personalizado
 	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
-	
+
+	// Preload carrega as relações informadas em um modelo ou slice de modelos já carregados
+	Preload(ctx context.Context, dest interface{}, relations ...string) error
+
 	// Transaction inicia uma nova transação
 	Transaction(ctx context.Context) (Transaction, error)
 }
 
+// Migrator é implementado pelos ORMs capazes de criar e alterar tabelas a partir dos modelos
+type Migrator interface {
+	// AutoMigrate cria as tabelas ausentes e adiciona as colunas e índices ausentes dos modelos,
+	// sem remover dados
+	AutoMigrate(ctx context.Context, models ...Model) error
+}
+
 // Transaction representa uma transação de banco de dados
 type Transaction interface {
 	// Commit confirma a transação
 	Commit() error
-	
+
 	// Rollback reverte a transação
 	Rollback() error
-	
+
 	// Create insere um novo registro dentro da transação
 	Create(ctx context.Context, model Model) error
-	
+
 	// Update atualiza um registro dentro da transação
-	Update(ctx context.Context, model ModelWithPrimaryKey) error
-	
+	Update(ctx context.Context, model Model) error
+
 	// Delete remove um registro dentro da transação
-	Delete(ctx context.Context, model ModelWithPrimaryKey) error
-	
+	Delete(ctx context.Context, model Model) error
+
 	// Query executa uma consulta SQL personalizada dentro da transação
 	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
-	
+
 	// Exec executa um comando SQL personalizado dentro da transação
 	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+	// Preload carrega as relações informadas dentro da transação
+	Preload(ctx context.Context, dest interface{}, relations ...string) error
+
+	// Association retorna as operações da relação many_to_many informada do modelo,
+	// executadas dentro da transação
+	Association(model Model, relation string) (Association, error)
+}
+
+// Association manipula os registros da tabela de junção de uma relação many_to_many.
+// Cada operação executa uma única instrução SQL e atualiza o campo da relação no modelo.
+type Association interface {
+	// Append associa os modelos informados, ignorando associações já existentes
+	Append(ctx context.Context, related ...interface{}) error
+
+	// Remove desfaz a associação com os modelos informados
+	Remove(ctx context.Context, related ...interface{}) error
+
+	// Replace substitui todas as associações pelos modelos informados
+	Replace(ctx context.Context, related ...interface{}) error
+
+	// Clear remove todas as associações do modelo
+	Clear(ctx context.Context) error
 }
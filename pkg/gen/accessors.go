@@ -0,0 +1,321 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// accessorsHeader marks the files written by Accessors, which are skipped when parsing
+const accessorsHeader = "// Code generated by night-orm gen accessors. DO NOT EDIT."
+
+// AccessorOptions configures the accessor generator
+type AccessorOptions struct {
+	// Types lists the structs to generate; empty means every struct with a db tag
+	Types []string
+	// Naming derives the table and column names not declared in tags; it must match the
+	// naming strategy of the ORM. Nil means utils.SnakeCaseNaming.
+	Naming utils.NamingStrategy
+}
+
+// modelStruct is a parsed struct declaration
+type modelStruct struct {
+	name    string
+	table   string
+	fields  []modelField
+	methods map[string]bool
+}
+
+// modelField is a mapped field of a parsed struct
+type modelField struct {
+	name    string
+	column  string
+	primary bool
+}
+
+// Accessors parses the Go files of the package in dir and returns the source
+// declaring, for each model struct, the TableName, PrimaryKey and PrimaryKeyValue
+// methods not written by hand, a <Struct>Columns variable with the column names and
+// the utils.FieldAccessor methods the ORM uses to read and scan fields without reflection.
+// The accessors return the fields as they are; the mapper wraps the array columns, so
+// both paths bind the same values.
+func Accessors(dir string, opts AccessorOptions) ([]byte, error) {
+	pkg, structs, err := parseModels(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]*modelStruct, 0, len(structs))
+	if len(opts.Types) == 0 {
+		for _, model := range structs {
+			if len(model.fields) > 0 && model.tagged() {
+				selected = append(selected, model)
+			}
+		}
+		sort.Slice(selected, func(i, j int) bool { return selected[i].name < selected[j].name })
+	} else {
+		for _, name := range opts.Types {
+			model, ok := structs[name]
+			if !ok {
+				return nil, fmt.Errorf("struct %s not found in package %s", name, pkg)
+			}
+			selected = append(selected, model)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no struct with db tags found in package %s", pkg)
+	}
+
+	naming := opts.Naming
+	if naming == nil {
+		naming = utils.SnakeCaseNaming{}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\n", accessorsHeader, pkg)
+	for _, model := range selected {
+		writeAccessors(&out, model, naming)
+	}
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated accessors: %w", err)
+	}
+	return source, nil
+}
+
+// tagged reports whether any field of the struct declares a db tag
+func (m *modelStruct) tagged() bool {
+	for _, field := range m.fields {
+		if field.column != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseModels parses the non-test, non-generated Go files of dir
+func parseModels(dir string) (string, map[string]*modelStruct, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+	pkg := ""
+	structs := make(map[string]*modelStruct)
+	methods := make(map[string]map[string]bool)
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+		if bytes.HasPrefix(content, []byte(accessorsHeader)) {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		pkg = file.Name.Name
+
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					if structType, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil {
+						table, fields := parseFields(structType)
+						structs[typeSpec.Name.Name] = &modelStruct{name: typeSpec.Name.Name, table: table, fields: fields}
+					}
+				}
+			case *ast.FuncDecl:
+				if receiver := receiverName(decl); receiver != "" {
+					if methods[receiver] == nil {
+						methods[receiver] = make(map[string]bool)
+					}
+					methods[receiver][decl.Name.Name] = true
+				}
+			}
+		}
+	}
+	if pkg == "" {
+		return "", nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	for name, model := range structs {
+		model.methods = methods[name]
+		if model.methods == nil {
+			model.methods = make(map[string]bool)
+		}
+	}
+	return pkg, structs, nil
+}
+
+// parseFields returns the table declared by a table tag, if any, and the mapped
+// fields of a struct, following the rules of utils.GetFields
+func parseFields(structType *ast.StructType) (string, []modelField) {
+	table := ""
+	fields := make([]modelField, 0, len(structType.Fields.List))
+	for _, field := range structType.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			if value, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(value)
+			}
+		}
+		if name, ok := tag.Lookup("table"); ok && name != "" && table == "" {
+			table = name
+		}
+		dbTag := tag.Get("db")
+		if _, isRelation := tag.Lookup("rel"); dbTag == "-" || isRelation {
+			continue
+		}
+		column, options := utils.ParseTag(dbTag)
+
+		names := field.Names
+		if len(names) == 0 {
+			// Embedded fields are mapped as one column named after their type, as
+			// utils.Mapper.Fields does
+			names = []*ast.Ident{embeddedName(field.Type)}
+		}
+		for _, name := range names {
+			if name == nil || !name.IsExported() {
+				continue
+			}
+			fields = append(fields, modelField{name: name.Name, column: column, primary: options.Has("primary")})
+		}
+	}
+	return table, fields
+}
+
+// embeddedName returns the field name of an embedded type, such as Base for *Base,
+// Time for time.Time or Page for Page[T], or nil when it has none
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.StarExpr:
+		return embeddedName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel
+	case *ast.IndexExpr:
+		return embeddedName(expr.X)
+	case *ast.IndexListExpr:
+		return embeddedName(expr.X)
+	}
+	return nil
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	expr := decl.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// writeAccessors writes the generated declarations of a struct
+func writeAccessors(w *bytes.Buffer, model *modelStruct, naming utils.NamingStrategy) {
+	name := model.name
+	receiver := string(unicode.ToLower(rune(name[0])))
+
+	columns := make([]modelField, len(model.fields))
+	for i, field := range model.fields {
+		if field.column == "" {
+			field.column = naming.ColumnName(field.name)
+		}
+		columns[i] = field
+	}
+
+	if !model.methods["TableName"] {
+		table := model.table
+		if table == "" {
+			table = naming.TableName(name)
+		}
+		fmt.Fprintf(w, "// TableName returns the table of %s\nfunc (%s *%s) TableName() string {\n\treturn %q\n}\n\n",
+			name, receiver, name, table)
+	}
+
+	if primary, ok := primaryField(columns); ok {
+		if !model.methods["PrimaryKey"] {
+			fmt.Fprintf(w, "// PrimaryKey returns the primary key column of %s\nfunc (%s *%s) PrimaryKey() string {\n\treturn %q\n}\n\n",
+				name, receiver, name, primary.column)
+		}
+		if !model.methods["PrimaryKeyValue"] {
+			fmt.Fprintf(w, "// PrimaryKeyValue returns the primary key value of %s\nfunc (%s *%s) PrimaryKeyValue() interface{} {\n\treturn %s.%s\n}\n\n",
+				name, receiver, name, receiver, primary.name)
+		}
+	}
+
+	fmt.Fprintf(w, "// %sColumns holds the column names of %s\nvar %sColumns = struct {\n", name, name, name)
+	for _, field := range columns {
+		fmt.Fprintf(w, "\t%s string\n", field.name)
+	}
+	w.WriteString("}{\n")
+	for _, field := range columns {
+		fmt.Fprintf(w, "\t%s: %q,\n", field.name, field.column)
+	}
+	w.WriteString("}\n\n")
+
+	fmt.Fprintf(w, "// FieldValues returns the value of each column of %s\nfunc (%s *%s) FieldValues() map[string]interface{} {\n\treturn map[string]interface{}{\n",
+		name, receiver, name)
+	for _, field := range columns {
+		fmt.Fprintf(w, "\t\t%q: %s.%s,\n", field.column, receiver, field.name)
+	}
+	w.WriteString("\t}\n}\n\n")
+
+	fmt.Fprintf(w, "// FieldPointer returns the scan destination of the field of %s mapped to column, or nil\nfunc (%s *%s) FieldPointer(column string) interface{} {\n\tswitch column {\n",
+		name, receiver, name)
+	for _, field := range columns {
+		fmt.Fprintf(w, "\tcase %q:\n\t\treturn &%s.%s\n", field.column, receiver, field.name)
+	}
+	w.WriteString("\t}\n\treturn nil\n}\n\n")
+}
+
+// primaryField returns the field tagged as primary key or, like utils.GetPrimaryKeyField,
+// the field named ID
+func primaryField(fields []modelField) (modelField, bool) {
+	for _, field := range fields {
+		if field.primary {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, "id") {
+			return field, true
+		}
+	}
+	return modelField{}, false
+}
+
+// TableName returns the default table of a struct: its name in plural snake_case,
+// as derived by utils.SnakeCaseNaming
+func TableName(structName string) string {
+	return utils.SnakeCaseNaming{}.TableName(structName)
+}
@@ -0,0 +1,147 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+const modelsSource = `package models
+
+import "time"
+
+type User struct {
+	ID        int64     ` + "`db:\"id,primary\"`" + `
+	Email     string    ` + "`db:\"email,unique\"`" + `
+	Tags      []string  ` + "`db:\"tags\"`" + `
+	Avatar    []byte    ` + "`db:\"avatar\"`" + `
+	CreatedAt time.Time ` + "`db:\"created_at\"`" + `
+	Posts     []Post    ` + "`rel:\"has_many\"`" + `
+	Secret    string    ` + "`db:\"-\"`" + `
+	internal  string
+}
+
+type Category struct {
+	ID   int    ` + "`db:\"id\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+
+func (c *Category) TableName() string { return "shop_categories" }
+
+type Post struct {
+	_      struct{} ` + "`table:\"blog_posts\"`" + `
+	ID     int64 ` + "`db:\"id,primary\"`" + `
+	UserID int64 ` + "`db:\"user_id\"`" + `
+	PublishedAt time.Time
+}
+
+type Audit struct {
+	CreatedBy string
+}
+
+type Article struct {
+	Audit
+	*Category ` + "`db:\"category\"`" + `
+	ID        int64 ` + "`db:\"id,primary\"`" + `
+}
+
+type options struct {
+	verbose bool
+}
+`
+
+func TestAccessors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(modelsSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Arquivos gerados anteriormente não contam como métodos escritos à mão
+	stale := accessorsHeader + "\n\npackage models\n\nfunc (u *User) TableName() string { return \"old\" }\n"
+	if err := os.WriteFile(filepath.Join(dir, "night_orm_gen.go"), []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := Accessors(dir, AccessorOptions{})
+	if err != nil {
+		t.Fatalf("Accessors returned error: %v", err)
+	}
+
+	code := string(source)
+	for _, expected := range []string{
+		"package models",
+		"func (u *User) TableName() string {\n\treturn \"users\"\n}",
+		"func (u *User) PrimaryKey() string {\n\treturn \"id\"\n}",
+		"func (u *User) PrimaryKeyValue() interface{} {\n\treturn u.ID\n}",
+		"var UserColumns = struct {",
+		"Email:     \"email\",",
+		"\"tags\":       u.Tags,",
+		"\"avatar\":     u.Avatar,",
+		"case \"tags\":\n\t\treturn &u.Tags",
+		"case \"created_at\":\n\t\treturn &u.CreatedAt",
+		"func (c *Category) PrimaryKey() string {\n\treturn \"id\"\n}",
+		"func (p *Post) TableName() string {\n\treturn \"blog_posts\"\n}",
+		"case \"published_at\":\n\t\treturn &p.PublishedAt",
+		// Os campos embutidos são uma coluna com o nome do tipo, como em Mapper.Fields
+		"\"audit\":    a.Audit,",
+		"case \"category\":\n\t\treturn &a.Category",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q, got:\n%s", expected, code)
+		}
+	}
+
+	for _, unexpected := range []string{"Category) TableName", "Secret", "internal", "Posts", "options", "pq."} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("Expected generated code not to contain %q, got:\n%s", unexpected, code)
+		}
+	}
+
+	t.Run("Types", func(t *testing.T) {
+		source, err := Accessors(dir, AccessorOptions{Types: []string{"Post"}})
+		if err != nil {
+			t.Fatalf("Accessors returned error: %v", err)
+		}
+		if strings.Contains(string(source), "(u *User)") || strings.Contains(string(source), "pq") {
+			t.Errorf("Expected only Post accessors, got:\n%s", source)
+		}
+
+		if _, err := Accessors(dir, AccessorOptions{Types: []string{"Missing"}}); err == nil {
+			t.Error("Expected error for unknown struct")
+		}
+	})
+
+	t.Run("Naming", func(t *testing.T) {
+		naming := utils.SnakeCaseNaming{TablePrefix: "app_", LowerCaseColumns: true}
+		source, err := Accessors(dir, AccessorOptions{Types: []string{"User", "Post"}, Naming: naming})
+		if err != nil {
+			t.Fatalf("Accessors returned error: %v", err)
+		}
+		code := string(source)
+		for _, expected := range []string{"return \"app_users\"", "return \"blog_posts\"", "case \"publishedat\":"} {
+			if !strings.Contains(code, expected) {
+				t.Errorf("Expected generated code to contain %q, got:\n%s", expected, code)
+			}
+		}
+	})
+}
+
+func TestTableName(t *testing.T) {
+	names := map[string]string{
+		"User":        "users",
+		"Category":    "categories",
+		"Day":         "days",
+		"Address":     "addresses",
+		"Box":         "boxes",
+		"UserProfile": "user_profiles",
+		"HTTPLog":     "http_logs",
+		"OAuth2Token": "o_auth2_tokens",
+	}
+	for structName, expected := range names {
+		if got := TableName(structName); got != expected {
+			t.Errorf("Expected TableName(%q) = %q, got %q", structName, expected, got)
+		}
+	}
+}
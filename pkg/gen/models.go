@@ -0,0 +1,288 @@
+// Package gen generates Go source code for night-orm models.
+package gen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/schema"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// Options configures the model generator
+type Options struct {
+	// Package is the package name of the generated file; defaults to "models"
+	Package string
+	// Schema is the database schema to read; empty means the current schema
+	Schema string
+	// Include lists glob patterns (path.Match syntax) of the tables to generate;
+	// empty means every table
+	Include []string
+	// Exclude lists glob patterns of the tables to skip
+	Exclude []string
+	// Types overrides the Go type of columns. Keys are "table.column" or a SQL type
+	// such as "uuid" or "numeric"; values are a Go type, qualified by its import path
+	// when needed, as in "github.com/google/uuid.UUID" or "time.Duration".
+	Types map[string]string
+}
+
+// selected reports whether the table passes the Include and Exclude filters
+func (o Options) selected(table string) bool {
+	if len(o.Include) > 0 && !matchAny(o.Include, table) {
+		return false
+	}
+	return !matchAny(o.Exclude, table)
+}
+
+// matchAny reports whether the name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Models reads the tables of the database and returns the Go source of their models
+func Models(ctx context.Context, q utils.Querier, opts Options) ([]byte, error) {
+	names, err := schema.InspectTables(ctx, q, opts.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*schema.Table, 0, len(names))
+	for _, name := range names {
+		if !opts.selected(name) {
+			continue
+		}
+		table, err := schema.InspectTable(ctx, q, opts.Schema, name)
+		if err != nil {
+			return nil, err
+		}
+		if table != nil {
+			tables = append(tables, table)
+		}
+	}
+	return Generate(tables, opts)
+}
+
+// Generate returns the formatted Go source declaring one model per table
+func Generate(tables []*schema.Table, opts Options) ([]byte, error) {
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "models"
+	}
+
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+	for _, table := range tables {
+		if err := writeModel(&body, table, opts, imports); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by night-orm gen models. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for importPath := range imports {
+			paths = append(paths, importPath)
+		}
+		sort.Strings(paths)
+		out.WriteString("import (\n")
+		for _, importPath := range paths {
+			fmt.Fprintf(&out, "\t%q\n", importPath)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(body.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated models: %w", err)
+	}
+	return source, nil
+}
+
+// writeModel writes the struct of a table with its TableName method and, for tables
+// with a single-column primary key, the PrimaryKey and PrimaryKeyValue methods
+func writeModel(w *bytes.Buffer, table *schema.Table, opts Options, imports map[string]bool) error {
+	name := StructName(table.Name)
+	fmt.Fprintf(w, "// %s is a row of the %s table\ntype %s struct {\n", name, table.QualifiedName(), name)
+	for _, column := range table.Columns {
+		goType, importPath := GoType(column)
+		if override, ok := typeOverride(opts.Types, table.Name, column); ok {
+			var err error
+			if goType, importPath, err = parseGoType(override); err != nil {
+				return fmt.Errorf("invalid type override for %s.%s: %w", table.Name, column.Name, err)
+			}
+		}
+		if importPath != "" {
+			imports[importPath] = true
+		}
+		tag := column.Name
+		if column.PrimaryKey {
+			tag += ",primary"
+		}
+		fmt.Fprintf(w, "\t%s %s `db:%q`\n", FieldName(column.Name), goType, tag)
+	}
+	w.WriteString("}\n\n")
+
+	receiver := strings.ToLower(name[:1])
+	fmt.Fprintf(w, "// TableName returns the table of %s\nfunc (%s *%s) TableName() string {\n\treturn %q\n}\n\n",
+		name, receiver, name, table.QualifiedName())
+
+	primaryKey := table.PrimaryKey()
+	if len(primaryKey) != 1 {
+		return nil
+	}
+	fmt.Fprintf(w, "// PrimaryKey returns the primary key column of %s\nfunc (%s *%s) PrimaryKey() string {\n\treturn %q\n}\n\n",
+		name, receiver, name, primaryKey[0])
+	fmt.Fprintf(w, "// PrimaryKeyValue returns the primary key value of %s\nfunc (%s *%s) PrimaryKeyValue() interface{} {\n\treturn %s.%s\n}\n\n",
+		name, receiver, name, receiver, FieldName(primaryKey[0]))
+	return nil
+}
+
+// typeOverride returns the Go type configured for the column, by "table.column"
+// first, then by its full SQL type such as numeric(12,2) and then by the type name
+func typeOverride(types map[string]string, table string, column schema.Column) (string, bool) {
+	if goType, ok := types[table+"."+column.Name]; ok {
+		return goType, true
+	}
+	sqlType := schema.NormalizeType(column.Type)
+	if goType, ok := types[sqlType]; ok {
+		return goType, true
+	}
+	if i := strings.Index(sqlType, "("); i >= 0 {
+		goType, ok := types[sqlType[:i]]
+		return goType, ok
+	}
+	return "", false
+}
+
+// parseGoType splits a type such as "github.com/google/uuid.UUID" into the type
+// used in code, "uuid.UUID", and its import path
+func parseGoType(spec string) (string, string, error) {
+	spec = strings.TrimSpace(spec)
+	prefix := strings.TrimLeft(spec, "[]*")
+	modifiers := spec[:len(spec)-len(prefix)]
+
+	dot := strings.LastIndex(prefix, ".")
+	if dot < 0 {
+		if prefix == "" {
+			return "", "", fmt.Errorf("empty type")
+		}
+		return spec, "", nil
+	}
+	importPath, typeName := prefix[:dot], prefix[dot+1:]
+	if importPath == "" || typeName == "" {
+		return "", "", fmt.Errorf("malformed type %q", spec)
+	}
+	pkg := importPath[strings.LastIndex(importPath, "/")+1:]
+	return modifiers + pkg + "." + typeName, importPath, nil
+}
+
+// baseTypes maps PostgreSQL types to the Go type of their non-null values and its import path
+var baseTypes = map[string][2]string{
+	"smallint":                    {"int16", ""},
+	"integer":                     {"int32", ""},
+	"bigint":                      {"int64", ""},
+	"real":                        {"float32", ""},
+	"double precision":            {"float64", ""},
+	"numeric":                     {"string", ""},
+	"boolean":                     {"bool", ""},
+	"text":                        {"string", ""},
+	"character varying":           {"string", ""},
+	"character":                   {"string", ""},
+	"uuid":                        {"string", ""},
+	"bytea":                       {"[]byte", ""},
+	"json":                        {"json.RawMessage", "encoding/json"},
+	"jsonb":                       {"json.RawMessage", "encoding/json"},
+	"date":                        {"time.Time", "time"},
+	"timestamp with time zone":    {"time.Time", "time"},
+	"timestamp without time zone": {"time.Time", "time"},
+	"time with time zone":         {"time.Time", "time"},
+	"time without time zone":      {"time.Time", "time"},
+}
+
+// nullTypes maps Go types to the database/sql wrapper used for nullable columns
+var nullTypes = map[string]string{
+	"int16":     "sql.NullInt16",
+	"int32":     "sql.NullInt32",
+	"int64":     "sql.NullInt64",
+	"float32":   "sql.NullFloat64",
+	"float64":   "sql.NullFloat64",
+	"string":    "sql.NullString",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+}
+
+// GoType returns the Go type of a column and the import path it requires. Nullable
+// columns use the database/sql null wrappers; slices already represent NULL as nil.
+func GoType(column schema.Column) (string, string) {
+	sqlType := schema.NormalizeType(column.Type)
+	array := strings.HasSuffix(sqlType, "[]")
+	sqlType = strings.TrimSuffix(sqlType, "[]")
+	if i := strings.Index(sqlType, "("); i >= 0 {
+		sqlType = sqlType[:i]
+	}
+
+	base, ok := baseTypes[sqlType]
+	if !ok {
+		base = [2]string{"string", ""}
+	}
+	if array {
+		return "[]" + base[0], base[1]
+	}
+	if !column.NotNull {
+		if wrapper, ok := nullTypes[base[0]]; ok {
+			return wrapper, "database/sql"
+		}
+	}
+	return base[0], base[1]
+}
+
+// initialisms are the name parts written in upper case, following Go conventions
+var initialisms = map[string]bool{
+	"id": true, "url": true, "uri": true, "uuid": true, "api": true, "http": true,
+	"ip": true, "sql": true, "json": true, "html": true, "xml": true, "cpu": true,
+}
+
+// FieldName converts a snake_case column name to an exported Go identifier
+func FieldName(column string) string {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(column, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		part = strings.ToLower(part)
+		if initialisms[part] {
+			name.WriteString(strings.ToUpper(part))
+			continue
+		}
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if name.Len() == 0 || name.String()[0] >= '0' && name.String()[0] <= '9' {
+		return "X" + name.String()
+	}
+	return name.String()
+}
+
+// StructName returns the singular exported Go name of a table
+func StructName(table string) string {
+	table = table[strings.LastIndex(table, ".")+1:]
+	switch {
+	case strings.HasSuffix(table, "ies"):
+		table = strings.TrimSuffix(table, "ies") + "y"
+	case strings.HasSuffix(table, "sses"), strings.HasSuffix(table, "xes"), strings.HasSuffix(table, "ches"):
+		table = strings.TrimSuffix(table, "es")
+	case strings.HasSuffix(table, "s") && !strings.HasSuffix(table, "ss") && !strings.HasSuffix(table, "us"):
+		table = strings.TrimSuffix(table, "s")
+	}
+	return FieldName(table)
+}
@@ -0,0 +1,134 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/schema"
+)
+
+func TestGenerate(t *testing.T) {
+	tables := []*schema.Table{{
+		Name: "user_addresses",
+		Columns: []schema.Column{
+			{Name: "id", Type: "bigint", NotNull: true, PrimaryKey: true},
+			{Name: "user_id", Type: "integer", NotNull: true},
+			{Name: "street", Type: "character varying(120)"},
+			{Name: "tags", Type: "text[]"},
+			{Name: "created_at", Type: "timestamp with time zone", NotNull: true},
+		},
+	}}
+
+	source, err := Generate(tables, Options{Package: "db"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	code := string(source)
+	for _, expected := range []string{
+		"package db",
+		"\"database/sql\"",
+		"\"time\"",
+		"type UserAddress struct {",
+		"ID        int64          `db:\"id,primary\"`",
+		"UserID    int32          `db:\"user_id\"`",
+		"Street    sql.NullString `db:\"street\"`",
+		"Tags      []string       `db:\"tags\"`",
+		"CreatedAt time.Time      `db:\"created_at\"`",
+		"func (u *UserAddress) TableName() string {\n\treturn \"user_addresses\"\n}",
+		"func (u *UserAddress) PrimaryKey() string {\n\treturn \"id\"\n}",
+		"func (u *UserAddress) PrimaryKeyValue() interface{} {\n\treturn u.ID\n}",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q, got:\n%s", expected, code)
+		}
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	tables := []*schema.Table{
+		{
+			Name: "invoices",
+			Columns: []schema.Column{
+				{Name: "id", Type: "uuid", NotNull: true, PrimaryKey: true},
+				{Name: "total", Type: "numeric(12,2)", NotNull: true},
+				{Name: "paid_after", Type: "bigint"},
+			},
+		},
+		{
+			Name: "invoice_items",
+			Columns: []schema.Column{
+				{Name: "invoice_id", Type: "uuid", NotNull: true, PrimaryKey: true},
+				{Name: "line", Type: "integer", NotNull: true, PrimaryKey: true},
+			},
+		},
+	}
+
+	source, err := Generate(tables, Options{Types: map[string]string{
+		"uuid":                "github.com/google/uuid.UUID",
+		"invoices.paid_after": "*time.Duration",
+		"numeric":             "float64",
+	}})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	code := string(source)
+	for _, expected := range []string{
+		"package models",
+		"\"github.com/google/uuid\"",
+		"\"time\"",
+		"ID        uuid.UUID      `db:\"id,primary\"`",
+		"Total     float64        `db:\"total\"`",
+		"PaidAfter *time.Duration `db:\"paid_after\"`",
+		"InvoiceID uuid.UUID `db:\"invoice_id,primary\"`",
+		"Line      int32     `db:\"line,primary\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q, got:\n%s", expected, code)
+		}
+	}
+
+	// Chaves primárias compostas não geram PrimaryKey/PrimaryKeyValue
+	if strings.Contains(code, "func (i *InvoiceItem) PrimaryKey()") {
+		t.Errorf("Expected no PrimaryKey method for composite key, got:\n%s", code)
+	}
+
+	if _, err := Generate(tables, Options{Types: map[string]string{"uuid": "github.com/google/."}}); err == nil {
+		t.Error("Expected error for malformed type override")
+	}
+}
+
+func TestOptionsSelected(t *testing.T) {
+	opts := Options{Include: []string{"billing_*", "users"}, Exclude: []string{"*_archive"}}
+	cases := map[string]bool{
+		"users":                 true,
+		"billing_invoices":      true,
+		"billing_items_archive": false,
+		"posts":                 false,
+	}
+	for table, expected := range cases {
+		if got := opts.selected(table); got != expected {
+			t.Errorf("Expected selected(%q) = %v, got %v", table, expected, got)
+		}
+	}
+	if !(Options{}).selected("anything") {
+		t.Error("Expected every table to be selected without filters")
+	}
+}
+
+func TestNames(t *testing.T) {
+	fields := map[string]string{"user_id": "UserID", "avatar_url": "AvatarURL", "name": "Name", "2fa": "X2fa"}
+	for column, expected := range fields {
+		if got := FieldName(column); got != expected {
+			t.Errorf("Expected FieldName(%q) = %q, got %q", column, expected, got)
+		}
+	}
+
+	structs := map[string]string{"users": "User", "categories": "Category", "addresses": "Address", "boxes": "Box", "billing.invoices": "Invoice", "status": "Status"}
+	for table, expected := range structs {
+		if got := StructName(table); got != expected {
+			t.Errorf("Expected StructName(%q) = %q, got %q", table, expected, got)
+		}
+	}
+}
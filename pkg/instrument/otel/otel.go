@@ -0,0 +1,186 @@
+// Package otel adapts OpenTelemetry to core.Instrumentation. Every call to the ORM or to
+// a transaction becomes a client span following the database semantic conventions, and
+// its duration is recorded in the db.client.operation.duration histogram.
+//
+//	instrumentation, err := otel.New(
+//		otel.WithAttributes(attribute.String("db.system.name", "postgresql")),
+//	)
+//	orm := postgres.NewPostgresORM(postgres.WithInstrumentation(instrumentation))
+//
+// The global tracer and meter providers are used unless WithTracerProvider and
+// WithMeterProvider are given.
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	otelglobal "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+)
+
+// ScopeName is the instrumentation scope of the tracer and of the meter
+const ScopeName = "github.com/rodolfocoding/night-orm/pkg/instrument/otel"
+
+// Attribute keys of the database semantic conventions
+const (
+	OperationNameKey  = attribute.Key("db.operation.name")
+	CollectionNameKey = attribute.Key("db.collection.name")
+	QueryTextKey      = attribute.Key("db.query.text")
+	ErrorTypeKey      = attribute.Key("error.type")
+)
+
+// Instrumentation is the core.Instrumentation backed by OpenTelemetry
+type Instrumentation struct {
+	tracer     trace.Tracer
+	duration   metric.Float64Histogram
+	attributes []attribute.KeyValue
+}
+
+// config holds the settings of New
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	attributes     []attribute.KeyValue
+}
+
+// Option configures the Instrumentation created by New
+type Option func(*config)
+
+// WithTracerProvider sets the provider of the tracer creating the spans
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the provider of the meter recording the durations
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithAttributes adds attributes to every span and measurement, such as
+// db.system.name or server.address
+func WithAttributes(attributes ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attributes = append(c.attributes, attributes...)
+	}
+}
+
+// New creates the OpenTelemetry instrumentation
+func New(opts ...Option) (*Instrumentation, error) {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otelglobal.GetTracerProvider()
+	}
+	if c.meterProvider == nil {
+		c.meterProvider = otelglobal.GetMeterProvider()
+	}
+
+	duration, err := c.meterProvider.Meter(ScopeName).Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithDescription("Duration of database client operations."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating duration histogram: %w", err)
+	}
+
+	return &Instrumentation{
+		tracer:     c.tracerProvider.Tracer(ScopeName),
+		duration:   duration,
+		attributes: c.attributes,
+	}, nil
+}
+
+// StartSpan implements core.Instrumentation, starting a client span named after the
+// operation and its table, such as "find_by_id users"
+func (i *Instrumentation) StartSpan(ctx context.Context, op core.Operation) (context.Context, core.Span) {
+	attributes := append([]attribute.KeyValue{OperationNameKey.String(op.Name)}, i.attributes...)
+	name := op.Name
+	if op.Table != "" {
+		attributes = append(attributes, CollectionNameKey.String(op.Table))
+		name += " " + op.Table
+	}
+
+	spanAttributes := attributes
+	if op.Statement != "" {
+		spanAttributes = append(spanAttributes[:len(spanAttributes):len(spanAttributes)], QueryTextKey.String(op.Statement))
+	}
+	ctx, s := i.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(spanAttributes...),
+	)
+	return ctx, &span{
+		instrumentation: i,
+		ctx:             ctx,
+		span:            s,
+		attributes:      attributes,
+		start:           time.Now(),
+		hasQuery:        op.Statement != "",
+	}
+}
+
+// span is the core.Span wrapping an OpenTelemetry span
+type span struct {
+	instrumentation *Instrumentation
+	ctx             context.Context
+	span            trace.Span
+	attributes      []attribute.KeyValue
+	start           time.Time
+
+	mu       sync.Mutex
+	hasQuery bool
+}
+
+// SetStatement implements core.Span. The first statement becomes the db.query.text
+// attribute of the span, and every statement is added as a "statement" event.
+func (s *span) SetStatement(statement string) {
+	s.mu.Lock()
+	first := !s.hasQuery
+	s.hasQuery = true
+	s.mu.Unlock()
+
+	if first {
+		s.span.SetAttributes(QueryTextKey.String(statement))
+	}
+	s.span.AddEvent("statement", trace.WithAttributes(QueryTextKey.String(statement)))
+}
+
+// End implements core.Span, recording the error and the duration of the operation.
+// Errors wrapping core.ErrNotFound are recorded without setting the error status.
+func (s *span) End(err error) {
+	attributes := s.attributes
+	if err != nil {
+		s.span.RecordError(err)
+		if !errors.Is(err, core.ErrNotFound) {
+			s.span.SetStatus(codes.Error, err.Error())
+			attributes = append(attributes[:len(attributes):len(attributes)], ErrorTypeKey.String(errorType(err)))
+		}
+	}
+	s.instrumentation.duration.Record(s.ctx, time.Since(s.start).Seconds(), metric.WithAttributes(attributes...))
+	s.span.End()
+}
+
+// errorType returns the type of the innermost error wrapped by err, such as *pq.Error,
+// keeping the error.type attribute low in cardinality
+func errorType(err error) string {
+	for next := errors.Unwrap(err); next != nil; next = errors.Unwrap(next) {
+		err = next
+	}
+	return fmt.Sprintf("%T", err)
+}
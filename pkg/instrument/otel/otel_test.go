@@ -0,0 +1,132 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/postgres"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+type User struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+// attributeValue procura o valor de um atributo
+func attributeValue(attributes []attribute.KeyValue, key attribute.Key) (string, bool) {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return kv.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+func TestInstrumentation(t *testing.T) {
+	ctx := context.Background()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	instrumentation, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithAttributes(attribute.String("db.system.name", "postgresql")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sqltest.New()
+	defer db.Close()
+	orm := postgres.NewPostgresORMFromDB(db.DB(), postgres.WithInstrumentation(instrumentation))
+
+	db.ExpectQuery(sqltest.Regexp(`^INSERT INTO "users"`)).WillReturnRows(sqltest.NewRows("id").AddRow(1))
+	db.ExpectExec(sqltest.Regexp(`^UPDATE "users"`)).WillReturnResult(0, 0)
+	db.ExpectExec(sqltest.Exact("VACUUM")).WillReturnError(errors.New("boom"))
+
+	if err := orm.Create(ctx, &User{Name: "Ana"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := orm.Update(ctx, &User{ID: 9}); !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := orm.Exec(ctx, "VACUUM"); err == nil {
+		t.Fatal("Expected error from Exec")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(ended))
+	}
+
+	t.Run("Spans", func(t *testing.T) {
+		create := ended[0]
+		if create.Name() != "create users" || create.SpanKind() != trace.SpanKindClient {
+			t.Errorf("Expected client span create users, got %s (%s)", create.Name(), create.SpanKind())
+		}
+		for key, expected := range map[attribute.Key]string{
+			OperationNameKey:  "create",
+			CollectionNameKey: "users",
+			QueryTextKey:      `INSERT INTO "users" ("name") VALUES ($1) RETURNING "id"`,
+			"db.system.name":  "postgresql",
+		} {
+			if got, _ := attributeValue(create.Attributes(), key); got != expected {
+				t.Errorf("Expected %s %q, got %q", key, expected, got)
+			}
+		}
+		if len(create.Events()) != 1 || create.Status().Code != codes.Unset {
+			t.Errorf("Expected one statement event and unset status, got %d and %v", len(create.Events()), create.Status())
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		// Registros não encontrados não marcam o span como falho
+		if update := ended[1]; update.Status().Code != codes.Unset {
+			t.Errorf("Expected unset status for not found, got %v", update.Status())
+		}
+		exec := ended[2]
+		if exec.Name() != "exec" || exec.Status().Code != codes.Error {
+			t.Errorf("Expected failed exec span, got %s with %v", exec.Name(), exec.Status())
+		}
+		if text, _ := attributeValue(exec.Attributes(), QueryTextKey); text != "VACUUM" {
+			t.Errorf("Expected query text VACUUM, got %q", text)
+		}
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		var data metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &data); err != nil {
+			t.Fatal(err)
+		}
+		histogram := data.ScopeMetrics[0].Metrics[0]
+		if histogram.Name != "db.client.operation.duration" || histogram.Unit != "s" {
+			t.Fatalf("Expected duration histogram in seconds, got %s (%s)", histogram.Name, histogram.Unit)
+		}
+		points := histogram.Data.(metricdata.Histogram[float64]).DataPoints
+		if len(points) != 3 {
+			t.Fatalf("Expected 3 data points, got %d", len(points))
+		}
+		var failed int
+		for _, point := range points {
+			if value, ok := point.Attributes.Value(ErrorTypeKey); ok {
+				failed++
+				if value.AsString() != "*errors.errorString" {
+					t.Errorf("Expected error type *errors.errorString, got %s", value.AsString())
+				}
+			}
+		}
+		if failed != 1 {
+			t.Errorf("Expected 1 failed data point, got %d", failed)
+		}
+	})
+}
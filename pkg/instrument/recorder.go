@@ -0,0 +1,122 @@
+// Package instrument provides a core.Instrumentation that records the spans in memory,
+// so tests can assert which operations the ORM ran and which statements each one sent
+// to the database.
+//
+//	rec := instrument.NewRecorder()
+//	orm := postgres.NewPostgresORMFromDB(db, postgres.WithInstrumentation(rec))
+//	...
+//	for _, span := range rec.Spans() {
+//		fmt.Println(span.Operation.Name, span.Operation.Table, span.Statements, span.Err)
+//	}
+//
+// The OpenTelemetry adapter lives in the instrument/otel subpackage.
+package instrument
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+)
+
+// RecordedSpan is a span ended by the ORM
+type RecordedSpan struct {
+	// Operation describes the call to the ORM or to the transaction
+	Operation core.Operation
+	// Statements are the statements run by the operation, in order
+	Statements []string
+	// Err is the error the operation ended with
+	Err error
+	// Parent is the operation of the enclosing span, such as the find_by_id running a
+	// preload, or nil
+	Parent *core.Operation
+	// Start is the time the span started
+	Start time.Time
+	// Duration is the time between the start and the end of the span
+	Duration time.Duration
+}
+
+// Recorder records the spans started by the ORM. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// spanKey is the context key of the running span, the parent of nested spans
+type spanKey struct{}
+
+// StartSpan implements core.Instrumentation
+func (r *Recorder) StartSpan(ctx context.Context, op core.Operation) (context.Context, core.Span) {
+	s := &span{recorder: r, record: RecordedSpan{Operation: op, Start: time.Now()}}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		operation := parent.record.Operation
+		s.record.Parent = &operation
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Spans returns the ended spans in the order they ended, so nested spans come before
+// the spans enclosing them
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedSpan(nil), r.spans...)
+}
+
+// Operations returns the names of the operations of the ended spans, in the order
+// they ended
+func (r *Recorder) Operations() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.spans))
+	for i, span := range r.spans {
+		names[i] = span.Operation.Name
+	}
+	return names
+}
+
+// Reset discards the recorded spans
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+// span is the core.Span started by the recorder
+type span struct {
+	recorder *Recorder
+	mu       sync.Mutex
+	record   RecordedSpan
+	ended    bool
+}
+
+// SetStatement implements core.Span
+func (s *span) SetStatement(statement string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Statements = append(s.record.Statements, statement)
+}
+
+// End implements core.Span. Only the first call is recorded.
+func (s *span) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.record.Err = err
+	s.record.Duration = time.Since(s.record.Start)
+	record := s.record
+	s.mu.Unlock()
+
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.spans = append(s.recorder.spans, record)
+}
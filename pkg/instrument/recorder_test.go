@@ -0,0 +1,92 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/postgres"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+type Author struct {
+	ID    int64   `db:"id,primary"`
+	Name  string  `db:"name"`
+	Books []*Book `rel:"has_many,fk=author_id"`
+}
+
+type Book struct {
+	ID       int64  `db:"id,primary"`
+	AuthorID int64  `db:"author_id"`
+	Title    string `db:"title"`
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	db := sqltest.New()
+	defer db.Close()
+	rec := NewRecorder()
+	orm := postgres.NewPostgresORMFromDB(db.DB(), postgres.WithInstrumentation(rec))
+
+	db.ExpectQuery(sqltest.Regexp(`FROM "authors"`)).WillReturnRows(sqltest.NewRows("id", "name").AddRow(1, "Clarice"))
+	db.ExpectQuery(sqltest.Regexp(`FROM "books"`)).WillReturnRows(sqltest.NewRows("id", "author_id", "title").AddRow(5, 1, "A Hora da Estrela"))
+	db.ExpectBegin()
+	db.ExpectExec(sqltest.Regexp(`^UPDATE "authors"`)).WillReturnError(errors.New("boom"))
+	db.ExpectRollback()
+
+	author := &Author{}
+	if err := orm.FindByID(core.WithPreload(ctx, "Books"), author, 1); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := orm.Transaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Update(ctx, author)
+	tx.Rollback()
+	// Rollback de uma transação encerrada não é registrado como falha
+	tx.Rollback()
+
+	expected := []string{"preload", "find_by_id", "begin", "update", "rollback", "rollback"}
+	if got := rec.Operations(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected operations %v, got %v", expected, got)
+	}
+	spans := rec.Spans()
+
+	t.Run("Nesting", func(t *testing.T) {
+		// O preload das relações é um span filho da busca
+		preload, find := spans[0], spans[1]
+		if preload.Parent == nil || preload.Parent.Name != "find_by_id" || find.Parent != nil {
+			t.Errorf("Expected preload nested in find_by_id, got parents %v and %v", preload.Parent, find.Parent)
+		}
+		if find.Operation.Table != "authors" || preload.Operation.Table != "authors" {
+			t.Errorf("Expected spans on authors, got %q and %q", find.Operation.Table, preload.Operation.Table)
+		}
+		if len(find.Statements) != 1 || len(preload.Statements) != 1 {
+			t.Errorf("Expected one statement per span, got %+v and %+v", find, preload)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		update := spans[3]
+		if update.Err == nil || !strings.HasPrefix(update.Statements[0], `UPDATE "authors"`) {
+			t.Errorf("Expected failed UPDATE span, got %+v", update)
+		}
+		if spans[2].Statements[0] != "BEGIN" || spans[4].Statements[0] != "ROLLBACK" {
+			t.Errorf("Expected BEGIN and ROLLBACK statements, got %v and %v", spans[2].Statements, spans[4].Statements)
+		}
+		if spans[4].Err != nil || spans[5].Err != nil || len(spans[5].Statements) != 0 {
+			t.Errorf("Expected quiet rollbacks, got %+v and %+v", spans[4], spans[5])
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		rec.Reset()
+		if len(rec.Spans()) != 0 {
+			t.Errorf("Expected no spans after Reset, got %d", len(rec.Spans()))
+		}
+	})
+}
@@ -0,0 +1,281 @@
+package sqlbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// Association manages the join table rows of a many_to_many relation
+type Association struct {
+	q            Querier
+	backend      *Backend
+	owner        reflect.Value
+	ownerKey     interface{}
+	relation     utils.Relation
+	targetColumn string
+}
+
+// Association returns the operations for the given many_to_many relation of the model,
+// run with q, usually the transaction of the backend
+func (b *Backend) Association(q Querier, model core.Model, relation string) (*Association, error) {
+	val := reflect.ValueOf(model)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("model must be a non-nil pointer to a struct")
+	}
+	val = val.Elem()
+
+	rel, err := utils.GetRelation(val.Type(), relation)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Kind != utils.ManyToMany {
+		return nil, fmt.Errorf("relation %s is not many_to_many", relation)
+	}
+
+	ownerColumn, targetColumn, err := b.Mapper.KeyColumns(rel, val.Type())
+	if err != nil {
+		return nil, fmt.Errorf("error resolving relation keys: %w", err)
+	}
+	rel.JoinTable = b.Mapper.JoinTable(rel)
+	ownerField, ok := utils.FindField(b.Mapper.Fields(val.Type()), ownerColumn)
+	if !ok {
+		return nil, fmt.Errorf("column %s not found in %s", ownerColumn, val.Type())
+	}
+	ownerKey := val.FieldByIndex(ownerField.Index)
+	if ownerKey.IsZero() {
+		return nil, errors.New("model must be persisted before managing its associations")
+	}
+
+	return &Association{
+		q:            q,
+		backend:      b,
+		owner:        val,
+		ownerKey:     ownerKey.Interface(),
+		relation:     rel,
+		targetColumn: targetColumn,
+	}, nil
+}
+
+// Append associates the given models, ignoring associations that already exist. The
+// join rows are inserted with the upsert clause of the dialect, which only skips the
+// existing rows when the join table has a unique constraint or primary key on
+// (fk, target_fk).
+func (a *Association) Append(ctx context.Context, related ...interface{}) error {
+	items, keys, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	qb := a.backend.Mapper.NewQueryBuilder()
+	a.writeInsert(qb, keys)
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error appending associations: %w", a.backend.TranslateError(err))
+	}
+
+	// Models already held by the relation field are not added again
+	current := a.fieldItems(a.owner.FieldByIndex(a.relation.Index))
+	present := make(map[string]bool, len(current))
+	for _, item := range current {
+		if key, ok := a.targetKey(item); ok {
+			present[fmt.Sprint(key)] = true
+		}
+	}
+	for _, item := range items {
+		if key, _ := a.targetKey(item); !present[fmt.Sprint(key)] {
+			current = append(current, item)
+		}
+	}
+	a.setField(current)
+	return nil
+}
+
+// Remove removes the associations with the given models
+func (a *Association) Remove(ctx context.Context, related ...interface{}) error {
+	_, keys, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	qb := a.backend.Mapper.NewQueryBuilder()
+	qb.WriteDelete(a.relation.JoinTable).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(a.relation.ForeignKey), qb.AddParam(a.ownerKey))).
+		WriteAnd(qb.In(a.relation.JoinForeignKey, keys))
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error removing associations: %w", a.backend.TranslateError(err))
+	}
+
+	removed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		removed[fmt.Sprint(key)] = true
+	}
+	remaining := make([]reflect.Value, 0)
+	for _, item := range a.fieldItems(a.owner.FieldByIndex(a.relation.Index)) {
+		if key, ok := a.targetKey(item); !ok || !removed[fmt.Sprint(key)] {
+			remaining = append(remaining, item)
+		}
+	}
+	a.setField(remaining)
+	return nil
+}
+
+// Replace replaces all associations with the given models
+func (a *Association) Replace(ctx context.Context, related ...interface{}) error {
+	items, keys, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return a.Clear(ctx)
+	}
+
+	// Without data-modifying CTEs the stale rows are deleted and the new ones inserted
+	// with two statements, within the transaction of the association
+	qb := a.backend.Mapper.NewQueryBuilder()
+	qb.WriteDelete(a.relation.JoinTable).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(a.relation.ForeignKey), qb.AddParam(a.ownerKey))).
+		WriteAnd("NOT " + qb.In(a.relation.JoinForeignKey, keys))
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error replacing associations: %w", a.backend.TranslateError(err))
+	}
+
+	qb = a.backend.Mapper.NewQueryBuilder()
+	a.writeInsert(qb, keys)
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error replacing associations: %w", a.backend.TranslateError(err))
+	}
+
+	a.setField(items)
+	return nil
+}
+
+// Clear removes all associations of the model
+func (a *Association) Clear(ctx context.Context) error {
+	qb := a.backend.Mapper.NewQueryBuilder()
+	qb.WriteDelete(a.relation.JoinTable).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(a.relation.ForeignKey), qb.AddParam(a.ownerKey)))
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error clearing associations: %w", a.backend.TranslateError(err))
+	}
+
+	a.setField(nil)
+	return nil
+}
+
+// writeInsert writes a batched insert of one join row per key, ignoring the existing rows
+// with the upsert clause of the dialect. Numbered placeholders let the rows share the
+// owner key parameter; ? placeholders cannot be reused, so it is bound once per row.
+func (a *Association) writeInsert(qb *utils.QueryBuilder, keys []interface{}) {
+	dialect := a.backend.Mapper.Dialect()
+	ownerParam := ""
+	shared := dialect.Placeholder(1) != dialect.Placeholder(2)
+	if shared {
+		ownerParam = qb.AddParam(a.ownerKey)
+	}
+
+	qb.Write(fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES ",
+		qb.Quote(a.relation.JoinTable), qb.Quote(a.relation.ForeignKey), qb.Quote(a.relation.JoinForeignKey)))
+	for i, key := range keys {
+		if i > 0 {
+			qb.Write(", ")
+		}
+		if !shared {
+			ownerParam = qb.AddParam(a.ownerKey)
+		}
+		qb.Write(fmt.Sprintf("(%s, %s)", ownerParam, qb.AddParam(key)))
+	}
+	qb.Write(" " + dialect.Upsert([]string{a.relation.ForeignKey, a.relation.JoinForeignKey}, nil))
+}
+
+// exec runs the built statement
+func (a *Association) exec(ctx context.Context, qb *utils.QueryBuilder) error {
+	query, args := qb.Build()
+	_, err := a.q.ExecContext(ctx, query, args...)
+	return err
+}
+
+// targets validates the related models and returns the first model of each key, with
+// the distinct keys
+func (a *Association) targets(related []interface{}) ([]reflect.Value, []interface{}, error) {
+	items := make([]reflect.Value, 0, len(related))
+	keys := make([]interface{}, 0, len(related))
+	seen := make(map[string]bool, len(related))
+	for _, model := range related {
+		val := reflect.ValueOf(model)
+		if val.Kind() == reflect.Ptr && !val.IsNil() {
+			val = val.Elem()
+		}
+		if !val.IsValid() || val.Type() != a.relation.Target {
+			return nil, nil, fmt.Errorf("expected %s, got %T", a.relation.Target, model)
+		}
+		if !val.CanAddr() {
+			copied := reflect.New(val.Type()).Elem()
+			copied.Set(val)
+			val = copied
+		}
+
+		key, ok := a.targetKey(val)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s must be persisted before being associated", a.relation.Target)
+		}
+		if !seen[fmt.Sprint(key)] {
+			seen[fmt.Sprint(key)] = true
+			items = append(items, val)
+			keys = append(keys, key)
+		}
+	}
+	return items, keys, nil
+}
+
+// targetKey returns the key of a related model referenced by the join table
+func (a *Association) targetKey(item reflect.Value) (interface{}, bool) {
+	field, ok := utils.FindField(a.backend.Mapper.Fields(a.relation.Target), a.targetColumn)
+	if !ok {
+		return nil, false
+	}
+	value := item.FieldByIndex(field.Index)
+	if value.IsZero() {
+		return nil, false
+	}
+	return value.Interface(), true
+}
+
+// fieldItems returns the models currently held by the relation field
+func (a *Association) fieldItems(field reflect.Value) []reflect.Value {
+	items := make([]reflect.Value, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		item := field.Index(i)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// setField replaces the relation field with the given models
+func (a *Association) setField(items []reflect.Value) {
+	field := a.owner.FieldByIndex(a.relation.Index)
+	slice := reflect.MakeSlice(a.relation.Type, 0, len(items))
+	for _, item := range items {
+		if a.relation.Type.Elem().Kind() == reflect.Ptr {
+			slice = reflect.Append(slice, item.Addr())
+		} else {
+			slice = reflect.Append(slice, item)
+		}
+	}
+	field.Set(slice)
+}
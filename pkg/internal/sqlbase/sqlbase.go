@@ -0,0 +1,341 @@
+// Package sqlbase implements the CRUD operations and many_to_many associations shared by
+// the database/sql backends without PostgreSQL extensions, such as SQLite and MySQL.
+//
+// The statements are built with the dialect of the backend mapper: placeholders,
+// quoting, RETURNING and the upsert clause come from utils.Dialect, and the values are
+// converted when the dialect implements utils.TypeConverter. The backend packages keep
+// their connection handling and delegate to a Backend with the *sql.DB or the *sql.Tx
+// the operation runs on.
+package sqlbase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// ErrNotConnected is returned by the backends before Connect is called
+var ErrNotConnected = errors.New("connection not established")
+
+// Querier runs statements; it is implemented by *sql.DB and *sql.Tx, so the ORM and
+// its transactions share the same code
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Backend builds and runs the statements of a backend
+type Backend struct {
+	// Mapper resolves the tables, columns and keys of the models and holds the dialect
+	Mapper *utils.Mapper
+	// TranslateError wraps the driver errors with the matching core typed errors
+	TranslateError func(error) error
+	// DefaultValues follows the table name when a model is inserted without columns,
+	// such as "DEFAULT VALUES" or "() VALUES ()"
+	DefaultValues string
+}
+
+// Create inserts a model, omitting a zero primary key and reading the generated one with
+// RETURNING or, when the dialect has no RETURNING, sql.Result.LastInsertId. Without
+// RETURNING only integer keys can be read back.
+func (b *Backend) Create(ctx context.Context, q Querier, model core.Model) error {
+	fields, err := b.Mapper.StructFields(model)
+	if err != nil {
+		return fmt.Errorf("error retrieving struct fields: %w", err)
+	}
+
+	table, err := b.Mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
+	// Models without a primary key are inserted without reading a generated key
+	primaryKey, primaryKeyValue, err := b.Mapper.PrimaryKey(model)
+	if err != nil {
+		primaryKey = ""
+	}
+	generate := primaryKey != "" && (primaryKeyValue == nil || reflect.ValueOf(primaryKeyValue).IsZero())
+
+	qb := b.Mapper.NewQueryBuilder()
+	columns := make([]string, 0, len(fields))
+	values := make([]interface{}, 0, len(fields))
+	for _, column := range b.Mapper.OrderedColumns(model, fields) {
+		if generate && column == primaryKey {
+			continue // Let the database assign the key
+		}
+		columns = append(columns, column)
+		values = append(values, fields[column])
+	}
+
+	if len(columns) == 0 {
+		qb.Write(fmt.Sprintf("INSERT INTO %s %s", qb.Quote(table), b.DefaultValues))
+	} else {
+		qb.WriteInsert(table, columns, values)
+	}
+	returning := generate && b.Mapper.Dialect().Returning()
+	if returning {
+		qb.WriteReturning(primaryKey)
+	}
+	query, args := qb.Build()
+
+	// Read the generated key in the type of the primary key field
+	if returning {
+		generatedID := b.primaryKeyDestination(model, primaryKey)
+		if err := q.QueryRowContext(ctx, query, args...).Scan(b.scanDestination(generatedID.Interface())); err != nil {
+			return fmt.Errorf("error inserting record: %w", b.TranslateError(err))
+		}
+		return b.setPrimaryKey(model, primaryKey, generatedID.Elem().Interface())
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error inserting record: %w", b.TranslateError(err))
+	}
+	if !generate || !isInteger(b.primaryKeyDestination(model, primaryKey).Elem()) {
+		return nil
+	}
+	generatedID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error retrieving generated key: %w", err)
+	}
+	return b.setPrimaryKey(model, primaryKey, generatedID)
+}
+
+// FindByID scans the record with the given primary key into the model and loads the
+// relations registered with core.WithPreload
+func (b *Backend) FindByID(ctx context.Context, q Querier, model core.Model, id interface{}) error {
+	val := reflect.ValueOf(model)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.New("model must be a non-nil pointer")
+	}
+	val = val.Elem()
+	if val.Kind() != reflect.Struct {
+		return errors.New("model must be a pointer to a struct")
+	}
+
+	table, err := b.Mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+	primaryKey, _, err := b.Mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
+
+	fields := b.Mapper.Fields(val.Type())
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.Column)
+	}
+
+	// Build the query selecting the mapped columns in declaration order
+	qb := b.Mapper.NewQueryBuilder()
+	qb.WriteSelect(columns...).
+		WriteFrom(table).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(b.bindValue(id))))
+
+	query, args := qb.Build()
+
+	// Execute the query and scan the values straight into the struct fields
+	row := q.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(b.Mapper.ScanDestinations(val, columns)...); err != nil {
+		if err == sql.ErrNoRows {
+			return core.ErrNotFound
+		}
+		return fmt.Errorf("error scanning values: %w", err)
+	}
+
+	return b.preloadFromContext(ctx, q, model)
+}
+
+// FindAll appends every record of the model to dest, a pointer to a slice of model
+// pointers, and loads the relations registered with core.WithPreload
+func (b *Backend) FindAll(ctx context.Context, q Querier, model core.Model, dest interface{}) error {
+	// Verify that the destination is a slice pointer
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.IsNil() {
+		return errors.New("destination must be a non-nil pointer to a slice")
+	}
+	destVal = destVal.Elem()
+	if destVal.Kind() != reflect.Slice {
+		return errors.New("destination must be a pointer to a slice")
+	}
+
+	table, err := b.Mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
+	qb := b.Mapper.NewQueryBuilder()
+	qb.WriteSelect().WriteFrom(table)
+	query, args := qb.Build()
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("error retrieving columns: %w", err)
+	}
+
+	elemType := destVal.Type().Elem()
+	for rows.Next() {
+		elemVal := reflect.New(elemType.Elem()).Elem()
+		if err := rows.Scan(b.Mapper.ScanDestinations(elemVal, columns)...); err != nil {
+			return fmt.Errorf("error scanning values: %w", err)
+		}
+		destVal.Set(reflect.Append(destVal, elemVal.Addr()))
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over results: %w", err)
+	}
+
+	return b.preloadFromContext(ctx, q, dest)
+}
+
+// Update updates every mapped column of a model by its primary key
+func (b *Backend) Update(ctx context.Context, q Querier, model core.Model) error {
+	fields, err := b.Mapper.StructFields(model)
+	if err != nil {
+		return fmt.Errorf("error retrieving struct fields: %w", err)
+	}
+
+	table, err := b.Mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
+	// Remove the primary key from the fields to be updated
+	primaryKey, primaryKeyValue, err := b.Mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
+	delete(fields, primaryKey)
+
+	qb := b.Mapper.NewQueryBuilder()
+	columns := make([]string, 0, len(fields))
+	values := make([]interface{}, 0, len(fields))
+	for _, column := range b.Mapper.OrderedColumns(model, fields) {
+		columns = append(columns, column)
+		values = append(values, fields[column])
+	}
+
+	qb.WriteUpdate(table, columns, values).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(b.bindValue(primaryKeyValue))))
+
+	query, args := qb.Build()
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error updating record: %w", b.TranslateError(err))
+	}
+	return affected(result, "no records were updated")
+}
+
+// Delete removes a model by its primary key
+func (b *Backend) Delete(ctx context.Context, q Querier, model core.Model) error {
+	table, err := b.Mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+	primaryKey, primaryKeyValue, err := b.Mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
+
+	qb := b.Mapper.NewQueryBuilder()
+	qb.WriteDelete(table).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(b.bindValue(primaryKeyValue))))
+
+	query, args := qb.Build()
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error deleting record: %w", b.TranslateError(err))
+	}
+	return affected(result, "no records were deleted")
+}
+
+// Preload loads the given relations into an already loaded model or slice of models
+func (b *Backend) Preload(ctx context.Context, q Querier, dest interface{}, relations ...string) error {
+	if err := b.Mapper.Preload(ctx, q, dest, relations...); err != nil {
+		return fmt.Errorf("error preloading relations: %w", err)
+	}
+	return nil
+}
+
+// preloadFromContext loads the relations registered in the context by core.WithPreload
+func (b *Backend) preloadFromContext(ctx context.Context, q Querier, dest interface{}) error {
+	relations := core.PreloadFromContext(ctx)
+	if len(relations) == 0 {
+		return nil
+	}
+	return b.Preload(ctx, q, dest, relations...)
+}
+
+// affected returns core.ErrNotFound, described by message, when the statement changed
+// no rows
+func affected(result sql.Result, message string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error retrieving affected rows count: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", message, core.ErrNotFound)
+	}
+	return nil
+}
+
+// bindValue converts a parameter with the dialect, when it implements utils.TypeConverter
+func (b *Backend) bindValue(value interface{}) interface{} {
+	if converter, ok := b.Mapper.Dialect().(utils.TypeConverter); ok {
+		return converter.BindValue(value)
+	}
+	return value
+}
+
+// scanDestination wraps a Scan destination with the dialect, when it implements
+// utils.TypeConverter
+func (b *Backend) scanDestination(dest interface{}) interface{} {
+	if converter, ok := b.Mapper.Dialect().(utils.TypeConverter); ok {
+		return converter.ScanDestination(dest)
+	}
+	return dest
+}
+
+// primaryKeyDestination returns a pointer to a new value of the primary key field type,
+// or to an interface{} when the column has no field
+func (b *Backend) primaryKeyDestination(model core.Model, column string) reflect.Value {
+	if info, ok := utils.FindField(b.Mapper.Fields(reflect.TypeOf(model)), column); ok {
+		return reflect.New(info.Type)
+	}
+	return reflect.New(reflect.TypeOf((*interface{})(nil)).Elem())
+}
+
+// setPrimaryKey sets the generated key on the model
+func (b *Backend) setPrimaryKey(model core.Model, column string, value interface{}) error {
+	if err := b.Mapper.SetField(model, column, value); err != nil {
+		return fmt.Errorf("error setting primary key value: %w", err)
+	}
+	return nil
+}
+
+// isInteger reports whether the value holds a signed or unsigned integer
+func isInteger(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package sqlbase
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// numbered is a dialect with numbered placeholders and RETURNING, like SQLite
+type numbered struct{}
+
+func (numbered) Name() string                       { return "numbered" }
+func (numbered) Placeholder(index int) string       { return "?" + strconv.Itoa(index) }
+func (numbered) QuoteIdentifier(name string) string { return utils.QuoteIdentifier(name) }
+func (numbered) Returning() bool                    { return true }
+func (numbered) Pagination(limit, offset int) string {
+	return utils.LimitOffset(limit, offset, "-1")
+}
+func (d numbered) Upsert(conflict, update []string) string {
+	return utils.ConflictUpsert(d, conflict, update, "excluded")
+}
+
+// positional is a dialect with ? placeholders and without RETURNING, like MySQL
+type positional struct{ numbered }
+
+func (positional) Placeholder(index int) string { return "?" }
+func (positional) Returning() bool              { return false }
+
+type Tag struct {
+	Code  string `db:"code,primary"`
+	Label string `db:"label"`
+}
+
+type Post struct {
+	ID    int64  `db:"id,primary"`
+	Title string `db:"title"`
+	Tags  []*Tag `rel:"many_to_many,join=post_tags,fk=post_id,target_fk=tag_code"`
+}
+
+// newBackend returns a backend of the dialect running against a recorder
+func newBackend(t *testing.T, dialect utils.Dialect) (*Backend, *sqltest.Recorder) {
+	t.Helper()
+	rec := sqltest.New()
+	t.Cleanup(func() { rec.Close() })
+	backend := &Backend{
+		Mapper:         utils.NewMapper(nil).WithDialect(dialect),
+		TranslateError: func(err error) error { return err },
+		DefaultValues:  "DEFAULT VALUES",
+	}
+	return backend, rec
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Returning", func(t *testing.T) {
+		backend, rec := newBackend(t, numbered{})
+
+		// A chave gerada é lida no tipo do campo
+		rec.ExpectQuery(sqltest.Exact(`INSERT INTO "posts" ("title") VALUES (?1) RETURNING "id"`)).
+			WithArgs("hello").
+			WillReturnRows(sqltest.NewRows("id").AddRow(int64(5)))
+
+		post := &Post{Title: "hello"}
+		if err := backend.Create(ctx, rec.DB(), post); err != nil {
+			t.Fatal(err)
+		}
+		if post.ID != 5 {
+			t.Errorf("Expected id 5, got %d", post.ID)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("LastInsertId", func(t *testing.T) {
+		backend, rec := newBackend(t, positional{})
+
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "posts" ("title") VALUES (?)`)).
+			WithArgs("hello").
+			WillReturnResult(9, 1)
+
+		post := &Post{Title: "hello"}
+		if err := backend.Create(ctx, rec.DB(), post); err != nil {
+			t.Fatal(err)
+		}
+		if post.ID != 9 {
+			t.Errorf("Expected id 9, got %d", post.ID)
+		}
+	})
+
+	t.Run("StringPrimaryKey", func(t *testing.T) {
+		backend, rec := newBackend(t, numbered{})
+
+		// A chave informada pelo modelo é inserida sem RETURNING
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "tags" ("code", "label") VALUES (?1, ?2)`)).WithArgs("go", "Go")
+
+		tag := &Tag{Code: "go", Label: "Go"}
+		if err := backend.Create(ctx, rec.DB(), tag); err != nil {
+			t.Fatal(err)
+		}
+		if tag.Code != "go" {
+			t.Errorf("Expected code go, got %s", tag.Code)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("GeneratedStringKey", func(t *testing.T) {
+		backend, rec := newBackend(t, numbered{})
+
+		rec.ExpectQuery(sqltest.Exact(`INSERT INTO "tags" ("label") VALUES (?1) RETURNING "code"`)).
+			WithArgs("Go").
+			WillReturnRows(sqltest.NewRows("code").AddRow("t-1"))
+
+		tag := &Tag{Label: "Go"}
+		if err := backend.Create(ctx, rec.DB(), tag); err != nil {
+			t.Fatal(err)
+		}
+		if tag.Code != "t-1" {
+			t.Errorf("Expected code t-1, got %s", tag.Code)
+		}
+	})
+
+	t.Run("GeneratedStringKeyWithoutReturning", func(t *testing.T) {
+		backend, rec := newBackend(t, positional{})
+
+		// Sem RETURNING, LastInsertId só é lido para chaves inteiras
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "tags" ("label") VALUES (?)`)).WithArgs("Go").WillReturnResult(3, 1)
+
+		tag := &Tag{Label: "Go"}
+		if err := backend.Create(ctx, rec.DB(), tag); err != nil {
+			t.Fatal(err)
+		}
+		if tag.Code != "" {
+			t.Errorf("Expected no code, got %q", tag.Code)
+		}
+	})
+}
+
+func TestAssociation(t *testing.T) {
+	ctx := context.Background()
+	goTag, sqlTag := &Tag{Code: "go"}, &Tag{Code: "sql"}
+
+	t.Run("NumberedPlaceholders", func(t *testing.T) {
+		backend, rec := newBackend(t, numbered{})
+
+		// O parâmetro do dono é compartilhado pelas linhas e os modelos repetidos são ignorados
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "post_tags" ("post_id", "tag_code") VALUES (?1, ?2), (?1, ?3) `+
+			`ON CONFLICT ("post_id", "tag_code") DO NOTHING`)).WithArgs(int64(1), "go", "sql")
+
+		post := &Post{ID: 1, Tags: []*Tag{goTag}}
+		association, err := backend.Association(rec.DB(), post, "Tags")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := association.Append(ctx, goTag, sqlTag, sqlTag); err != nil {
+			t.Fatal(err)
+		}
+		if len(post.Tags) != 2 || post.Tags[1].Code != "sql" {
+			t.Errorf("Expected tags go and sql, got %+v", post.Tags)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("PositionalPlaceholders", func(t *testing.T) {
+		backend, rec := newBackend(t, positional{})
+
+		// Os marcadores ? não podem ser reutilizados, então o dono é enviado em cada linha
+		rec.ExpectExec(sqltest.Exact(`DELETE FROM "post_tags" WHERE "post_id" = ? AND NOT "tag_code" IN (?, ?)`)).
+			WithArgs(int64(1), "go", "sql")
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "post_tags" ("post_id", "tag_code") VALUES (?, ?), (?, ?) `+
+			`ON CONFLICT ("post_id", "tag_code") DO NOTHING`)).WithArgs(int64(1), "go", int64(1), "sql")
+
+		post := &Post{ID: 1}
+		association, err := backend.Association(rec.DB(), post, "Tags")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := association.Replace(ctx, goTag, sqlTag); err != nil {
+			t.Fatal(err)
+		}
+		if len(post.Tags) != 2 {
+			t.Errorf("Expected 2 tags, got %+v", post.Tags)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
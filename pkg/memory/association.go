@@ -0,0 +1,238 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// MemoryAssociation manages the join table rows of a many_to_many relation within a
+// transaction
+type MemoryAssociation struct {
+	tx           *MemoryTransaction
+	mapper       *utils.Mapper
+	owner        reflect.Value
+	ownerKey     string
+	relation     utils.Relation
+	targetColumn string
+}
+
+// Association returns the operations for the given many_to_many relation of the model
+func (t *MemoryTransaction) Association(model core.Model, relation string) (core.Association, error) {
+	val := reflect.ValueOf(model)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("model must be a non-nil pointer to a struct")
+	}
+	val = val.Elem()
+
+	mapper := t.orm.mapper
+	rel, err := utils.GetRelation(val.Type(), relation)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Kind != utils.ManyToMany {
+		return nil, fmt.Errorf("relation %s is not many_to_many", relation)
+	}
+
+	ownerColumn, targetColumn, err := mapper.KeyColumns(rel, val.Type())
+	if err != nil {
+		return nil, fmt.Errorf("error resolving relation keys: %w", err)
+	}
+	rel.JoinTable = mapper.JoinTable(rel)
+	ownerField, ok := utils.FindField(mapper.Fields(val.Type()), ownerColumn)
+	if !ok {
+		return nil, fmt.Errorf("column %s not found in %s", ownerColumn, val.Type())
+	}
+	ownerKey, _, ok := utils.RelationKey(val.FieldByIndex(ownerField.Index))
+	if !ok || val.FieldByIndex(ownerField.Index).IsZero() {
+		return nil, errors.New("model must be persisted before managing its associations")
+	}
+
+	return &MemoryAssociation{
+		tx:           t,
+		mapper:       mapper,
+		owner:        val,
+		ownerKey:     ownerKey,
+		relation:     rel,
+		targetColumn: targetColumn,
+	}, nil
+}
+
+// Append associates the given models, ignoring associations that already exist
+func (a *MemoryAssociation) Append(ctx context.Context, related ...interface{}) error {
+	return a.change(related, func(rows []joinRow, keys map[string]bool, ordered []string) []joinRow {
+		for _, key := range ordered {
+			if !hasJoin(rows, a.ownerKey, key) {
+				rows = append(rows, joinRow{owner: a.ownerKey, target: key})
+			}
+		}
+		return rows
+	}, func(current, items []reflect.Value, keys map[string]bool) []reflect.Value {
+		// Models already held by the relation field are not added again
+		present := make(map[string]bool, len(current))
+		for _, item := range current {
+			if key, ok := a.targetKey(item); ok {
+				present[key] = true
+			}
+		}
+		for _, item := range items {
+			if key, _ := a.targetKey(item); !present[key] {
+				current = append(current, item)
+			}
+		}
+		return current
+	})
+}
+
+// Remove removes the associations with the given models
+func (a *MemoryAssociation) Remove(ctx context.Context, related ...interface{}) error {
+	return a.change(related, func(rows []joinRow, keys map[string]bool, ordered []string) []joinRow {
+		return a.filter(rows, func(row joinRow) bool { return !keys[row.target] })
+	}, func(current, items []reflect.Value, keys map[string]bool) []reflect.Value {
+		remaining := make([]reflect.Value, 0, len(current))
+		for _, item := range current {
+			if key, ok := a.targetKey(item); !ok || !keys[key] {
+				remaining = append(remaining, item)
+			}
+		}
+		return remaining
+	})
+}
+
+// Replace replaces all associations with the given models
+func (a *MemoryAssociation) Replace(ctx context.Context, related ...interface{}) error {
+	return a.change(related, func(rows []joinRow, keys map[string]bool, ordered []string) []joinRow {
+		rows = a.filter(rows, func(joinRow) bool { return false })
+		for _, key := range ordered {
+			rows = append(rows, joinRow{owner: a.ownerKey, target: key})
+		}
+		return rows
+	}, func(current, items []reflect.Value, keys map[string]bool) []reflect.Value {
+		return items
+	})
+}
+
+// Clear removes all associations of the model
+func (a *MemoryAssociation) Clear(ctx context.Context) error {
+	return a.Replace(ctx)
+}
+
+// change validates the related models, rewrites the join rows and updates the
+// relation field of the owner
+func (a *MemoryAssociation) change(related []interface{},
+	rewrite func(rows []joinRow, keys map[string]bool, ordered []string) []joinRow,
+	update func(current, items []reflect.Value, keys map[string]bool) []reflect.Value) error {
+	items, ordered, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]bool, len(ordered))
+	for _, key := range ordered {
+		keys[key] = true
+	}
+
+	return a.tx.run(func() error {
+		data := a.tx.data
+		rows := append([]joinRow(nil), data.joins[a.relation.JoinTable]...)
+		data.setJoins(a.relation.JoinTable, rewrite(rows, keys, ordered))
+		a.setField(update(a.fieldItems(), items, keys))
+		return nil
+	})
+}
+
+// filter keeps the rows of other owners and the rows of this owner accepted by keep
+func (a *MemoryAssociation) filter(rows []joinRow, keep func(joinRow) bool) []joinRow {
+	kept := make([]joinRow, 0, len(rows))
+	for _, row := range rows {
+		if row.owner != a.ownerKey || keep(row) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// hasJoin reports whether the join rows link the owner and the target
+func hasJoin(rows []joinRow, owner, target string) bool {
+	for _, row := range rows {
+		if row.owner == owner && row.target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// targets validates the related models and returns the first model of each key, with
+// the distinct keys
+func (a *MemoryAssociation) targets(related []interface{}) ([]reflect.Value, []string, error) {
+	items := make([]reflect.Value, 0, len(related))
+	keys := make([]string, 0, len(related))
+	seen := make(map[string]bool, len(related))
+	for _, model := range related {
+		val := reflect.ValueOf(model)
+		if val.Kind() == reflect.Ptr && !val.IsNil() {
+			val = val.Elem()
+		}
+		if !val.IsValid() || val.Type() != a.relation.Target {
+			return nil, nil, fmt.Errorf("expected %s, got %T", a.relation.Target, model)
+		}
+		if !val.CanAddr() {
+			val = copyRow(val)
+		}
+
+		key, ok := a.targetKey(val)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s must be persisted before being associated", a.relation.Target)
+		}
+		if !seen[key] {
+			seen[key] = true
+			items = append(items, val)
+			keys = append(keys, key)
+		}
+	}
+	return items, keys, nil
+}
+
+// targetKey returns the key of a related model referenced by the join table
+func (a *MemoryAssociation) targetKey(item reflect.Value) (string, bool) {
+	field, ok := utils.FindField(a.mapper.Fields(a.relation.Target), a.targetColumn)
+	if !ok || item.FieldByIndex(field.Index).IsZero() {
+		return "", false
+	}
+	key, _, ok := utils.RelationKey(item.FieldByIndex(field.Index))
+	return key, ok
+}
+
+// fieldItems returns the models currently held by the relation field
+func (a *MemoryAssociation) fieldItems() []reflect.Value {
+	field := a.owner.FieldByIndex(a.relation.Index)
+	items := make([]reflect.Value, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		item := field.Index(i)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// setField replaces the relation field with the given models
+func (a *MemoryAssociation) setField(items []reflect.Value) {
+	field := a.owner.FieldByIndex(a.relation.Index)
+	slice := reflect.MakeSlice(a.relation.Type, 0, len(items))
+	for _, item := range items {
+		if a.relation.Type.Elem().Kind() == reflect.Ptr {
+			slice = reflect.Append(slice, item.Addr())
+		} else {
+			slice = reflect.Append(slice, item)
+		}
+	}
+	field.Set(slice)
+}
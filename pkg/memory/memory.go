@@ -0,0 +1,474 @@
+// Package memory provides an in-memory implementation of core.ORM and core.Transaction
+// for unit tests of code that depends on the ORM. Models are stored by table and primary
+// key; custom SQL is not supported.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// ErrUnsupported is returned by Query and Exec, since the in-memory ORM does not run SQL
+var ErrUnsupported = errors.New("memory: custom SQL is not supported")
+
+// MemoryORM is the in-memory ORM implementation. It is safe for concurrent use.
+type MemoryORM struct {
+	mu     sync.RWMutex
+	data   *store
+	mapper *utils.Mapper
+	naming utils.NamingStrategy
+
+	// Sequences are shared by the ORM and its transactions and, as in databases, are
+	// not rolled back
+	seqMu     sync.Mutex
+	sequences map[string]int64
+}
+
+// NewMemoryORM creates a new, empty in-memory ORM
+func NewMemoryORM(opts ...Option) *MemoryORM {
+	m := &MemoryORM{data: newStore(), sequences: make(map[string]int64)}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.mapper = utils.DefaultMapper
+	if m.naming != nil {
+		m.mapper = utils.NewMapper(m.naming)
+	}
+	return m
+}
+
+// Mapper returns the mapper resolving table names and primary keys of the models
+func (m *MemoryORM) Mapper() *utils.Mapper {
+	return m.mapper
+}
+
+// Connect does nothing: the in-memory ORM is always ready
+func (m *MemoryORM) Connect(ctx context.Context, connectionString string) error {
+	return nil
+}
+
+// Close does nothing; the stored models are kept
+func (m *MemoryORM) Close() error {
+	return nil
+}
+
+// DB returns nil: there is no underlying database
+func (m *MemoryORM) DB() *sql.DB {
+	return nil
+}
+
+// Reset removes every stored model and restarts the generated IDs
+func (m *MemoryORM) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = newStore()
+
+	m.seqMu.Lock()
+	defer m.seqMu.Unlock()
+	m.sequences = make(map[string]int64)
+}
+
+// Create stores a copy of the model. A zero integer primary key is generated from a
+// sequence per table; an existing key or a repeated value in a column tagged unique
+// returns core.ErrDuplicate.
+func (m *MemoryORM) Create(ctx context.Context, model core.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.create(m.data, model)
+}
+
+// FindByID copies the stored model with the given primary key into model, or returns
+// core.ErrNotFound
+func (m *MemoryORM) FindByID(ctx context.Context, model core.Model, id interface{}) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	val := reflect.ValueOf(model)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.New("model must be a non-nil pointer to a struct")
+	}
+	table, err := m.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+	key, ok := keyOf(id)
+	if !ok {
+		return core.ErrNotFound
+	}
+	row, ok := m.data.lookup(table).rows[key]
+	if !ok {
+		return core.ErrNotFound
+	}
+	val.Elem().Set(copyRow(row))
+
+	return m.preloadFromContext(ctx, m.data, model)
+}
+
+// FindAll copies every stored model of the model's table into dest, a pointer to a
+// slice of structs or of pointers to structs, in insertion order
+func (m *MemoryORM) FindAll(ctx context.Context, model core.Model, dest interface{}) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.IsNil() || destVal.Elem().Kind() != reflect.Slice {
+		return errors.New("destination must be a non-nil pointer to a slice")
+	}
+	destVal = destVal.Elem()
+
+	table, err := m.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
+	elemType := destVal.Type().Elem()
+	t := m.data.lookup(table)
+	for _, key := range t.order {
+		row := t.rows[key]
+		item := reflect.New(row.Type())
+		item.Elem().Set(copyRow(row))
+		if elemType.Kind() == reflect.Ptr {
+			destVal.Set(reflect.Append(destVal, item))
+		} else {
+			destVal.Set(reflect.Append(destVal, item.Elem()))
+		}
+	}
+
+	return m.preloadFromContext(ctx, m.data, dest)
+}
+
+// Update replaces the stored copy of the model, or returns core.ErrNotFound
+func (m *MemoryORM) Update(ctx context.Context, model core.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.update(m.data, model)
+}
+
+// Delete removes the stored model, or returns core.ErrNotFound
+func (m *MemoryORM) Delete(ctx context.Context, model core.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.delete(m.data, model)
+}
+
+// Query returns ErrUnsupported
+func (m *MemoryORM) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, ErrUnsupported
+}
+
+// Exec returns ErrUnsupported
+func (m *MemoryORM) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, ErrUnsupported
+}
+
+// Preload loads the given relations from the stored models
+func (m *MemoryORM) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.preload(ctx, m.data, dest, relations...)
+}
+
+// preload loads the relations of dest from the models held by the store
+func (m *MemoryORM) preload(ctx context.Context, st *store, dest interface{}, relations ...string) error {
+	if err := m.mapper.PreloadFrom(ctx, loader{mapper: m.mapper, data: st}, dest, relations...); err != nil {
+		return fmt.Errorf("error preloading relations: %w", err)
+	}
+	return nil
+}
+
+// preloadFromContext loads the relations registered in the context by core.WithPreload
+func (m *MemoryORM) preloadFromContext(ctx context.Context, st *store, dest interface{}) error {
+	relations := core.PreloadFromContext(ctx)
+	if len(relations) == 0 {
+		return nil
+	}
+	return m.preload(ctx, st, dest, relations...)
+}
+
+// Transaction starts a transaction working on a snapshot of the stored models. Its
+// changes are invisible outside the transaction until Commit; Rollback discards them.
+func (m *MemoryORM) Transaction(ctx context.Context) (core.Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data := m.data.clone()
+	data.touched = make(map[string]map[string]bool)
+	data.touchedJoins = make(map[string]bool)
+	return &MemoryTransaction{orm: m, data: data, snapshot: m.data.clone()}, nil
+}
+
+// create stores a copy of the model in the store
+func (m *MemoryORM) create(st *store, model core.Model) error {
+	val, table, err := m.resolve(model)
+	if err != nil {
+		return err
+	}
+	t := st.table(table)
+
+	// Models without a primary key are stored under a synthetic key
+	primaryKey, primaryKeyValue, err := m.mapper.PrimaryKey(model)
+	if err != nil {
+		return st.put(table, fmt.Sprintf("#%d", m.nextID(table+"#")), m.copyModel(val))
+	}
+
+	if primaryKeyValue == nil || reflect.ValueOf(primaryKeyValue).IsZero() {
+		if _, ok := integerKey(primaryKeyValue); !ok {
+			return fmt.Errorf("error generating primary key: %s is not an integer", primaryKey)
+		}
+		if err := m.mapper.SetField(model, primaryKey, m.nextID(table)); err != nil {
+			return fmt.Errorf("error generating primary key: %w", err)
+		}
+		_, primaryKeyValue, _ = m.mapper.PrimaryKey(model)
+	}
+
+	key, ok := keyOf(primaryKeyValue)
+	if !ok {
+		return errors.New("error inserting record: primary key is nil")
+	}
+	if _, exists := t.rows[key]; exists {
+		return fmt.Errorf("error inserting record: %w: %s %s already exists", core.ErrDuplicate, table, key)
+	}
+	if err := m.checkUnique(t, val, key); err != nil {
+		return fmt.Errorf("error inserting record: %w", err)
+	}
+	if id, ok := integerKey(primaryKeyValue); ok {
+		m.advance(table, id) // Explicit keys advance the sequence, so generated keys never collide
+	}
+	return st.put(table, key, m.copyModel(val))
+}
+
+// nextID returns the next value of the named sequence
+func (m *MemoryORM) nextID(name string) int64 {
+	m.seqMu.Lock()
+	defer m.seqMu.Unlock()
+	m.sequences[name]++
+	return m.sequences[name]
+}
+
+// advance moves the named sequence past an explicit key
+func (m *MemoryORM) advance(name string, id int64) {
+	m.seqMu.Lock()
+	defer m.seqMu.Unlock()
+	if id > m.sequences[name] {
+		m.sequences[name] = id
+	}
+}
+
+// update replaces the stored copy of the model
+func (m *MemoryORM) update(st *store, model core.Model) error {
+	val, table, err := m.resolve(model)
+	if err != nil {
+		return err
+	}
+	_, primaryKeyValue, err := m.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
+
+	t := st.table(table)
+	key, ok := keyOf(primaryKeyValue)
+	if _, exists := t.rows[key]; !ok || !exists {
+		return fmt.Errorf("no records were updated: %w", core.ErrNotFound)
+	}
+	if err := m.checkUnique(t, val, key); err != nil {
+		return fmt.Errorf("error updating record: %w", err)
+	}
+	return st.put(table, key, m.copyModel(val))
+}
+
+// delete removes the stored model
+func (m *MemoryORM) delete(st *store, model core.Model) error {
+	_, table, err := m.resolve(model)
+	if err != nil {
+		return err
+	}
+	_, primaryKeyValue, err := m.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
+
+	key, ok := keyOf(primaryKeyValue)
+	if _, exists := st.lookup(table).rows[key]; !ok || !exists {
+		return fmt.Errorf("no records were deleted: %w", core.ErrNotFound)
+	}
+	st.remove(table, key)
+	return nil
+}
+
+// resolve returns the struct value and the table of a model
+func (m *MemoryORM) resolve(model core.Model) (reflect.Value, string, error) {
+	val := reflect.ValueOf(model)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, "", errors.New("model must be a non-nil pointer to a struct")
+	}
+	table, err := m.mapper.ModelTableName(model)
+	if err != nil {
+		return reflect.Value{}, "", fmt.Errorf("error resolving table name: %w", err)
+	}
+	return val.Elem(), table, nil
+}
+
+// checkUnique returns core.ErrDuplicate when another stored row has the same non-zero
+// value in a column tagged unique
+func (m *MemoryORM) checkUnique(t *table, val reflect.Value, key string) error {
+	for _, field := range m.mapper.Fields(val.Type()) {
+		if !field.Options.Has("unique") {
+			continue
+		}
+		value, _, ok := utils.RelationKey(val.FieldByIndex(field.Index))
+		if !ok || val.FieldByIndex(field.Index).IsZero() {
+			continue
+		}
+		for rowKey, row := range t.rows {
+			if rowKey == key {
+				continue
+			}
+			if other, _, ok := utils.RelationKey(row.FieldByIndex(field.Index)); ok && other == value {
+				return fmt.Errorf("%w: %s = %s", core.ErrDuplicate, field.Column, value)
+			}
+		}
+	}
+	return nil
+}
+
+// copyModel returns a copy of the struct without its relation fields, which are only
+// filled by Preload, as with a database
+func (m *MemoryORM) copyModel(val reflect.Value) reflect.Value {
+	copied := copyRow(val)
+	if relations, err := utils.GetRelations(val.Type()); err == nil {
+		for _, relation := range relations {
+			field := copied.FieldByIndex(relation.Index)
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	return copied
+}
+
+// integerKey returns the value of an integer primary key
+func integerKey(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// MemoryTransaction is the in-memory transaction implementation
+type MemoryTransaction struct {
+	mu       sync.Mutex
+	orm      *MemoryORM
+	data     *store
+	snapshot *store // the stored models when the transaction started
+	done     bool
+}
+
+// Commit applies the rows created, updated or deleted by the transaction to the ORM.
+// Rows changed by both the transaction and others since it started keep the
+// transaction's version. When a row the transaction inserted was also inserted by
+// others since it started, or a row it touched repeats a value of a column tagged
+// unique, Commit applies nothing and returns core.ErrDuplicate.
+func (t *MemoryTransaction) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+
+	t.orm.mu.Lock()
+	defer t.orm.mu.Unlock()
+	merged := t.orm.data.clone()
+	merged.apply(t.data)
+	if err := t.checkCommit(merged); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	t.orm.data = merged
+	return nil
+}
+
+// checkCommit checks the rows touched by the transaction against the keys committed
+// since it started and against the unique columns of merged, the stored models with
+// the transaction applied; the caller holds t.orm.mu
+func (t *MemoryTransaction) checkCommit(merged *store) error {
+	for name, keys := range t.data.touched {
+		touched := t.data.lookup(name)
+		committed := t.orm.data.lookup(name)
+		snapshot := t.snapshot.lookup(name)
+		for key := range keys {
+			row, ok := touched.rows[key]
+			if !ok {
+				continue
+			}
+			_, existed := snapshot.rows[key]
+			if _, exists := committed.rows[key]; exists && !existed {
+				return fmt.Errorf("%w: %s %s already exists", core.ErrDuplicate, name, key)
+			}
+			if err := t.orm.checkUnique(merged.lookup(name), row, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rollback discards the changes made by the transaction
+func (t *MemoryTransaction) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	return nil
+}
+
+// Create stores a copy of the model within the transaction
+func (t *MemoryTransaction) Create(ctx context.Context, model core.Model) error {
+	return t.run(func() error { return t.orm.create(t.data, model) })
+}
+
+// Update replaces the stored copy of the model within the transaction
+func (t *MemoryTransaction) Update(ctx context.Context, model core.Model) error {
+	return t.run(func() error { return t.orm.update(t.data, model) })
+}
+
+// Delete removes the stored model within the transaction
+func (t *MemoryTransaction) Delete(ctx context.Context, model core.Model) error {
+	return t.run(func() error { return t.orm.delete(t.data, model) })
+}
+
+// Query returns ErrUnsupported
+func (t *MemoryTransaction) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, ErrUnsupported
+}
+
+// Exec returns ErrUnsupported
+func (t *MemoryTransaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, ErrUnsupported
+}
+
+// Preload loads the given relations from the models visible to the transaction
+func (t *MemoryTransaction) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	return t.run(func() error { return t.orm.preload(ctx, t.data, dest, relations...) })
+}
+
+// run executes an operation on the transaction snapshot, failing after Commit or Rollback
+func (t *MemoryTransaction) run(operation func() error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return sql.ErrTxDone
+	}
+	return operation()
+}
@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+)
+
+var (
+	_ core.ORM         = (*MemoryORM)(nil)
+	_ core.Transaction = (*MemoryTransaction)(nil)
+)
+
+type Customer struct {
+	ID     int64    `db:"id,primary"`
+	Email  string   `db:"email,unique"`
+	Orders []*Order `rel:"has_many,fk=customer_id"`
+	Tags   []Tag    `rel:"many_to_many,join=customer_tags,fk=customer_id,target_fk=tag_id"`
+}
+
+type Order struct {
+	ID         int64     `db:"id,primary"`
+	CustomerID int64     `db:"customer_id"`
+	Total      float64   `db:"total"`
+	Customer   *Customer `rel:"belongs_to,fk=customer_id"`
+}
+
+type Tag struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+type Setting struct {
+	Key   string `db:"key,primary"`
+	Value string `db:"value"`
+}
+
+type Profile struct {
+	ID       int64             `db:"id,primary"`
+	Labels   []string          `db:"labels"`
+	Meta     map[string]string `db:"meta"`
+	Nickname *string           `db:"nickname"`
+}
+
+func TestMemoryORM(t *testing.T) {
+	ctx := context.Background()
+	orm := NewMemoryORM()
+
+	ana := &Customer{Email: "ana@example.com"}
+	bia := &Customer{Email: "bia@example.com"}
+	for _, customer := range []*Customer{ana, bia} {
+		if err := orm.Create(ctx, customer); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+	if ana.ID != 1 || bia.ID != 2 {
+		t.Errorf("Expected generated IDs 1 and 2, got %d and %d", ana.ID, bia.ID)
+	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		if err := orm.Create(ctx, &Customer{ID: 1, Email: "other@example.com"}); !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate for an existing key, got %v", err)
+		}
+		if err := orm.Create(ctx, &Customer{Email: "ana@example.com"}); !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate for a unique column, got %v", err)
+		}
+
+		// Chaves explícitas avançam a sequência
+		explicit := &Customer{ID: 10, Email: "carla@example.com"}
+		next := &Customer{Email: "davi@example.com"}
+		if err := orm.Create(ctx, explicit); err != nil {
+			t.Fatal(err)
+		}
+		if err := orm.Create(ctx, next); err != nil {
+			t.Fatal(err)
+		}
+		if next.ID != 11 {
+			t.Errorf("Expected ID 11 after an explicit key, got %d", next.ID)
+		}
+	})
+
+	t.Run("FindByID", func(t *testing.T) {
+		found := &Customer{}
+		if err := orm.FindByID(ctx, found, 1); err != nil {
+			t.Fatalf("FindByID returned error: %v", err)
+		}
+		if found.Email != "ana@example.com" {
+			t.Errorf("Expected ana, got %+v", found)
+		}
+
+		// O modelo armazenado é uma cópia
+		found.Email = "changed@example.com"
+		again := &Customer{}
+		orm.FindByID(ctx, again, int64(1))
+		if again.Email != "ana@example.com" {
+			t.Errorf("Expected stored copy to be unchanged, got %s", again.Email)
+		}
+
+		if err := orm.FindByID(ctx, &Customer{}, 99); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("UpdateDelete", func(t *testing.T) {
+		bia.Email = "bia@example.org"
+		if err := orm.Update(ctx, bia); err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+		if err := orm.Update(ctx, &Customer{ID: 99}); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := orm.Update(ctx, &Customer{ID: 2, Email: "ana@example.com"}); !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+
+		if err := orm.Delete(ctx, bia); err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+		if err := orm.Delete(ctx, bia); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+
+		var customers []Customer
+		if err := orm.FindAll(ctx, &Customer{}, &customers); err != nil {
+			t.Fatalf("FindAll returned error: %v", err)
+		}
+		if len(customers) != 3 || customers[0].ID != 1 || customers[1].ID != 10 {
+			t.Errorf("Expected customers in insertion order, got %+v", customers)
+		}
+	})
+
+	t.Run("Preload", func(t *testing.T) {
+		for _, order := range []*Order{{CustomerID: 1, Total: 10}, {CustomerID: 1, Total: 20}, {CustomerID: 10, Total: 5}} {
+			if err := orm.Create(ctx, order); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		customer := &Customer{}
+		if err := orm.FindByID(core.WithPreload(ctx, "Orders.Customer"), customer, 1); err != nil {
+			t.Fatalf("FindByID returned error: %v", err)
+		}
+		if len(customer.Orders) != 2 || customer.Orders[1].Total != 20 {
+			t.Fatalf("Expected two preloaded orders, got %+v", customer.Orders)
+		}
+		if customer.Orders[0].Customer == nil || customer.Orders[0].Customer.Email != "ana@example.com" {
+			t.Errorf("Expected nested customer, got %+v", customer.Orders[0].Customer)
+		}
+	})
+
+	t.Run("NonIntegerKey", func(t *testing.T) {
+		if err := orm.Create(ctx, &Setting{Key: "theme", Value: "dark"}); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if err := orm.Create(ctx, &Setting{Value: "x"}); err == nil {
+			t.Error("Expected error generating a string key")
+		}
+		setting := &Setting{}
+		if err := orm.FindByID(ctx, setting, "theme"); err != nil || setting.Value != "dark" {
+			t.Errorf("Expected stored setting, got %+v (%v)", setting, err)
+		}
+	})
+
+	t.Run("Copies", func(t *testing.T) {
+		nickname := "ana"
+		profile := &Profile{Labels: []string{"a"}, Meta: map[string]string{"k": "v"}, Nickname: &nickname}
+		if err := orm.Create(ctx, profile); err != nil {
+			t.Fatal(err)
+		}
+
+		// Alterações no modelo criado ou nos modelos lidos não afetam o modelo armazenado
+		profile.Labels[0], profile.Meta["k"], *profile.Nickname = "x", "x", "x"
+		loaded := &Profile{}
+		if err := orm.FindByID(ctx, loaded, profile.ID); err != nil {
+			t.Fatal(err)
+		}
+		loaded.Labels[0], loaded.Meta["k"], *loaded.Nickname = "y", "y", "y"
+		var profiles []Profile
+		if err := orm.FindAll(ctx, &Profile{}, &profiles); err != nil {
+			t.Fatal(err)
+		}
+		stored := profiles[0]
+		if stored.Labels[0] != "a" || stored.Meta["k"] != "v" || *stored.Nickname != "ana" {
+			t.Errorf("Expected the stored profile to be unchanged, got %v %v %s", stored.Labels, stored.Meta, *stored.Nickname)
+		}
+	})
+
+	if _, err := orm.Exec(ctx, "DELETE FROM customers"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestMemoryTransaction(t *testing.T) {
+	ctx := context.Background()
+	orm := NewMemoryORM()
+	customer := &Customer{Email: "ana@example.com"}
+	if err := orm.Create(ctx, customer); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Rollback", func(t *testing.T) {
+		tx, _ := orm.Transaction(ctx)
+		if err := tx.Create(ctx, &Customer{Email: "bia@example.com"}); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if err := tx.Delete(ctx, customer); err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+
+		// As alterações não são visíveis fora da transação
+		var customers []*Customer
+		orm.FindAll(ctx, &Customer{}, &customers)
+		if len(customers) != 1 || customers[0].ID != customer.ID {
+			t.Errorf("Expected uncommitted changes to be isolated, got %+v", customers)
+		}
+
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Create(ctx, &Customer{Email: "x@example.com"}); !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("Expected sql.ErrTxDone after rollback, got %v", err)
+		}
+		if err := orm.FindByID(ctx, &Customer{}, customer.ID); err != nil {
+			t.Errorf("Expected customer to survive rollback, got %v", err)
+		}
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		news, sale := &Tag{Name: "news"}, &Tag{Name: "sale"}
+		orm.Create(ctx, news)
+		orm.Create(ctx, sale)
+
+		tx, _ := orm.Transaction(ctx)
+		created := &Customer{Email: "bia@example.com"}
+		if err := tx.Create(ctx, created); err != nil {
+			t.Fatal(err)
+		}
+		association, err := tx.Association(customer, "Tags")
+		if err != nil {
+			t.Fatalf("Association returned error: %v", err)
+		}
+		if err := association.Append(ctx, news, sale, news); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+		if err := association.Append(ctx, sale); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+		if len(customer.Tags) != 2 {
+			t.Errorf("Expected repeated models to be appended once, got %+v", customer.Tags)
+		}
+		if err := association.Remove(ctx, news); err != nil {
+			t.Fatalf("Remove returned error: %v", err)
+		}
+		if len(customer.Tags) != 1 || customer.Tags[0].Name != "sale" {
+			t.Errorf("Expected the relation field to follow the changes, got %+v", customer.Tags)
+		}
+
+		// Modelos criados fora da transação depois do seu início são mantidos no commit
+		outside := &Customer{Email: "carla@example.com"}
+		if err := orm.Create(ctx, outside); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		var customers []*Customer
+		if err := orm.FindAll(core.WithPreload(ctx, "Tags"), &Customer{}, &customers); err != nil {
+			t.Fatal(err)
+		}
+		if len(customers) != 3 {
+			t.Fatalf("Expected three customers after commit, got %d", len(customers))
+		}
+		if len(customers[0].Tags) != 1 || customers[0].Tags[0].Name != "sale" {
+			t.Errorf("Expected committed association, got %+v", customers[0].Tags)
+		}
+		if created.ID == outside.ID {
+			t.Errorf("Expected distinct generated IDs, got %d twice", created.ID)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		first, _ := orm.Transaction(ctx)
+		second, _ := orm.Transaction(ctx)
+		if err := first.Create(ctx, &Customer{ID: 100, Email: "first@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := second.Create(ctx, &Customer{ID: 100, Email: "second@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := first.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		// A chave inserida pela outra transação é verificada no commit
+		if err := second.Commit(); !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate for a key committed meanwhile, got %v", err)
+		}
+		stored := &Customer{}
+		if err := orm.FindByID(ctx, stored, 100); err != nil || stored.Email != "first@example.com" {
+			t.Errorf("Expected the first transaction's row, got %+v (%v)", stored, err)
+		}
+
+		// Assim como os valores das colunas unique
+		first, _ = orm.Transaction(ctx)
+		second, _ = orm.Transaction(ctx)
+		first.Create(ctx, &Customer{Email: "dup@example.com"})
+		second.Create(ctx, &Customer{Email: "dup@example.com"})
+		if err := first.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err := second.Commit(); !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate for a unique value committed meanwhile, got %v", err)
+		}
+	})
+}
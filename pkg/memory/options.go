@@ -0,0 +1,14 @@
+package memory
+
+import "github.com/rodolfocoding/night-orm/pkg/utils"
+
+// Option configures a MemoryORM
+type Option func(*MemoryORM)
+
+// WithNamingStrategy sets the strategy deriving the table, column and join table
+// names that models do not declare explicitly, as in the ORM under test
+func WithNamingStrategy(naming utils.NamingStrategy) Option {
+	return func(m *MemoryORM) {
+		m.naming = naming
+	}
+}
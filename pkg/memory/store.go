@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// table holds the rows of a table by primary key, in insertion order
+type table struct {
+	rows  map[string]reflect.Value
+	order []string
+}
+
+// joinRow is a row of a many_to_many join table, holding both keys as utils.KeyString
+type joinRow struct {
+	owner  string
+	target string
+}
+
+// store holds the tables and join tables. The stores of transactions also record
+// the rows they touch, so Commit applies only those.
+type store struct {
+	tables       map[string]*table
+	joins        map[string][]joinRow
+	touched      map[string]map[string]bool
+	touchedJoins map[string]bool
+}
+
+// newStore creates an empty store
+func newStore() *store {
+	return &store{tables: make(map[string]*table), joins: make(map[string][]joinRow)}
+}
+
+// table returns the table with the given name, creating it when missing
+func (s *store) table(name string) *table {
+	t, ok := s.tables[name]
+	if !ok {
+		t = &table{rows: make(map[string]reflect.Value)}
+		s.tables[name] = t
+	}
+	return t
+}
+
+// lookup returns the table with the given name, or an empty one, without changing the store
+func (s *store) lookup(name string) *table {
+	if t, ok := s.tables[name]; ok {
+		return t
+	}
+	return &table{rows: map[string]reflect.Value{}}
+}
+
+// put stores a row, keeping the insertion order of new keys
+func (s *store) put(name, key string, row reflect.Value) error {
+	t := s.table(name)
+	if _, exists := t.rows[key]; !exists {
+		t.order = append(t.order, key)
+	}
+	t.rows[key] = row
+	s.touch(name, key)
+	return nil
+}
+
+// remove deletes a row
+func (s *store) remove(name, key string) {
+	t := s.table(name)
+	delete(t.rows, key)
+	for i, existing := range t.order {
+		if existing == key {
+			t.order = append(t.order[:i:i], t.order[i+1:]...)
+			break
+		}
+	}
+	s.touch(name, key)
+}
+
+// touch records a changed row in the store of a transaction
+func (s *store) touch(name, key string) {
+	if s.touched == nil {
+		return
+	}
+	if s.touched[name] == nil {
+		s.touched[name] = make(map[string]bool)
+	}
+	s.touched[name][key] = true
+}
+
+// setJoins replaces the rows of a join table
+func (s *store) setJoins(name string, rows []joinRow) {
+	s.joins[name] = rows
+	if s.touchedJoins != nil {
+		s.touchedJoins[name] = true
+	}
+}
+
+// clone returns a copy of the store; stored rows are deep copies never modified in
+// place nor handed to callers, so they are shared
+func (s *store) clone() *store {
+	copied := newStore()
+	for name, t := range s.tables {
+		rows := make(map[string]reflect.Value, len(t.rows))
+		for key, row := range t.rows {
+			rows[key] = row
+		}
+		copied.tables[name] = &table{rows: rows, order: append([]string(nil), t.order...)}
+	}
+	for name, rows := range s.joins {
+		copied.joins[name] = append([]joinRow(nil), rows...)
+	}
+	return copied
+}
+
+// apply copies the rows touched by a transaction store into this store
+func (s *store) apply(tx *store) {
+	for name, keys := range tx.touched {
+		source := tx.lookup(name)
+		target := s.table(name)
+		for key := range keys {
+			if row, ok := source.rows[key]; ok {
+				s.put(name, key, row)
+			} else if _, exists := target.rows[key]; exists {
+				s.remove(name, key)
+			}
+		}
+	}
+	for name := range tx.touchedJoins {
+		s.joins[name] = append([]joinRow(nil), tx.joins[name]...)
+	}
+}
+
+// keyOf returns the key of a primary or foreign key value; nil has no key
+func keyOf(value interface{}) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	key, _, ok := utils.RelationKey(reflect.ValueOf(value))
+	return key, ok
+}
+
+// loader implements utils.RelationLoader over the models held by a store
+type loader struct {
+	mapper *utils.Mapper
+	data   *store
+}
+
+// LoadRelated returns copies of the stored models of the target type whose column has
+// one of the keys
+func (l loader) LoadRelated(ctx context.Context, target reflect.Type, column string, keys []interface{}) ([]reflect.Value, error) {
+	t, field, err := l.targetTable(target, column)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if k, ok := keyOf(key); ok {
+			wanted[k] = true
+		}
+	}
+
+	related := make([]reflect.Value, 0)
+	for _, rowKey := range t.order {
+		row := t.rows[rowKey]
+		if key, _, ok := utils.RelationKey(row.FieldByIndex(field.Index)); ok && wanted[key] {
+			related = append(related, copyRow(row))
+		}
+	}
+	return related, nil
+}
+
+// LoadJoined returns copies of the stored models linked to the owners by the rows of
+// the relation's join table, with the owner key of each model
+func (l loader) LoadJoined(ctx context.Context, relation utils.Relation, column string, keys []interface{}) ([]reflect.Value, []string, error) {
+	t, field, err := l.targetTable(relation.Target, column)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	owners := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if k, ok := keyOf(key); ok {
+			owners[k] = true
+		}
+	}
+	byKey := make(map[string]reflect.Value, len(t.rows))
+	for _, row := range t.rows {
+		if key, _, ok := utils.RelationKey(row.FieldByIndex(field.Index)); ok {
+			byKey[key] = row
+		}
+	}
+
+	related := make([]reflect.Value, 0)
+	ownerKeys := make([]string, 0)
+	for _, join := range l.data.joins[relation.JoinTable] {
+		row, ok := byKey[join.target]
+		if !owners[join.owner] || !ok {
+			continue
+		}
+		related = append(related, copyRow(row))
+		ownerKeys = append(ownerKeys, join.owner)
+	}
+	return related, ownerKeys, nil
+}
+
+// targetTable returns the stored table of a type and the field mapped to the column
+func (l loader) targetTable(target reflect.Type, column string) (*table, utils.FieldInfo, error) {
+	name, err := l.mapper.TableName(target)
+	if err != nil {
+		return nil, utils.FieldInfo{}, err
+	}
+	field, ok := utils.FindField(l.mapper.Fields(target), column)
+	if !ok {
+		return nil, utils.FieldInfo{}, fmt.Errorf("column %q not found in %s", column, target)
+	}
+	return l.data.lookup(name), field, nil
+}
+
+// copyRow returns an addressable deep copy of a stored row, so neither the stored rows
+// nor the models of the callers share slices, maps or pointers
+func copyRow(row reflect.Value) reflect.Value {
+	copied := reflect.New(row.Type()).Elem()
+	copied.Set(deepCopy(row))
+	return copied
+}
+
+// deepCopy returns a copy of the value with its pointers, slices, maps and interfaces
+// copied recursively. Unexported struct fields, such as the location of time.Time, are
+// copied shallowly.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return copied
+	}
+	return v
+}
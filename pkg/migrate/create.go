@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// nameSanitizer matches the characters replaced in migration names
+var nameSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Write creates the next numbered pair of migration files in dir with the given
+// contents and returns their paths. The version follows the highest version found in
+// dir, keeping its zero padding (four digits by default).
+func Write(dir, name, up, down string) (string, string, error) {
+	name = strings.Trim(nameSanitizer.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("error creating migrations directory: %w", err)
+	}
+
+	version, width, err := nextVersion(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix := fmt.Sprintf("%0*d_%s", width, version, name)
+	upPath := filepath.Join(dir, prefix+".up.sql")
+	downPath := filepath.Join(dir, prefix+".down.sql")
+	if err := writeNew(upPath, up); err != nil {
+		return "", "", err
+	}
+	if err := writeNew(downPath, down); err != nil {
+		os.Remove(upPath)
+		return "", "", err
+	}
+	return upPath, downPath, nil
+}
+
+// nextVersion returns the version following the highest one in dir and the padding
+// width used by its file names
+func nextVersion(dir string) (uint64, int, error) {
+	migrations, err := Load(os.DirFS(dir), ".")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	width := 4
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading migrations directory: %w", err)
+	}
+	for _, entry := range entries {
+		if match := fileName.FindStringSubmatch(entry.Name()); match != nil && len(match[1]) > width {
+			width = len(match[1])
+		}
+	}
+
+	if len(migrations) == 0 {
+		return 1, width, nil
+	}
+	return migrations[len(migrations)-1].Version + 1, width, nil
+}
+
+// writeNew writes content to a file that must not exist yet
+func writeNew(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating migration file: %w", err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing migration file: %w", err)
+	}
+	return file.Close()
+}
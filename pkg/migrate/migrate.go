@@ -0,0 +1,315 @@
+// Package migrate applies versioned SQL migrations read from an fs.FS.
+//
+// Migrations are pairs of files named NNNN_name.up.sql and NNNN_name.down.sql, so
+// they can be embedded with go:embed. Applied versions are tracked in a table
+// (schema_migrations by default), every migration runs in its own transaction and
+// the whole run is guarded by a PostgreSQL advisory lock, so several replicas can
+// start concurrently and only one of them applies the pending migrations.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultTable is the table used to track applied migrations
+const DefaultTable = "schema_migrations"
+
+// ErrNoDownMigration is returned when reverting a migration without a .down.sql file
+var ErrNoDownMigration = errors.New("migration has no down file")
+
+// ErrTargetBelowCurrent is returned by UpTo when a migration above the target version
+// is already applied
+var ErrTargetBelowCurrent = errors.New("target version is below the current version")
+
+// fileName matches migration files such as 0001_create_users.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a versioned pair of up and down scripts
+type Migration struct {
+	// Version is the number prefix of the file names
+	Version uint64
+	// Name is the description between the version and the direction
+	Name string
+	// Up is the SQL applying the migration
+	Up string
+	// Down is the SQL reverting the migration; empty when there is no down file
+	Down string
+	// HasDown reports whether a down file exists
+	HasDown bool
+}
+
+// Status describes a migration and whether it was applied
+type Status struct {
+	// Version is the migration version
+	Version uint64
+	// Name is the migration name
+	Name string
+	// Applied reports whether the migration was applied
+	Applied bool
+	// AppliedAt is the time the migration was applied
+	AppliedAt time.Time
+	// Missing reports an applied version without files in the source
+	Missing bool
+}
+
+// Option configures a Migrator
+type Option func(*Migrator)
+
+// WithTable sets the table used to track applied migrations
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithLockID sets the key of the advisory lock guarding migration runs.
+// By default the key is derived from the table name.
+func WithLockID(id int64) Option {
+	return func(m *Migrator) {
+		m.lockID = id
+	}
+}
+
+// Migrator applies and reverts migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	table      string
+	lockID     int64
+}
+
+// New loads the migrations from dir inside fsys and returns a Migrator for db
+func New(db *sql.DB, fsys fs.FS, dir string, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{db: db, migrations: migrations, table: DefaultTable}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.lockID == 0 {
+		hash := fnv.New64a()
+		hash.Write([]byte("night-orm:" + m.table))
+		m.lockID = int64(hash.Sum64())
+	}
+	return m, nil
+}
+
+// Load reads the migrations from dir inside fsys, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations directory: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+			migration.HasDown = true
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrations returns the loaded migrations sorted by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(applied map[uint64]time.Time) ([]step, error) {
+		return pending(m.migrations, applied, ^uint64(0)), nil
+	})
+}
+
+// UpTo applies the pending migrations up to and including version. Unlike Goto it never
+// reverts: it fails with ErrTargetBelowCurrent when a later migration is applied.
+func (m *Migrator) UpTo(ctx context.Context, version uint64) error {
+	if !m.known(version) {
+		return fmt.Errorf("migration version %d not found", version)
+	}
+	return m.run(ctx, func(applied map[uint64]time.Time) ([]step, error) {
+		for current := range applied {
+			if current > version {
+				return nil, fmt.Errorf("%w: %d is applied", ErrTargetBelowCurrent, current)
+			}
+		}
+		return pending(m.migrations, applied, version), nil
+	})
+}
+
+// Down reverts the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	return m.run(ctx, func(applied map[uint64]time.Time) ([]step, error) {
+		steps, err := reverting(m.migrations, applied, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(steps) > n {
+			steps = steps[:n]
+		}
+		return steps, nil
+	})
+}
+
+// Goto migrates up or down until version is the last applied migration.
+// Version 0 reverts every migration.
+func (m *Migrator) Goto(ctx context.Context, version uint64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("migration version %d not found", version)
+	}
+	return m.run(ctx, func(applied map[uint64]time.Time) ([]step, error) {
+		steps, err := reverting(m.migrations, applied, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(steps, pending(m.migrations, applied, version)...), nil
+	})
+}
+
+// Status returns every known migration and the applied versions without files
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	applied, names, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	return status(m.migrations, applied, names), nil
+}
+
+// known reports whether a migration with the given version was loaded
+func (m *Migrator) known(version uint64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// step is a migration to apply (up) or revert (down)
+type step struct {
+	migration Migration
+	up        bool
+}
+
+// pending returns the unapplied migrations up to and including target, in ascending order
+func pending(migrations []Migration, applied map[uint64]time.Time, target uint64) []step {
+	steps := make([]step, 0)
+	for _, migration := range migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			steps = append(steps, step{migration: migration, up: true})
+		}
+	}
+	return steps
+}
+
+// reverting returns the applied migrations above target, in descending order
+func reverting(migrations []Migration, applied map[uint64]time.Time, target uint64) ([]step, error) {
+	steps := make([]step, 0)
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if !migration.HasDown {
+			return nil, fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+		}
+		steps = append(steps, step{migration: migration})
+	}
+	return steps, nil
+}
+
+// status merges the loaded migrations with the applied versions
+func status(migrations []Migration, applied map[uint64]time.Time, names map[uint64]string) []Status {
+	statuses := make([]Status, 0, len(migrations))
+	known := make(map[uint64]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	for version, appliedAt := range applied {
+		if !known[version] {
+			statuses = append(statuses, Status{
+				Version:   version,
+				Name:      names[version],
+				Applied:   true,
+				AppliedAt: appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
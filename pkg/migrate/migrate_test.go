@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id bigserial PRIMARY KEY);")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email text;")},
+		"migrations/0002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+		"migrations/0010_seed.up.sql":           {Data: []byte("INSERT INTO users DEFAULT VALUES;")},
+		"migrations/README.md":                  {Data: []byte("ignored")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS(), "migrations")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if len(migrations) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" || !migrations[0].HasDown {
+		t.Errorf("Unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[2].Version != 10 || migrations[2].HasDown {
+		t.Errorf("Unexpected last migration: %+v", migrations[2])
+	}
+
+	t.Run("MissingUp", func(t *testing.T) {
+		fsys := fstest.MapFS{"m/0001_a.down.sql": {Data: []byte("SELECT 1")}}
+		if _, err := Load(fsys, "m"); err == nil {
+			t.Errorf("Expected error for migration without up file, got nil")
+		}
+	})
+
+	t.Run("DuplicateVersion", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0001_a.up.sql": {Data: []byte("SELECT 1")},
+			"m/0001_b.up.sql": {Data: []byte("SELECT 2")},
+		}
+		if _, err := Load(fsys, "m"); err == nil {
+			t.Errorf("Expected error for duplicated version, got nil")
+		}
+	})
+}
+
+func versions(steps []step) []uint64 {
+	result := make([]uint64, len(steps))
+	for i, s := range steps {
+		result[i] = s.migration.Version
+	}
+	return result
+}
+
+func TestPlanning(t *testing.T) {
+	migrations, err := Load(testFS(), "migrations")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	applied := map[uint64]time.Time{1: time.Now()}
+
+	steps := pending(migrations, applied, ^uint64(0))
+	if got := versions(steps); len(got) != 2 || got[0] != 2 || got[1] != 10 {
+		t.Errorf("Expected pending versions [2 10], got %v", got)
+	}
+
+	steps = pending(migrations, applied, 2)
+	if got := versions(steps); len(got) != 1 || got[0] != 2 {
+		t.Errorf("Expected pending versions up to 2 to be [2], got %v", got)
+	}
+
+	applied[2] = time.Now()
+	steps, err = reverting(migrations, applied, 0)
+	if err != nil {
+		t.Fatalf("reverting returned error: %v", err)
+	}
+	if got := versions(steps); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Errorf("Expected reverting versions [2 1], got %v", got)
+	}
+
+	// Migrações sem arquivo down não podem ser revertidas
+	applied[10] = time.Now()
+	if _, err := reverting(migrations, applied, 2); !errors.Is(err, ErrNoDownMigration) {
+		t.Errorf("Expected ErrNoDownMigration, got %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	migrations, err := Load(testFS(), "migrations")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	applied := map[uint64]time.Time{1: time.Now(), 7: time.Now()}
+	statuses := status(migrations, applied, map[uint64]string{7: "removed"})
+
+	if len(statuses) != 4 {
+		t.Fatalf("Expected 4 statuses, got %d", len(statuses))
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Unexpected applied flags: %+v", statuses)
+	}
+	if statuses[2].Version != 7 || !statuses[2].Missing || statuses[2].Name != "removed" {
+		t.Errorf("Expected version 7 to be reported as missing, got %+v", statuses[2])
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := Write(dir, "Create Users", "CREATE TABLE users ();\n", "DROP TABLE users;\n")
+	if err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if filepath.Base(up) != "0001_create_users.up.sql" || filepath.Base(down) != "0001_create_users.down.sql" {
+		t.Errorf("Unexpected file names %s and %s", up, down)
+	}
+
+	if _, _, err := Write(dir, "add email", "ALTER TABLE users ADD COLUMN email text;\n", ""); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	migrations, err := Load(os.DirFS(dir), ".")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[1].Version != 2 || migrations[1].Name != "add_email" {
+		t.Errorf("Expected second migration 2_add_email, got %+v", migrations)
+	}
+
+	if _, _, err := Write(dir, "!!", "", ""); err == nil {
+		t.Error("Expected error for empty migration name")
+	}
+}
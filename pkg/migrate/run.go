@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// querier is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// run acquires the advisory lock on a dedicated connection, reads the applied versions
+// and executes the steps returned by plan, one transaction per migration
+func (m *Migrator) run(ctx context.Context, plan func(applied map[uint64]time.Time) ([]step, error)) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return fmt.Errorf("error acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockID)
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	// The applied versions are read after the lock is held, so a replica waiting for
+	// the lock sees the migrations applied by the one that held it
+	applied, _, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	steps, err := plan(applied)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range steps {
+		if err := m.apply(ctx, conn, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply runs a single migration step and records it in the same transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, s step) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	direction, script := "up", s.migration.Up
+	if !s.up {
+		direction, script = "down", s.migration.Down
+	}
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("error running migration %d_%s (%s): %w", s.migration.Version, s.migration.Name, direction, err)
+		}
+	}
+
+	if s.up {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name) VALUES ($1, $2)", m.table), s.migration.Version, s.migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = $1", m.table), s.migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("error recording migration %d_%s: %w", s.migration.Version, s.migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing migration %d_%s: %w", s.migration.Version, s.migration.Name, err)
+	}
+	return nil
+}
+
+// ensureTable creates the table tracking the applied migrations
+func (m *Migrator) ensureTable(ctx context.Context, q querier) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    version bigint PRIMARY KEY,
+    name text NOT NULL,
+    applied_at timestamp with time zone NOT NULL DEFAULT now()
+)`, m.table))
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", m.table, err)
+	}
+	return nil
+}
+
+// applied returns the applied versions with their application time and names
+func (m *Migrator) applied(ctx context.Context, q querier) (map[uint64]time.Time, map[uint64]string, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT version, name, applied_at FROM %s", m.table))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]time.Time)
+	names := make(map[uint64]string)
+	for rows.Next() {
+		var version uint64
+		var name string
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &name, &appliedAt); err != nil {
+			return nil, nil, fmt.Errorf("error scanning applied migration: %w", err)
+		}
+		applied[version] = appliedAt
+		names[version] = name
+	}
+	return applied, names, rows.Err()
+}
@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+// lockID is the advisory lock key of the migrators under test
+const lockID = 42
+
+// newRecorded returns a Migrator of testFS running against a recorder
+func newRecorded(t *testing.T) (*Migrator, *sqltest.Recorder) {
+	t.Helper()
+	rec := sqltest.New()
+	t.Cleanup(func() { rec.Close() })
+	m, err := New(rec.DB(), testFS(), "migrations", WithLockID(lockID))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return m, rec
+}
+
+// expectLocked expects the lock, the tracking table and the read of the applied versions
+func expectLocked(rec *sqltest.Recorder, applied ...uint64) {
+	rec.ExpectExec(sqltest.Exact("SELECT pg_advisory_lock($1)")).WithArgs(lockID)
+	rec.ExpectExec(sqltest.Regexp(`^CREATE TABLE IF NOT EXISTS schema_migrations `))
+	rows := sqltest.NewRows("version", "name", "applied_at")
+	for _, version := range applied {
+		rows.AddRow(int64(version), "applied", time.Now())
+	}
+	rec.ExpectQuery(sqltest.Exact("SELECT version, name, applied_at FROM schema_migrations")).WillReturnRows(rows)
+}
+
+// expectUp expects a migration applied and recorded in its own transaction
+func expectUp(rec *sqltest.Recorder, version uint64, name, script string) {
+	rec.ExpectBegin()
+	rec.ExpectExec(sqltest.Exact(script))
+	rec.ExpectExec(sqltest.Exact("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).WithArgs(int64(version), name)
+	rec.ExpectCommit()
+}
+
+// expectDown expects a migration reverted and unrecorded in its own transaction
+func expectDown(rec *sqltest.Recorder, version uint64, script string) {
+	rec.ExpectBegin()
+	rec.ExpectExec(sqltest.Exact(script))
+	rec.ExpectExec(sqltest.Exact("DELETE FROM schema_migrations WHERE version = $1")).WithArgs(int64(version))
+	rec.ExpectCommit()
+}
+
+// expectUnlock expects the release of the advisory lock
+func expectUnlock(rec *sqltest.Recorder) {
+	rec.ExpectExec(sqltest.Exact("SELECT pg_advisory_unlock($1)")).WithArgs(lockID)
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Up", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// As versões aplicadas são lidas com o lock adquirido e cada migração tem sua transação
+		expectLocked(rec, 1)
+		expectUp(rec, 2, "add_email", "ALTER TABLE users ADD COLUMN email text;")
+		expectUp(rec, 10, "seed", "INSERT INTO users DEFAULT VALUES;")
+		expectUnlock(rec)
+
+		if err := m.Up(ctx); err != nil {
+			t.Fatalf("Up returned error: %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// Apenas as n últimas migrações são revertidas, da mais recente para a mais antiga
+		expectLocked(rec, 1, 2)
+		expectDown(rec, 2, "ALTER TABLE users DROP COLUMN email;")
+		expectUnlock(rec)
+
+		if err := m.Down(ctx, 1); err != nil {
+			t.Fatalf("Down returned error: %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Goto", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// As reversões acima do alvo rodam antes das migrações pendentes até ele
+		expectLocked(rec, 2)
+		expectDown(rec, 2, "ALTER TABLE users DROP COLUMN email;")
+		expectUp(rec, 1, "create_users", "CREATE TABLE users (id bigserial PRIMARY KEY);")
+		expectUnlock(rec)
+
+		if err := m.Goto(ctx, 1); err != nil {
+			t.Fatalf("Goto returned error: %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("UpTo", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// Apenas as migrações pendentes até o alvo são aplicadas
+		expectLocked(rec, 1)
+		expectUp(rec, 2, "add_email", "ALTER TABLE users ADD COLUMN email text;")
+		expectUnlock(rec)
+
+		if err := m.UpTo(ctx, 2); err != nil {
+			t.Fatalf("UpTo returned error: %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("UpToBelowCurrent", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// Um alvo abaixo da versão atual não reverte nada
+		expectLocked(rec, 1, 2)
+		expectUnlock(rec)
+
+		if err := m.UpTo(ctx, 1); !errors.Is(err, ErrTargetBelowCurrent) {
+			t.Fatalf("Expected ErrTargetBelowCurrent, got %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// A migração que falha é desfeita, as seguintes não rodam e o lock é liberado
+		failure := errors.New("syntax error")
+		expectLocked(rec)
+		expectUp(rec, 1, "create_users", "CREATE TABLE users (id bigserial PRIMARY KEY);")
+		rec.ExpectBegin()
+		rec.ExpectExec(sqltest.Exact("ALTER TABLE users ADD COLUMN email text;")).WillReturnError(failure)
+		rec.ExpectRollback()
+		expectUnlock(rec)
+
+		err := m.Up(ctx)
+		if !errors.Is(err, failure) {
+			t.Fatalf("Expected the migration error, got %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("LockError", func(t *testing.T) {
+		m, rec := newRecorded(t)
+
+		// Sem o lock nenhuma migração é aplicada
+		rec.ExpectExec(sqltest.Exact("SELECT pg_advisory_lock($1)")).WithArgs(lockID).WillReturnError(errors.New("canceled"))
+
+		if err := m.Up(ctx); err == nil {
+			t.Fatal("Expected error acquiring the lock")
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
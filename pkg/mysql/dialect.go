@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// MySQLDialect is the MySQL and MariaDB dialect: ? placeholders, backtick quoting,
+// ON DUPLICATE KEY UPDATE and generated keys read with sql.Result.LastInsertId
+type MySQLDialect struct{}
+
+// Name returns "mysql"
+func (MySQLDialect) Name() string {
+	return "mysql"
+}
+
+// Placeholder returns "?"
+func (MySQLDialect) Placeholder(index int) string {
+	return "?"
+}
+
+// QuoteIdentifier wraps the identifier in backticks, doubling inner backticks. The
+// identifier is truncated at the first NUL character.
+func (MySQLDialect) QuoteIdentifier(name string) string {
+	if end := strings.IndexRune(name, 0); end > -1 {
+		name = name[:end]
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Returning returns false: MySQL has no RETURNING clause
+func (MySQLDialect) Returning() bool {
+	return false
+}
+
+// Upsert returns "ON DUPLICATE KEY UPDATE column = VALUES(column)". MySQL resolves the
+// conflict with every unique index, so the conflict columns only matter when there is
+// nothing to update: the first one is assigned to itself, ignoring the duplicate row.
+func (d MySQLDialect) Upsert(conflict, update []string) string {
+	if len(update) == 0 {
+		if len(conflict) == 0 {
+			return ""
+		}
+		quoted := d.QuoteIdentifier(conflict[0])
+		return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s = %s", quoted, quoted)
+	}
+
+	assignments := make([]string, len(update))
+	for i, column := range update {
+		quoted := d.QuoteIdentifier(column)
+		assignments[i] = fmt.Sprintf("%s = VALUES(%s)", quoted, quoted)
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+// Pagination returns "LIMIT n OFFSET m", writing the largest BIGINT UNSIGNED as the
+// limit when only an offset is given
+func (MySQLDialect) Pagination(limit, offset int) string {
+	return utils.LimitOffset(limit, offset, "18446744073709551615")
+}
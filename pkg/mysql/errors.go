@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// errorCodes maps MySQL server error numbers to the ORM typed errors
+var errorCodes = map[uint16]error{
+	1062: core.ErrDuplicate,   // ER_DUP_ENTRY
+	1586: core.ErrDuplicate,   // ER_DUP_ENTRY_WITH_KEY_NAME
+	1451: core.ErrForeignKey,  // ER_ROW_IS_REFERENCED_2
+	1452: core.ErrForeignKey,  // ER_NO_REFERENCED_ROW_2
+	1216: core.ErrForeignKey,  // ER_NO_REFERENCED_ROW
+	1217: core.ErrForeignKey,  // ER_ROW_IS_REFERENCED
+	1048: core.ErrNotNull,     // ER_BAD_NULL_ERROR
+	1364: core.ErrNotNull,     // ER_NO_DEFAULT_FOR_FIELD
+	1213: core.ErrDeadlock,    // ER_LOCK_DEADLOCK
+	1205: core.ErrLockTimeout, // ER_LOCK_WAIT_TIMEOUT
+}
+
+// translateError wraps driver errors with the matching ORM typed error, keeping the
+// original error in the chain
+func translateError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if typed, ok := errorCodes[mysqlErr.Number]; ok {
+			return fmt.Errorf("%w: %w", typed, err)
+		}
+	}
+	return err
+}
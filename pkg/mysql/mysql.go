@@ -0,0 +1,239 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/internal/sqlbase"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLORM is the MySQL and MariaDB ORM implementation
+type MySQLORM struct {
+	db         *sql.DB
+	mapper     *utils.Mapper
+	backend    *sqlbase.Backend
+	naming     utils.NamingStrategy
+	schema     string
+	driverName string
+}
+
+// NewMySQLORM creates a new instance of the MySQL ORM
+func NewMySQLORM(opts ...Option) *MySQLORM {
+	m := &MySQLORM{driverName: "mysql"}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.mapper = utils.NewMapper(m.naming).WithDialect(MySQLDialect{})
+	if m.schema != "" {
+		m.mapper = m.mapper.WithSchema(m.schema)
+	}
+	m.backend = &sqlbase.Backend{Mapper: m.mapper, TranslateError: translateError, DefaultValues: "() VALUES ()"}
+	return m
+}
+
+// Connect creates a MySQL ORM with the given options and connects with a go-sql-driver
+// DSN, such as "user:password@tcp(localhost:3306)/app"
+func Connect(ctx context.Context, dsn string, opts ...Option) (*MySQLORM, error) {
+	orm := NewMySQLORM(opts...)
+	if err := orm.Connect(ctx, dsn); err != nil {
+		return nil, err
+	}
+	return orm, nil
+}
+
+// Mapper returns the mapper resolving table names and primary keys of the models
+func (m *MySQLORM) Mapper() *utils.Mapper {
+	return m.mapper
+}
+
+// Dialect returns the MySQL dialect used to build the queries
+func (m *MySQLORM) Dialect() utils.Dialect {
+	return m.mapper.Dialect()
+}
+
+// Connect establishes a connection to the MySQL database with a go-sql-driver DSN, such
+// as "user:password@tcp(localhost:3306)/app". The DSN is adjusted so DATETIME columns are
+// scanned into time.Time (parseTime) and UPDATE reports the matched rather than the
+// changed rows (clientFoundRows), which Update relies on to detect missing records.
+func (m *MySQLORM) Connect(ctx context.Context, dsn string) error {
+	dsn, err := dataSourceName(dsn)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(m.driverName, dsn)
+	if err != nil {
+		return fmt.Errorf("error connecting to MySQL: %w", err)
+	}
+
+	// Test the connection
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("error pinging MySQL connection: %w", err)
+	}
+
+	m.db = db
+	return nil
+}
+
+// dataSourceName enables parseTime and clientFoundRows on a go-sql-driver DSN
+func dataSourceName(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("error parsing MySQL DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.ClientFoundRows = true
+	return cfg.FormatDSN(), nil
+}
+
+// Close closes the database connection
+func (m *MySQLORM) Close() error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.db.Close()
+}
+
+// DB returns the underlying database connection
+func (m *MySQLORM) DB() *sql.DB {
+	return m.db
+}
+
+// Create inserts a new record into the database, setting the generated primary key
+func (m *MySQLORM) Create(ctx context.Context, model core.Model) error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.backend.Create(ctx, m.db, model)
+}
+
+// FindByID retrieves a record by ID
+func (m *MySQLORM) FindByID(ctx context.Context, model core.Model, id interface{}) error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.backend.FindByID(ctx, m.db, model, id)
+}
+
+// FindAll retrieves all records of a model
+func (m *MySQLORM) FindAll(ctx context.Context, model core.Model, dest interface{}) error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.backend.FindAll(ctx, m.db, model, dest)
+}
+
+// Update updates an existing record
+func (m *MySQLORM) Update(ctx context.Context, model core.Model) error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.backend.Update(ctx, m.db, model)
+}
+
+// Delete removes a record from the database
+func (m *MySQLORM) Delete(ctx context.Context, model core.Model) error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.backend.Delete(ctx, m.db, model)
+}
+
+// Query executes a custom SQL query
+func (m *MySQLORM) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if m.db == nil {
+		return nil, sqlbase.ErrNotConnected
+	}
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+// Exec executes a custom SQL command
+func (m *MySQLORM) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if m.db == nil {
+		return nil, sqlbase.ErrNotConnected
+	}
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+// Preload loads the given relations into an already loaded model or slice of models
+func (m *MySQLORM) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	if m.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return m.backend.Preload(ctx, m.db, dest, relations...)
+}
+
+// Transaction starts a new transaction
+func (m *MySQLORM) Transaction(ctx context.Context) (core.Transaction, error) {
+	if m.db == nil {
+		return nil, sqlbase.ErrNotConnected
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	return &MySQLTransaction{tx: tx, orm: m}, nil
+}
+
+// MySQLTransaction is the MySQL transaction implementation
+type MySQLTransaction struct {
+	tx  *sql.Tx
+	orm *MySQLORM
+}
+
+// Commit commits the transaction
+func (t *MySQLTransaction) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback rolls back the transaction
+func (t *MySQLTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Create inserts a new record within the transaction, setting the generated primary key
+func (t *MySQLTransaction) Create(ctx context.Context, model core.Model) error {
+	return t.orm.backend.Create(ctx, t.tx, model)
+}
+
+// Update updates a record within the transaction
+func (t *MySQLTransaction) Update(ctx context.Context, model core.Model) error {
+	return t.orm.backend.Update(ctx, t.tx, model)
+}
+
+// Delete removes a record within the transaction
+func (t *MySQLTransaction) Delete(ctx context.Context, model core.Model) error {
+	return t.orm.backend.Delete(ctx, t.tx, model)
+}
+
+// Query executes a custom SQL query within the transaction
+func (t *MySQLTransaction) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// Exec executes a custom SQL command within the transaction
+func (t *MySQLTransaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+// Preload loads the given relations within the transaction
+func (t *MySQLTransaction) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	return t.orm.backend.Preload(ctx, t.tx, dest, relations...)
+}
+
+// Association returns the operations for the given many_to_many relation of the model
+func (t *MySQLTransaction) Association(model core.Model, relation string) (core.Association, error) {
+	association, err := t.orm.backend.Association(t.tx, model, relation)
+	if err != nil {
+		return nil, err
+	}
+	return association, nil
+}
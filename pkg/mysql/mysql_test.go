@@ -0,0 +1,193 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// newRecordedORM retorna um ORM ligado ao pool de um gravador exclusivo do teste, que
+// verifica ao final se todas as instruções esperadas foram executadas
+func newRecordedORM(t *testing.T) (*MySQLORM, *sqltest.Recorder) {
+	t.Helper()
+	rec := sqltest.New()
+	t.Cleanup(func() {
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		rec.Close()
+	})
+	orm := NewMySQLORM()
+	orm.db = rec.DB()
+	return orm, rec
+}
+
+type Account struct {
+	ID        int64     `db:"id,primary"`
+	Email     string    `db:"email"`
+	Active    bool      `db:"active"`
+	CreatedAt time.Time `db:"created_at"`
+	Groups    []Group   `rel:"many_to_many,join=account_groups,fk=account_id,target_fk=group_id"`
+}
+
+type Group struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+func TestMySQLORM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Connect", func(t *testing.T) {
+		dsn, err := dataSourceName("app:secret@tcp(localhost:3306)/app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, option := range []string{"parseTime=true", "clientFoundRows=true"} {
+			if !strings.Contains(dsn, option) {
+				t.Errorf("Expected DSN to contain %s, got %s", option, dsn)
+			}
+		}
+		if _, err := dataSourceName("app:secret@localhost"); err == nil {
+			t.Error("Expected an invalid DSN to fail")
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		orm, rec := newRecordedORM(t)
+		rec.ExpectExec(sqltest.Exact("INSERT INTO `accounts` (`email`, `active`, `created_at`) VALUES (?, ?, ?)")).
+			WithArgs("ana@example.com", true, sqltest.AnyArg()).
+			WillReturnResult(42, 1)
+
+		account := &Account{Email: "ana@example.com", Active: true}
+		if err := orm.Create(ctx, account); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if account.ID != 42 {
+			t.Errorf("Expected ID from LastInsertId, got %d", account.ID)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		orm, rec := newRecordedORM(t)
+		rec.ExpectExec(sqltest.Regexp("^INSERT INTO `accounts`")).
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+		err := orm.Create(ctx, &Account{Email: "ana@example.com"})
+		if !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) {
+			t.Errorf("Expected the driver error to stay in the chain, got %v", err)
+		}
+	})
+
+	t.Run("LockWaitTimeout", func(t *testing.T) {
+		orm, rec := newRecordedORM(t)
+		// O tempo limite de lock não é um deadlock: apenas a instrução foi desfeita
+		rec.ExpectExec(sqltest.Regexp("^INSERT INTO `accounts`")).
+			WillReturnError(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"})
+
+		err := orm.Create(ctx, &Account{Email: "ana@example.com"})
+		if !errors.Is(err, core.ErrLockTimeout) || errors.Is(err, core.ErrDeadlock) {
+			t.Errorf("Expected ErrLockTimeout, got %v", err)
+		}
+	})
+
+	t.Run("FindByID", func(t *testing.T) {
+		orm, rec := newRecordedORM(t)
+		createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		rec.ExpectQuery(sqltest.Exact("SELECT `id`, `email`, `active`, `created_at` FROM `accounts` WHERE `id` = ?")).
+			WithArgs(7).
+			WillReturnRows(sqltest.NewRows("id", "email", "active", "created_at").
+				AddRow(7, []byte("bia@example.com"), 1, createdAt))
+		rec.ExpectQuery(sqltest.Regexp("^SELECT .* FROM `accounts`")).WithArgs(8)
+
+		account := &Account{}
+		if err := orm.FindByID(ctx, account, 7); err != nil {
+			t.Fatalf("FindByID returned error: %v", err)
+		}
+		if account.ID != 7 || account.Email != "bia@example.com" || !account.Active || !account.CreatedAt.Equal(createdAt) {
+			t.Errorf("Unexpected account: %+v", account)
+		}
+		if err := orm.FindByID(ctx, &Account{}, 8); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("UpdateDelete", func(t *testing.T) {
+		orm, rec := newRecordedORM(t)
+		rec.ExpectExec(sqltest.Regexp("^UPDATE `accounts`")).WillReturnResult(0, 0)
+		rec.ExpectExec(sqltest.Exact("DELETE FROM `accounts` WHERE `id` = ?")).
+			WithArgs(9).
+			WillReturnError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete a parent row"})
+
+		if err := orm.Update(ctx, &Account{ID: 9, Email: "x"}); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := orm.Delete(ctx, &Account{ID: 9}); !errors.Is(err, core.ErrForeignKey) {
+			t.Errorf("Expected ErrForeignKey, got %v", err)
+		}
+	})
+}
+
+func TestMySQLTransaction(t *testing.T) {
+	ctx := context.Background()
+	orm, rec := newRecordedORM(t)
+
+	rec.ExpectBegin()
+	rec.ExpectExec(sqltest.Exact("INSERT INTO `groups` (`name`) VALUES (?)")).
+		WithArgs("admins").
+		WillReturnResult(3, 1)
+	// Cada linha da tabela de junção recebe a chave do dono
+	rec.ExpectExec(sqltest.Exact("INSERT INTO `account_groups` (`account_id`, `group_id`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `account_id` = `account_id`")).
+		WithArgs(1, 3, 1, 4)
+	rec.ExpectCommit()
+
+	tx, err := orm.Transaction(ctx)
+	if err != nil {
+		t.Fatalf("Transaction returned error: %v", err)
+	}
+	group := &Group{Name: "admins"}
+	if err := tx.Create(ctx, group); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if group.ID != 3 {
+		t.Errorf("Expected ID 3, got %d", group.ID)
+	}
+
+	association, err := tx.Association(&Account{ID: 1}, "Groups")
+	if err != nil {
+		t.Fatalf("Association returned error: %v", err)
+	}
+	if err := association.Append(ctx, group, &Group{ID: 4}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMySQLDialect(t *testing.T) {
+	d := MySQLDialect{}
+	if got := d.QuoteIdentifier("we`ird"); got != "`we``ird`" {
+		t.Errorf("Expected doubled backticks, got %s", got)
+	}
+	if got := d.Upsert([]string{"id"}, []string{"name", "email"}); got != "ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `email` = VALUES(`email`)" {
+		t.Errorf("Unexpected upsert clause: %s", got)
+	}
+	if got := d.Upsert([]string{"id"}, nil); got != "ON DUPLICATE KEY UPDATE `id` = `id`" {
+		t.Errorf("Unexpected upsert clause: %s", got)
+	}
+	if got := d.Pagination(0, 10); got != "LIMIT 18446744073709551615 OFFSET 10" {
+		t.Errorf("Unexpected pagination: %s", got)
+	}
+}
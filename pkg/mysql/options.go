@@ -0,0 +1,30 @@
+package mysql
+
+import "github.com/rodolfocoding/night-orm/pkg/utils"
+
+// Option configures a MySQLORM
+type Option func(*MySQLORM)
+
+// WithNamingStrategy sets the strategy deriving the table, column and join table
+// names that models do not declare explicitly
+func WithNamingStrategy(naming utils.NamingStrategy) Option {
+	return func(m *MySQLORM) {
+		m.naming = naming
+	}
+}
+
+// WithDefaultSchema sets the database of the models that declare none with a qualified
+// table name, a Schema method or a schema tag. MySQL calls schemas databases.
+func WithDefaultSchema(schema string) Option {
+	return func(m *MySQLORM) {
+		m.schema = schema
+	}
+}
+
+// WithDriverName sets the database/sql driver used by Connect. The default is "mysql",
+// registered by github.com/go-sql-driver/mysql, but an instrumented wrapper may be used.
+func WithDriverName(name string) Option {
+	return func(m *MySQLORM) {
+		m.driverName = name
+	}
+}
@@ -0,0 +1,308 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// PostgresAssociation manages the join table rows of a many_to_many relation
+type PostgresAssociation struct {
+	run          runner
+	mapper       *utils.Mapper
+	owner        reflect.Value
+	ownerKey     interface{}
+	relation     utils.Relation
+	targetColumn string
+}
+
+// Association returns the operations for the given many_to_many relation of the model
+func (t *PostgresTransaction) Association(model core.Model, relation string) (core.Association, error) {
+	val := reflect.ValueOf(model)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("model must be a non-nil pointer to a struct")
+	}
+	val = val.Elem()
+
+	rel, err := utils.GetRelation(val.Type(), relation)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Kind != utils.ManyToMany {
+		return nil, fmt.Errorf("relation %s is not many_to_many", relation)
+	}
+
+	ownerColumn, targetColumn, err := t.mapper.KeyColumns(rel, val.Type())
+	if err != nil {
+		return nil, fmt.Errorf("error resolving relation keys: %w", err)
+	}
+	rel.JoinTable = t.mapper.JoinTable(rel)
+	ownerField, ok := utils.FindField(t.mapper.Fields(val.Type()), ownerColumn)
+	if !ok {
+		return nil, fmt.Errorf("column %s not found in %s", ownerColumn, val.Type())
+	}
+	ownerKey := val.FieldByIndex(ownerField.Index)
+	if ownerKey.IsZero() {
+		return nil, errors.New("model must be persisted before managing its associations")
+	}
+
+	return &PostgresAssociation{
+		run:          t.run,
+		mapper:       t.mapper,
+		owner:        val,
+		ownerKey:     ownerKey.Interface(),
+		relation:     rel,
+		targetColumn: targetColumn,
+	}, nil
+}
+
+// Append associates the given models, ignoring associations that already exist. The
+// join rows are inserted with ON CONFLICT DO NOTHING, which only skips the existing
+// rows when the join table has a unique constraint or primary key on (fk, target_fk).
+func (a *PostgresAssociation) Append(ctx context.Context, related ...interface{}) error {
+	return a.run.instrument(ctx, a.operation("association_append"), func(ctx context.Context) error {
+		return a.appendRelated(ctx, related...)
+	})
+}
+
+// appendRelated runs Append within its span
+func (a *PostgresAssociation) appendRelated(ctx context.Context, related ...interface{}) error {
+	items, keys, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	qb := a.mapper.NewQueryBuilder()
+	ownerParam := qb.AddParam(a.ownerKey)
+	a.writeInsert(qb, ownerParam, a.addParams(qb, keys))
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error appending associations: %w", translateError(err))
+	}
+
+	// Models already held by the relation field are not added again
+	current := a.fieldItems(a.owner.FieldByIndex(a.relation.Index))
+	present := make(map[string]bool, len(current))
+	for _, item := range current {
+		if key, ok := a.targetKey(item); ok {
+			present[fmt.Sprint(key)] = true
+		}
+	}
+	for _, item := range items {
+		if key, _ := a.targetKey(item); !present[fmt.Sprint(key)] {
+			current = append(current, item)
+		}
+	}
+	a.setField(current)
+	return nil
+}
+
+// Remove removes the associations with the given models
+func (a *PostgresAssociation) Remove(ctx context.Context, related ...interface{}) error {
+	return a.run.instrument(ctx, a.operation("association_remove"), func(ctx context.Context) error {
+		return a.remove(ctx, related...)
+	})
+}
+
+// remove runs Remove within its span
+func (a *PostgresAssociation) remove(ctx context.Context, related ...interface{}) error {
+	_, keys, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	qb := a.mapper.NewQueryBuilder()
+	qb.WriteDelete(a.relation.JoinTable).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(a.relation.ForeignKey), qb.AddParam(a.ownerKey))).
+		WriteAnd(qb.In(a.relation.JoinForeignKey, keys))
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error removing associations: %w", translateError(err))
+	}
+
+	removed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		removed[fmt.Sprint(key)] = true
+	}
+	remaining := make([]reflect.Value, 0)
+	for _, item := range a.fieldItems(a.owner.FieldByIndex(a.relation.Index)) {
+		if key, ok := a.targetKey(item); !ok || !removed[fmt.Sprint(key)] {
+			remaining = append(remaining, item)
+		}
+	}
+	a.setField(remaining)
+	return nil
+}
+
+// Replace replaces all associations with the given models
+func (a *PostgresAssociation) Replace(ctx context.Context, related ...interface{}) error {
+	return a.run.instrument(ctx, a.operation("association_replace"), func(ctx context.Context) error {
+		return a.replace(ctx, related...)
+	})
+}
+
+// replace runs Replace within its span
+func (a *PostgresAssociation) replace(ctx context.Context, related ...interface{}) error {
+	items, keys, err := a.targets(related)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return a.Clear(ctx)
+	}
+
+	// Delete the stale rows and insert the new ones in a single statement
+	qb := a.mapper.NewQueryBuilder()
+	ownerParam := qb.AddParam(a.ownerKey)
+	placeholders := a.addParams(qb, keys)
+	qb.Write(fmt.Sprintf("WITH removed AS (DELETE FROM %s WHERE %s = %s AND %s NOT IN (%s)) ",
+		qb.Quote(a.relation.JoinTable), qb.Quote(a.relation.ForeignKey), ownerParam,
+		qb.Quote(a.relation.JoinForeignKey), strings.Join(placeholders, ", ")))
+	a.writeInsert(qb, ownerParam, placeholders)
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error replacing associations: %w", translateError(err))
+	}
+
+	a.setField(items)
+	return nil
+}
+
+// Clear removes all associations of the model
+func (a *PostgresAssociation) Clear(ctx context.Context) error {
+	return a.run.instrument(ctx, a.operation("association_clear"), func(ctx context.Context) error {
+		return a.clear(ctx)
+	})
+}
+
+// clear runs Clear within its span
+func (a *PostgresAssociation) clear(ctx context.Context) error {
+	qb := a.mapper.NewQueryBuilder()
+	qb.WriteDelete(a.relation.JoinTable).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(a.relation.ForeignKey), qb.AddParam(a.ownerKey)))
+	if err := a.exec(ctx, qb); err != nil {
+		return fmt.Errorf("error clearing associations: %w", translateError(err))
+	}
+
+	a.setField(nil)
+	return nil
+}
+
+// operation describes the named operation on the join table
+func (a *PostgresAssociation) operation(name string) core.Operation {
+	return core.Operation{Name: name, Table: a.relation.JoinTable}
+}
+
+// addParams adds the keys as parameters and returns their placeholders
+func (a *PostgresAssociation) addParams(qb *utils.QueryBuilder, keys []interface{}) []string {
+	placeholders := make([]string, len(keys))
+	for i, key := range keys {
+		placeholders[i] = qb.AddParam(key)
+	}
+	return placeholders
+}
+
+// writeInsert writes a batched insert of one join row per placeholder, ignoring existing
+// rows; ON CONFLICT DO NOTHING relies on a unique constraint on (fk, target_fk)
+func (a *PostgresAssociation) writeInsert(qb *utils.QueryBuilder, ownerParam string, placeholders []string) {
+	qb.Write(fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES ",
+		qb.Quote(a.relation.JoinTable), qb.Quote(a.relation.ForeignKey), qb.Quote(a.relation.JoinForeignKey)))
+	for i, placeholder := range placeholders {
+		if i > 0 {
+			qb.Write(", ")
+		}
+		qb.Write(fmt.Sprintf("(%s, %s)", ownerParam, placeholder))
+	}
+	qb.Write(" ON CONFLICT DO NOTHING")
+}
+
+// exec runs the built statement within the transaction
+func (a *PostgresAssociation) exec(ctx context.Context, qb *utils.QueryBuilder) error {
+	query, args := qb.Build()
+	_, err := a.run.ExecContext(ctx, query, args...)
+	return err
+}
+
+// targets validates the related models and returns the first model of each key, with
+// the distinct keys
+func (a *PostgresAssociation) targets(related []interface{}) ([]reflect.Value, []interface{}, error) {
+	items := make([]reflect.Value, 0, len(related))
+	keys := make([]interface{}, 0, len(related))
+	seen := make(map[string]bool, len(related))
+	for _, model := range related {
+		val := reflect.ValueOf(model)
+		if val.Kind() == reflect.Ptr && !val.IsNil() {
+			val = val.Elem()
+		}
+		if !val.IsValid() || val.Type() != a.relation.Target {
+			return nil, nil, fmt.Errorf("expected %s, got %T", a.relation.Target, model)
+		}
+		if !val.CanAddr() {
+			copied := reflect.New(val.Type()).Elem()
+			copied.Set(val)
+			val = copied
+		}
+
+		key, ok := a.targetKey(val)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s must be persisted before being associated", a.relation.Target)
+		}
+		if !seen[fmt.Sprint(key)] {
+			seen[fmt.Sprint(key)] = true
+			items = append(items, val)
+			keys = append(keys, key)
+		}
+	}
+	return items, keys, nil
+}
+
+// targetKey returns the key of a related model referenced by the join table
+func (a *PostgresAssociation) targetKey(item reflect.Value) (interface{}, bool) {
+	field, ok := utils.FindField(a.mapper.Fields(a.relation.Target), a.targetColumn)
+	if !ok {
+		return nil, false
+	}
+	value := item.FieldByIndex(field.Index)
+	if value.IsZero() {
+		return nil, false
+	}
+	return value.Interface(), true
+}
+
+// fieldItems returns the models currently held by the relation field
+func (a *PostgresAssociation) fieldItems(field reflect.Value) []reflect.Value {
+	items := make([]reflect.Value, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		item := field.Index(i)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// setField replaces the relation field with the given models
+func (a *PostgresAssociation) setField(items []reflect.Value) {
+	field := a.owner.FieldByIndex(a.relation.Index)
+	slice := reflect.MakeSlice(a.relation.Type, 0, len(items))
+	for _, item := range items {
+		if a.relation.Type.Elem().Kind() == reflect.Ptr {
+			slice = reflect.Append(slice, item.Addr())
+		} else {
+			slice = reflect.Append(slice, item)
+		}
+	}
+	field.Set(slice)
+}
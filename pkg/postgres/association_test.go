@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+type User struct {
+	ID    int64   `db:"id,primary"`
+	Roles []*Role `rel:"many_to_many,join=user_roles,fk=user_id,target_fk=role_id"`
+}
+
+type Role struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+// roleIDs retorna as chaves dos papéis do usuário
+func roleIDs(user *User) []int64 {
+	ids := make([]int64, len(user.Roles))
+	for i, role := range user.Roles {
+		ids[i] = role.ID
+	}
+	return ids
+}
+
+func TestAssociation(t *testing.T) {
+	ctx := context.Background()
+	admin, editor, viewer := &Role{ID: 1, Name: "admin"}, &Role{ID: 2, Name: "editor"}, &Role{ID: 3, Name: "viewer"}
+
+	// roles inicia uma transação gravada e retorna a associação Roles do usuário
+	roles := func(t *testing.T, user *User) (*sqltest.Recorder, core.Transaction, core.Association) {
+		t.Helper()
+		rec := sqltest.New()
+		t.Cleanup(func() { rec.Close() })
+		rec.ExpectBegin()
+		tx, err := NewPostgresORMFromDB(rec.DB()).Transaction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		association, err := tx.Association(user, "Roles")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec, tx, association
+	}
+
+	// finish desfaz a transação e verifica que as instruções esperadas foram executadas
+	finish := func(t *testing.T, rec *sqltest.Recorder, tx core.Transaction) {
+		t.Helper()
+		rec.ExpectRollback()
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		user := &User{ID: 7, Roles: []*Role{admin}}
+		rec, tx, association := roles(t, user)
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "user_roles" ("user_id", "role_id") VALUES ($1, $2), ($1, $3) ON CONFLICT DO NOTHING`)).
+			WithArgs(7, 1, 2)
+
+		// Os modelos repetidos e os que já estão no campo não são acrescentados de novo
+		if err := association.Append(ctx, admin, editor, editor); err != nil {
+			t.Fatal(err)
+		}
+		if ids := roleIDs(user); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("Expected roles [1 2], got %v", ids)
+		}
+		finish(t, rec, tx)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		user := &User{ID: 7, Roles: []*Role{admin, editor, viewer}}
+		rec, tx, association := roles(t, user)
+		rec.ExpectExec(sqltest.Exact(`DELETE FROM "user_roles" WHERE "user_id" = $1 AND "role_id" IN ($2, $3)`)).
+			WithArgs(7, 1, 3)
+
+		if err := association.Remove(ctx, admin, viewer); err != nil {
+			t.Fatal(err)
+		}
+		if ids := roleIDs(user); len(ids) != 1 || ids[0] != 2 {
+			t.Errorf("Expected roles [2], got %v", ids)
+		}
+		finish(t, rec, tx)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		user := &User{ID: 7, Roles: []*Role{admin}}
+		rec, tx, association := roles(t, user)
+		rec.ExpectExec(sqltest.Exact(`WITH removed AS (DELETE FROM "user_roles" WHERE "user_id" = $1 AND "role_id" NOT IN ($2, $3)) `+
+			`INSERT INTO "user_roles" ("user_id", "role_id") VALUES ($1, $2), ($1, $3) ON CONFLICT DO NOTHING`)).
+			WithArgs(7, 2, 3)
+
+		if err := association.Replace(ctx, editor, viewer, editor); err != nil {
+			t.Fatal(err)
+		}
+		if ids := roleIDs(user); len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+			t.Errorf("Expected roles [2 3], got %v", ids)
+		}
+		finish(t, rec, tx)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		user := &User{ID: 7, Roles: []*Role{admin, editor}}
+		rec, tx, association := roles(t, user)
+		rec.ExpectExec(sqltest.Exact(`DELETE FROM "user_roles" WHERE "user_id" = $1`)).WithArgs(7)
+		// Replace sem modelos equivale a Clear
+		rec.ExpectExec(sqltest.Exact(`DELETE FROM "user_roles" WHERE "user_id" = $1`)).WithArgs(7)
+
+		if err := association.Clear(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := association.Replace(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(user.Roles) != 0 {
+			t.Errorf("Expected no roles, got %v", roleIDs(user))
+		}
+		finish(t, rec, tx)
+	})
+
+	t.Run("NotPersisted", func(t *testing.T) {
+		user := &User{ID: 7}
+		rec, tx, association := roles(t, user)
+		if err := association.Append(ctx, &Role{Name: "guest"}); err == nil {
+			t.Error("Expected error appending a model without key")
+		}
+		finish(t, rec, tx)
+	})
+}
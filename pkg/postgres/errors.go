@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+
+	"github.com/lib/pq"
+)
+
+// ErrCallerOwnedPool is returned by Connect and the operations of an ORM given
+// WithSearchPath or a session parameter option together with a pool opened by the caller,
+// through NewPostgresORMFromDB or WithReplicas, since those connections cannot receive them
+var ErrCallerOwnedPool = errors.New("postgres: WithSearchPath and the session parameter options cannot apply to pools given with NewPostgresORMFromDB or WithReplicas; use NewPostgresORMFromConnector or Connect")
+
+// errorCodes maps PostgreSQL SQLSTATE codes to the ORM typed errors
+var errorCodes = map[pq.ErrorCode]error{
+	"23505": core.ErrDuplicate,   // unique_violation
+	"23503": core.ErrForeignKey,  // foreign_key_violation
+	"23502": core.ErrNotNull,     // not_null_violation
+	"40P01": core.ErrDeadlock,    // deadlock_detected
+	"40001": core.ErrDeadlock,    // serialization_failure
+	"55P03": core.ErrLockTimeout, // lock_not_available
+}
+
+// translateError wraps driver errors with the matching ORM typed error, keeping the
+// original error in the chain
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if typed, ok := errorCodes[pqErr.Code]; ok {
+			return fmt.Errorf("%w: %w", typed, err)
+		}
+	}
+	return err
+}
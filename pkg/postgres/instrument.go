@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// spanKey is the context key of the span of the running operation, which receives the
+// statements reported by the runner
+type spanKey struct{}
+
+// instrument runs an operation of the ORM or of a transaction within a span of the
+// instrumentation, if one is set
+func (r runner) instrument(ctx context.Context, op core.Operation, run func(ctx context.Context) error) error {
+	if r.instrumentation == nil {
+		return run(ctx)
+	}
+	ctx, span := r.instrumentation.StartSpan(ctx, op)
+	err := run(context.WithValue(ctx, spanKey{}, span))
+	span.End(err)
+	return err
+}
+
+// operation describes the operation name on the table of the model, or of the elements
+// of a slice of models, leaving the table empty when it cannot be resolved
+func operation(mapper *utils.Mapper, name string, model interface{}) core.Operation {
+	typ := reflect.TypeOf(model)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ != nil && typ.Kind() == reflect.Slice {
+		model = reflect.Zero(typ.Elem()).Interface()
+	}
+	table, _ := mapper.ModelTableName(model)
+	return core.Operation{Name: name, Table: table}
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+type Credential struct {
+	ID       int64  `db:"id,primary"`
+	Login    string `db:"login"`
+	Password string `db:"password,sensitive"`
+}
+
+// recordingLogger guarda os eventos recebidos
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []core.QueryEvent
+}
+
+func (l *recordingLogger) LogQuery(ctx context.Context, event core.QueryEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+	rec := sqltest.New()
+	defer rec.Close()
+	logger := &recordingLogger{}
+	orm := NewPostgresORMFromDB(rec.DB(), WithLogger(logger))
+
+	rec.ExpectQuery(sqltest.Regexp(`^INSERT INTO "credentials"`)).WillReturnRows(sqltest.NewRows("id").AddRow(1))
+	rec.ExpectExec(sqltest.Regexp(`^UPDATE "credentials"`)).WillReturnResult(0, 1)
+	rec.ExpectBegin()
+	rec.ExpectExec(sqltest.Regexp(`^DELETE`)).WillReturnError(errors.New("boom"))
+	rec.ExpectRollback()
+
+	credential := &Credential{Login: "ana", Password: "s3cret"}
+	if err := orm.Create(ctx, credential); err != nil {
+		t.Fatal(err)
+	}
+	if err := orm.Update(ctx, credential); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := orm.Transaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Delete(ctx, credential)
+	tx.Rollback()
+	// Rollback de uma transação encerrada não é registrado
+	tx.Rollback()
+
+	if len(logger.events) != 5 {
+		t.Fatalf("Expected 5 events, got %d: %+v", len(logger.events), logger.events)
+	}
+
+	t.Run("Redaction", func(t *testing.T) {
+		// Os valores das colunas sensíveis são ocultados, mas enviados ao banco
+		created, updated := logger.events[0], logger.events[1]
+		if created.Args[0] != "ana" || created.Args[1] != core.Redacted {
+			t.Errorf("Expected redacted password on insert, got %v", created.Args)
+		}
+		if updated.Args[1] != core.Redacted || updated.Args[2] != int64(1) {
+			t.Errorf("Expected redacted password on update, got %v", updated.Args)
+		}
+		if args := rec.Statements()[0].Args; args[1] != "s3cret" {
+			t.Errorf("Expected the real password to reach the driver, got %v", args)
+		}
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		if logger.events[0].RowsAffected != -1 || logger.events[1].RowsAffected != 1 {
+			t.Errorf("Expected rows -1 and 1, got %d and %d", logger.events[0].RowsAffected, logger.events[1].RowsAffected)
+		}
+		statements := []string{logger.events[2].SQL, logger.events[4].SQL}
+		if statements[0] != "BEGIN" || statements[1] != "ROLLBACK" {
+			t.Errorf("Expected BEGIN and ROLLBACK events, got %v", statements)
+		}
+		if logger.events[3].Err == nil || logger.events[3].Slow {
+			t.Errorf("Expected failed statement without slow flag, got %+v", logger.events[3])
+		}
+	})
+}
+
+func TestSlogLogger(t *testing.T) {
+	ctx := context.Background()
+	rec := sqltest.New()
+	defer rec.Close()
+
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	orm := NewPostgresORMFromDB(rec.DB(),
+		WithLogger(core.NewSlogLogger(slog.New(handler))),
+		WithSlowQueryThreshold(time.Nanosecond),
+	)
+
+	rec.ExpectExec(sqltest.Regexp(`^UPDATE`))
+	if err := orm.Update(ctx, &Credential{ID: 2, Login: "bia", Password: "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+	for _, part := range []string{"level=WARN", `msg="slow query"`, `sql="UPDATE \"credentials\"`, "rows=1", "[REDACTED]"} {
+		if !strings.Contains(output, part) {
+			t.Errorf("Expected log to contain %s, got %s", part, output)
+		}
+	}
+	if strings.Contains(output, "hunter2") {
+		t.Errorf("Expected password to be redacted, got %s", output)
+	}
+}
@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+)
+
+// intercept runs the operation through the middleware chain, which ends with the
+// handler running its statement
+func (r runner) intercept(ctx context.Context, op *core.Op, handler core.Handler) error {
+	return core.Chain(r.middleware, handler)(ctx, op)
+}
+
+// queryHandler runs the statement of a Query operation
+func queryHandler(run runner) core.Handler {
+	return func(ctx context.Context, op *core.Op) (err error) {
+		op.Rows, err = run.QueryContext(ctx, op.SQL, op.Args...)
+		return err
+	}
+}
+
+// execHandler runs the statement of an Exec operation
+func execHandler(run runner) core.Handler {
+	return func(ctx context.Context, op *core.Op) (err error) {
+		op.Result, err = run.ExecContext(ctx, op.SQL, op.Args...)
+		return err
+	}
+}
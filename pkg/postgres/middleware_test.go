@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Order", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Cada middleware registra a entrada e a saída da operação
+		var calls []string
+		trace := func(name string) core.Middleware {
+			return func(ctx context.Context, op *core.Op, next core.Handler) error {
+				calls = append(calls, fmt.Sprintf("%s>%s %s", name, op.Kind, op.Table))
+				err := next(ctx, op)
+				calls = append(calls, "<"+name)
+				return err
+			}
+		}
+		orm := NewPostgresORMFromDB(rec.DB(), WithMiddleware(trace("a"), trace("b")))
+
+		rec.ExpectExec(sqltest.Regexp(`^DELETE FROM "credentials"`))
+		if err := orm.Delete(ctx, &Credential{ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"a>delete credentials", "b>delete credentials", "<b", "<a"}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("Expected calls %v, got %v", expected, calls)
+		}
+	})
+
+	t.Run("Rewrite", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Restringe as operações de leitura ao inquilino da requisição
+		tenant := func(ctx context.Context, op *core.Op, next core.Handler) error {
+			if op.Kind == core.OpFind || op.Kind == core.OpQuery {
+				op.SQL += fmt.Sprintf(" AND \"tenant_id\" = $%d", len(op.Args)+1)
+				op.Args = append(op.Args, 42)
+			}
+			return next(ctx, op)
+		}
+		orm := NewPostgresORMFromDB(rec.DB(), WithMiddleware(tenant))
+
+		rec.ExpectQuery(sqltest.Regexp(`WHERE "id" = \$1 AND "tenant_id" = \$2$`)).
+			WithArgs(7, 42).
+			WillReturnRows(sqltest.NewRows("id", "login", "password").AddRow(7, "ana", "x"))
+		rec.ExpectQuery(sqltest.Exact(`SELECT 1 WHERE true AND "tenant_id" = $1`)).WithArgs(42)
+
+		credential := &Credential{}
+		if err := orm.FindByID(ctx, credential, 7); err != nil {
+			t.Fatal(err)
+		}
+		if credential.Login != "ana" {
+			t.Errorf("Expected login ana, got %s", credential.Login)
+		}
+		rows, err := orm.Query(ctx, "SELECT 1 WHERE true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ShortCircuit", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Atende as buscas por um cache e ignora as alterações, sem acessar o banco
+		cache := func(ctx context.Context, op *core.Op, next core.Handler) error {
+			switch op.Kind {
+			case core.OpFind:
+				if credential, ok := op.Model.(*Credential); ok {
+					*credential = Credential{ID: 3, Login: "cached"}
+					return nil
+				}
+			case core.OpUpdate, core.OpDelete:
+				return nil
+			case core.OpExec:
+				return errors.New("blocked")
+			}
+			return next(ctx, op)
+		}
+		orm := NewPostgresORMFromDB(rec.DB(), WithMiddleware(cache))
+
+		credential := &Credential{}
+		if err := orm.FindByID(ctx, credential, 3); err != nil || credential.Login != "cached" {
+			t.Errorf("Expected cached credential, got %+v (%v)", credential, err)
+		}
+		if err := orm.Update(ctx, credential); err != nil {
+			t.Errorf("Expected update completed by the middleware, got %v", err)
+		}
+		if _, err := orm.Exec(ctx, "TRUNCATE credentials"); err == nil || err.Error() != "blocked" {
+			t.Errorf("Expected blocked error, got %v", err)
+		}
+		if statements := rec.Statements(); len(statements) != 0 {
+			t.Errorf("Expected no statements, got %v", statements)
+		}
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Audita as linhas afetadas após a execução das instruções
+		var audit []string
+		observe := func(ctx context.Context, op *core.Op, next core.Handler) error {
+			err := next(ctx, op)
+			if op.Result != nil {
+				rows, _ := op.Result.RowsAffected()
+				audit = append(audit, fmt.Sprintf("%s %s %d", op.Kind, op.Table, rows))
+			}
+			return err
+		}
+		orm := NewPostgresORMFromDB(rec.DB(), WithMiddleware(observe))
+
+		rec.ExpectBegin()
+		rec.ExpectExec(sqltest.Regexp(`^INSERT INTO "credentials"`))
+		rec.ExpectExec(sqltest.Regexp(`^UPDATE "credentials"`)).WillReturnResult(0, 0)
+		rec.ExpectExec(sqltest.Exact(`DELETE FROM "sessions"`)).WillReturnResult(0, 3)
+		rec.ExpectCommit()
+
+		tx, err := orm.Transaction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Create(ctx, &Credential{ID: 5, Login: "bia"})
+		if err := tx.Update(ctx, &Credential{ID: 6}); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		tx.Exec(ctx, `DELETE FROM "sessions"`)
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"create credentials 1", "update credentials 0", "exec  3"}
+		if !reflect.DeepEqual(audit, expected) {
+			t.Errorf("Expected audit %v, got %v", expected, audit)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/schema"
+)
+
+// AutoMigrateOptions controls the destructive changes made by AutoMigrateWithOptions
+type AutoMigrateOptions struct {
+	// DropColumns drops the columns that no longer exist in the models
+	DropColumns bool
+	// DropIndexes drops the indexes that no longer exist in the models
+	DropIndexes bool
+}
+
+// AutoMigrate creates the missing tables and adds the missing columns and indexes of
+// the given models. It never drops tables, columns or indexes.
+func (p *PostgresORM) AutoMigrate(ctx context.Context, models ...core.Model) error {
+	return p.AutoMigrateWithOptions(ctx, AutoMigrateOptions{}, models...)
+}
+
+// AutoMigrateWithOptions migrates the given models like AutoMigrate, optionally dropping
+// the columns and indexes that no longer exist in the models. All changes run in a
+// single transaction.
+func (p *PostgresORM) AutoMigrateWithOptions(ctx context.Context, opts AutoMigrateOptions, models ...core.Model) error {
+	if err := p.connected(); err != nil {
+		return err
+	}
+	return p.runner(p.db).instrument(ctx, core.Operation{Name: "auto_migrate"}, func(ctx context.Context) error {
+		return p.autoMigrate(ctx, opts, models)
+	})
+}
+
+// autoMigrate runs AutoMigrateWithOptions within its span
+func (p *PostgresORM) autoMigrate(ctx context.Context, opts AutoMigrateOptions, models []core.Model) error {
+	tx, run, err := p.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer run.control(ctx, "ROLLBACK", tx.Rollback)
+
+	for _, model := range models {
+		desired, err := schema.FromModelWith(p.mapper, model)
+		if err != nil {
+			return fmt.Errorf("error describing model %T: %w", model, err)
+		}
+
+		current, err := schema.InspectTable(ctx, run, desired.Schema, desired.Name)
+		if err != nil {
+			return err
+		}
+
+		statements, err := schema.Plan(desired, current, schema.PlanOptions{
+			DropColumns: opts.DropColumns,
+			DropIndexes: opts.DropIndexes,
+		})
+		if err != nil {
+			return fmt.Errorf("error migrating table %s: %w", desired.QualifiedName(), err)
+		}
+		for _, statement := range statements {
+			if _, err := run.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("error migrating table %s: %w", desired.QualifiedName(), err)
+			}
+		}
+	}
+
+	if err := run.control(ctx, "COMMIT", tx.Commit); err != nil {
+		return fmt.Errorf("error committing migration: %w", err)
+	}
+	return nil
+}
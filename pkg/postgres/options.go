@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// Option configures a PostgresORM
+type Option func(*PostgresORM)
+
+// WithNamingStrategy sets the strategy deriving the table, column and join table
+// names that models do not declare explicitly
+func WithNamingStrategy(naming utils.NamingStrategy) Option {
+	return func(p *PostgresORM) {
+		p.naming = naming
+	}
+}
+
+// WithDefaultSchema sets the schema of the models that declare none with a qualified
+// table name, a Schema method or a schema tag
+func WithDefaultSchema(schema string) Option {
+	return func(p *PostgresORM) {
+		p.schema = schema
+	}
+}
+
+// WithSearchPath sets the search_path of every connection opened by Connect or
+// NewPostgresORMFromConnector, so unqualified names in custom queries resolve against
+// the given schemas in order
+func WithSearchPath(schemas ...string) Option {
+	return func(p *PostgresORM) {
+		p.searchPath = schemas
+	}
+}
+
+// WithDriverName sets the database/sql driver used by Connect, such as a driver
+// registered by an instrumentation wrapper around lib/pq. The default is "postgres".
+func WithDriverName(name string) Option {
+	return func(p *PostgresORM) {
+		p.driverName = name
+	}
+}
+
+// WithClosePool sets whether Close closes a pool given to NewPostgresORMFromDB or
+// opened by NewPostgresORMFromConnector. Pools given to NewPostgresORMFromDB are kept
+// open by default and pools of NewPostgresORMFromConnector are closed; pools opened by
+// Connect are always closed.
+func WithClosePool(close bool) Option {
+	return func(p *PostgresORM) {
+		p.closeDB = close
+	}
+}
+
+// WithMaxOpenConns sets the maximum number of open connections of the pool, as
+// sql.DB.SetMaxOpenConns; zero or less means no limit
+func WithMaxOpenConns(n int) Option {
+	return withPoolSetting(func(db *sql.DB) { db.SetMaxOpenConns(n) })
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept by the pool, as
+// sql.DB.SetMaxIdleConns; zero or less keeps none
+func WithMaxIdleConns(n int) Option {
+	return withPoolSetting(func(db *sql.DB) { db.SetMaxIdleConns(n) })
+}
+
+// WithConnMaxLifetime sets how long a connection may be reused, as
+// sql.DB.SetConnMaxLifetime
+func WithConnMaxLifetime(d time.Duration) Option {
+	return withPoolSetting(func(db *sql.DB) { db.SetConnMaxLifetime(d) })
+}
+
+// WithConnMaxIdleTime sets how long a connection may stay idle, as
+// sql.DB.SetConnMaxIdleTime
+func WithConnMaxIdleTime(d time.Duration) Option {
+	return withPoolSetting(func(db *sql.DB) { db.SetConnMaxIdleTime(d) })
+}
+
+// withPoolSetting records a setting applied to the pool opened by Connect or given to
+// the other constructors
+func withPoolSetting(setting func(*sql.DB)) Option {
+	return func(p *PostgresORM) {
+		p.poolSettings = append(p.poolSettings, setting)
+	}
+}
+
+// WithApplicationName sets the application_name reported by the connections opened by
+// Connect or NewPostgresORMFromConnector, as shown in pg_stat_activity
+func WithApplicationName(name string) Option {
+	return WithSessionParameter("application_name", name)
+}
+
+// WithStatementTimeout sets the statement_timeout of the connections opened by
+// Connect or NewPostgresORMFromConnector, aborting statements that run longer; zero
+// disables the timeout
+func WithStatementTimeout(d time.Duration) Option {
+	return WithSessionParameter("statement_timeout", strconv.FormatInt(d.Milliseconds(), 10))
+}
+
+// WithTimezone sets the TimeZone of the connections opened by Connect or
+// NewPostgresORMFromConnector, such as "UTC" or "America/Sao_Paulo"
+func WithTimezone(timezone string) Option {
+	return WithSessionParameter("TimeZone", timezone)
+}
+
+// WithSessionParameter sets a run-time parameter, such as lock_timeout, of the
+// connections opened by Connect, in the connection string, or by
+// NewPostgresORMFromConnector, with set_config. The pools given to NewPostgresORMFromDB
+// or WithReplicas are opened by the caller, so combined with them Connect and the
+// operations fail with ErrCallerOwnedPool.
+func WithSessionParameter(name, value string) Option {
+	return func(p *PostgresORM) {
+		p.session = append(p.session, parameter{name, value})
+	}
+}
+
+// WithLogger sets the logger receiving an event for every statement run by the ORM and
+// its transactions, such as core.NewSlogLogger(slog.Default())
+func WithLogger(logger core.Logger) Option {
+	return func(p *PostgresORM) {
+		p.logger = logger
+	}
+}
+
+// WithSlowQueryThreshold marks the statements that take at least d as slow, so the
+// logger reports them at warn level; zero disables the threshold
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(p *PostgresORM) {
+		p.slowQuery = d
+	}
+}
+
+// WithInstrumentation sets the instrumentation starting a span around every call to the
+// ORM and its transactions, such as the OpenTelemetry adapter of the instrument/otel
+// package
+func WithInstrumentation(instrumentation core.Instrumentation) Option {
+	return func(p *PostgresORM) {
+		p.instrumentation = instrumentation
+	}
+}
+
+// WithStatementCache prepares the statements with arguments run by the ORM and its
+// transactions, keeping up to size of them in an LRU cache keyed by their SQL, so hot
+// paths are parsed and planned once per connection. Zero, the default, disables the
+// cache. Do not use it behind connection poolers running in transaction mode, which do
+// not keep prepared statements across transactions.
+func WithStatementCache(size int) Option {
+	return func(p *PostgresORM) {
+		p.stmtCacheSize = size
+	}
+}
+
+// WithReplicas sends the reads of the ORM to the given read replica pools, balanced in
+// round robin over the healthy ones. Writes, transactions and migrations stay on the
+// primary, and core.WithPrimary forces a read onto it; Query only uses the replicas
+// with core.WithReplica. The pools stay owned by the
+// caller, so Close does not close them.
+func WithReplicas(replicas ...*sql.DB) Option {
+	return func(p *PostgresORM) {
+		p.replicaDBs = append(p.replicaDBs, replicas...)
+	}
+}
+
+// WithReplicaDSNs opens a read replica pool for each connection string when Connect is
+// called, with the same search_path, session parameters and pool settings as the
+// primary; reads are routed like WithReplicas. Close closes these pools.
+func WithReplicaDSNs(connectionStrings ...string) Option {
+	return func(p *PostgresORM) {
+		p.replicaDSNs = append(p.replicaDSNs, connectionStrings...)
+	}
+}
+
+// WithReplicaRetryInterval sets how long a replica that could not be reached is
+// skipped before reads are sent to it again. The default is 30 seconds.
+func WithReplicaRetryInterval(d time.Duration) Option {
+	return func(p *PostgresORM) {
+		p.replicaRetry = d
+	}
+}
+
+// WithMiddleware adds middleware intercepting the Create, Find, Update, Delete, Query
+// and Exec operations of the ORM and its transactions once their statement is built.
+// Middleware given first runs first.
+func WithMiddleware(middleware ...core.Middleware) Option {
+	return func(p *PostgresORM) {
+		p.middleware = append(p.middleware, middleware...)
+	}
+}
@@ -3,32 +3,119 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
-	"strings"
+	"time"
 
 	"github.com/rodolfocoding/night-orm/pkg/core"
 	"github.com/rodolfocoding/night-orm/pkg/utils"
-
-	"github.com/lib/pq"
 )
 
 // PostgresORM is the PostgreSQL ORM implementation
 type PostgresORM struct {
-	db *sql.DB
+	db         *sql.DB
+	closeDB    bool
+	connector  driver.Connector // opens db once the options are applied
+	driverName string
+	mapper     *utils.Mapper
+	naming     utils.NamingStrategy
+	schema     string
+	searchPath []string
+
+	poolSettings    []func(*sql.DB)
+	session         []parameter
+	logger          core.Logger
+	slowQuery       time.Duration
+	instrumentation core.Instrumentation
+	middleware      []core.Middleware
+	stmtCacheSize   int
+	statements      *stmtCache
+	replicaDBs      []*sql.DB
+	replicaDSNs     []string
+	replicaRetry    time.Duration
+	replicas        *replicaSet
+	err             error // option conflict reported by Connect and the operations
 }
 
 // NewPostgresORM creates a new instance of the PostgreSQL ORM
-func NewPostgresORM() *PostgresORM {
-	return &PostgresORM{}
+func NewPostgresORM(opts ...Option) *PostgresORM {
+	return newPostgresORM(&PostgresORM{closeDB: true}, opts)
+}
+
+// NewPostgresORMFromDB creates an instance of the PostgreSQL ORM on an existing
+// connection pool, such as the application's own or one opened by the sqltest
+// package; Connect is not needed. The pool stays owned by the caller, so Close does
+// not close it unless WithClosePool(true) is given. Since the connections of the pool
+// are opened by the caller, WithSearchPath and the session parameter options make Connect
+// and every operation fail with ErrCallerOwnedPool.
+func NewPostgresORMFromDB(db *sql.DB, opts ...Option) *PostgresORM {
+	return newPostgresORM(&PostgresORM{db: db}, opts)
+}
+
+// NewPostgresORMFromConnector creates an instance of the PostgreSQL ORM on a pool opened
+// with the connector, such as one wrapping the driver or refreshing credentials for
+// each new connection; Connect is not needed. The search_path and session parameters
+// of the options are set on each new connection. Close closes the pool unless
+// WithClosePool(false) is given.
+func NewPostgresORMFromConnector(connector driver.Connector, opts ...Option) *PostgresORM {
+	return newPostgresORM(&PostgresORM{connector: connector, closeDB: true}, opts)
+}
+
+// newPostgresORM applies the options, opens the pool of a connector and builds the
+// mapper
+func newPostgresORM(p *PostgresORM, opts []Option) *PostgresORM {
+	p.driverName = "postgres"
+	for _, opt := range opts {
+		opt(p)
+	}
+	if (p.db != nil || len(p.replicaDBs) > 0) && (len(p.searchPath) > 0 || len(p.session) > 0) {
+		p.err = ErrCallerOwnedPool
+	}
+	if p.connector != nil {
+		p.db = sql.OpenDB(p.sessionConnector(p.connector))
+		p.connector = nil
+	}
+
+	p.mapper = utils.DefaultMapper
+	if p.naming != nil {
+		p.mapper = utils.NewMapper(p.naming)
+	}
+	if p.schema != "" {
+		p.mapper = p.mapper.WithSchema(p.schema)
+	}
+	if p.db != nil {
+		p.configurePool(p.db)
+		p.statements = newStmtCache(p.db, p.stmtCacheSize)
+	}
+	for _, db := range p.replicaDBs {
+		p.configurePool(db)
+		p.addReplica(db, false)
+	}
+	return p
+}
+
+// Mapper returns the mapper resolving table names and primary keys of the models
+func (p *PostgresORM) Mapper() *utils.Mapper {
+	return p.mapper
+}
+
+// Dialect returns the PostgreSQL dialect used to build the queries
+func (p *PostgresORM) Dialect() utils.Dialect {
+	return p.mapper.Dialect()
 }
 
-// Connect establishes a connection to the PostgreSQL database
+// Connect establishes a connection to the PostgreSQL database, and opens the pools of
+// the replicas given with WithReplicaDSNs. Calling it again closes the statements, pools
+// and replicas of the previous connection once the new one answers.
 func (p *PostgresORM) Connect(ctx context.Context, connectionString string) error {
-	db, err := sql.Open("postgres", connectionString)
+	if p.err != nil {
+		return p.err
+	}
+	db, err := p.open(connectionString)
 	if err != nil {
-		return fmt.Errorf("error connecting to PostgreSQL: %w", err)
+		return err
 	}
 
 	// Test the connection
@@ -37,16 +124,86 @@ func (p *PostgresORM) Connect(ctx context.Context, connectionString string) erro
 		return fmt.Errorf("error pinging PostgreSQL connection: %w", err)
 	}
 
+	// Reconnecting replaces the pool and the replicas opened by a previous Connect
+	p.disconnect()
+	if err := p.connectReplicas(ctx); err != nil {
+		db.Close()
+		return err
+	}
+
 	p.db = db
+	p.closeDB = true // Pools opened by Connect are always closed by Close
+	p.statements = newStmtCache(db, p.stmtCacheSize)
 	return nil
 }
 
-// Close closes the database connection
+// disconnect closes the cached statements, the replica pools opened by Connect and the
+// database connection when owned, keeping the replicas given with WithReplicas
+func (p *PostgresORM) disconnect() {
+	if p.statements != nil {
+		p.statements.Close()
+		p.statements = nil
+	}
+	if p.replicas != nil {
+		p.replicas.closeOwned()
+	}
+	if p.db != nil && p.closeDB {
+		p.db.Close()
+	}
+	p.db = nil
+}
+
+// open opens a pool on the connection string, with the search_path, session parameters
+// and pool settings of the options
+func (p *PostgresORM) open(connectionString string) (*sql.DB, error) {
+	if len(p.searchPath) > 0 {
+		var err error
+		if connectionString, err = withSearchPath(connectionString, p.searchPath); err != nil {
+			return nil, fmt.Errorf("error setting search_path: %w", err)
+		}
+	}
+	connectionString, err := withParameters(connectionString, p.session)
+	if err != nil {
+		return nil, fmt.Errorf("error setting session parameters: %w", err)
+	}
+
+	db, err := sql.Open(p.driverName, connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to PostgreSQL: %w", err)
+	}
+	p.configurePool(db)
+	return db, nil
+}
+
+// Close closes the cached statements, the replica pools opened by Connect and the
+// database connection, unless it is a pool shared with NewPostgresORMFromDB
 func (p *PostgresORM) Close() error {
 	if p.db == nil {
 		return errors.New("connection not established")
 	}
-	return p.db.Close()
+	if p.statements != nil {
+		p.statements.Close()
+	}
+	var replicasErr error
+	if p.replicas != nil {
+		replicasErr = p.replicas.close()
+	}
+	if !p.closeDB {
+		return replicasErr
+	}
+	return errors.Join(p.db.Close(), replicasErr)
+}
+
+// connected returns the option conflict found by the constructor, or an error when no
+// connection was established
+func (p *PostgresORM) connected() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.db == nil {
+		return errors.New("connection not established")
+	}
+	return nil
 }
 
 // DB returns the underlying database connection
@@ -56,87 +213,109 @@ func (p *PostgresORM) DB() *sql.DB {
 
 // Create inserts a new record into the database
 func (p *PostgresORM) Create(ctx context.Context, model core.Model) error {
-	if p.db == nil {
-		return errors.New("connection not established")
+	return p.runner(p.db).instrument(ctx, operation(p.mapper, "create", model), func(ctx context.Context) error {
+		return p.create(ctx, model)
+	})
+}
+
+// create runs Create within its span
+func (p *PostgresORM) create(ctx context.Context, model core.Model) error {
+	if err := p.connected(); err != nil {
+		return err
 	}
+	return insert(ctx, p.runner(p.db), p.mapper, model)
+}
 
+// insert inserts the model through run, the runner of the pool or of a transaction,
+// and sets the primary key generated by the database
+func insert(ctx context.Context, run runner, mapper *utils.Mapper, model core.Model) error {
 	// Get the struct fields
-	fields, err := utils.GetStructFields(model)
+	fields, err := mapper.StructFields(model)
 	if err != nil {
 		return fmt.Errorf("error retrieving struct fields: %w", err)
 	}
 
 	// Prepare the insert query
-	qb := utils.NewQueryBuilder()
+	qb := mapper.NewQueryBuilder()
 	columns := make([]string, 0, len(fields))
 	values := make([]interface{}, 0, len(fields))
 
-	// Check if the model implements ModelWithPrimaryKey to identify the primary key
-	var primaryKey string
-	var primaryKeyValue interface{}
-	if modelWithPK, ok := model.(core.ModelWithPrimaryKey); ok {
-		primaryKey = modelWithPK.PrimaryKey()
-		primaryKeyValue = modelWithPK.PrimaryKeyValue()
+	table, err := mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
+	// Identify the primary key from the model methods or the struct tags; models
+	// without a primary key are inserted without RETURNING
+	primaryKey, primaryKeyValue, err := mapper.PrimaryKey(model)
+	if err != nil {
+		primaryKey = ""
 	}
 
 	// Filter fields, omitting the primary key if its value is zero
-	for column, value := range fields {
-		if column == primaryKey && reflect.ValueOf(primaryKeyValue).IsZero() {
+	for _, column := range mapper.OrderedColumns(model, fields) {
+		value := fields[column]
+		if column == primaryKey && (primaryKeyValue == nil || reflect.ValueOf(primaryKeyValue).IsZero()) {
 			continue // Omit the primary key if its value is zero
 		}
 		columns = append(columns, column)
 		values = append(values, value)
 	}
 
-	qb.WriteInsert(model.TableName(), columns, values)
-	// Add RETURNING to retrieve the generated ID
-	if primaryKey != "" {
+	qb.WriteInsert(table, columns, values)
+	// Add RETURNING to retrieve the generated primary key, when the model leaves it zero
+	generated := primaryKey != "" && (primaryKeyValue == nil || reflect.ValueOf(primaryKeyValue).IsZero())
+	if generated {
 		qb.WriteReturning(primaryKey)
 	}
 	query, args := qb.Build()
-
-	// Execute the query and capture the returned ID
-	var generatedID int
-	if primaryKey != "" {
-		err = p.db.QueryRowContext(ctx, query, args...).Scan(&generatedID)
-	} else {
-		_, err = p.db.ExecContext(ctx, query, args...)
-	}
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			return fmt.Errorf("record already exists: %w", err)
+	sensitive := sensitiveColumns(mapper, model, columns)
+
+	// Execute the query through the middleware and capture the returned ID
+	op := &core.Op{Kind: core.OpCreate, Table: table, Model: model, SQL: query, Args: args}
+	err = run.intercept(ctx, op, func(ctx context.Context, op *core.Op) (err error) {
+		if !generated {
+			op.Result, err = run.exec(ctx, op.SQL, op.Args, sensitive)
+			return translateError(err)
+		}
+		generatedID := primaryKeyDestination(mapper, model, primaryKey)
+		if err := run.queryRow(ctx, op.SQL, op.Args, sensitive).Scan(generatedID.Interface()); err != nil {
+			return translateError(err)
 		}
-		return fmt.Errorf("error inserting record: %w", err)
-	}
 
-	// Update the model with the generated ID, if applicable
-	if primaryKey != "" {
-		if err := utils.SetStructField(model, primaryKey, generatedID); err != nil {
+		// Update the model with the generated ID
+		if err := mapper.SetField(model, primaryKey, generatedID.Elem().Interface()); err != nil {
 			return fmt.Errorf("error setting primary key value: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error inserting record: %w", err)
 	}
 
 	return nil
 }
 
-// FindByID retrieves a record by ID
-func (p *PostgresORM) FindByID(ctx context.Context, model core.ModelWithPrimaryKey, id interface{}) error {
-	if p.db == nil {
-		return errors.New("connection not established")
+// primaryKeyDestination returns a pointer to a new value of the type of the primary key
+// field, to scan the key returned by an insert into
+func primaryKeyDestination(mapper *utils.Mapper, model core.Model, column string) reflect.Value {
+	if info, ok := utils.FindField(mapper.Fields(reflect.TypeOf(model)), column); ok {
+		return reflect.New(info.Type)
 	}
+	return reflect.New(reflect.TypeOf((*interface{})(nil)).Elem())
+}
 
-	// Build the query
-	qb := utils.NewQueryBuilder()
-	qb.WriteSelect().
-		WriteFrom(model.TableName()).
-		WriteWhere(fmt.Sprintf("%s = %s", model.PrimaryKey(), qb.AddParam(id)))
-
-	query, args := qb.Build()
+// FindByID retrieves a record by ID
+func (p *PostgresORM) FindByID(ctx context.Context, model core.Model, id interface{}) error {
+	return p.runner(p.db).instrument(ctx, operation(p.mapper, "find_by_id", model), func(ctx context.Context) error {
+		return p.findByID(ctx, model, id)
+	})
+}
 
-	// Execute the query
-	row := p.db.QueryRowContext(ctx, query, args...)
-	if row.Err() != nil {
-		return fmt.Errorf("error executing query: %w", row.Err())
+// findByID runs FindByID within its span
+func (p *PostgresORM) findByID(ctx context.Context, model core.Model, id interface{}) error {
+	if err := p.connected(); err != nil {
+		return err
 	}
 
 	// Get the struct fields
@@ -149,39 +328,50 @@ func (p *PostgresORM) FindByID(ctx context.Context, model core.ModelWithPrimaryK
 		return errors.New("model must be a pointer to a struct")
 	}
 
-	// Prepare destinations for scanning
-	fields, err := utils.GetStructFields(model)
+	table, err := p.mapper.ModelTableName(model)
 	if err != nil {
-		return fmt.Errorf("error retrieving struct fields: %w", err)
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+	primaryKey, _, err := p.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
 	}
 
-	// Create slices for column names and value destinations
+	fields := p.mapper.Fields(val.Type())
 	columns := make([]string, 0, len(fields))
-	destinations := make([]interface{}, 0, len(fields))
-
-	for column := range fields {
-		columns = append(columns, column)
-		// Create a destination for each field
-		dest := reflect.New(reflect.TypeOf(fields[column])).Interface()
-		destinations = append(destinations, dest)
+	for _, field := range fields {
+		columns = append(columns, field.Column)
 	}
 
-	// Scan the values
-	if err := row.Scan(destinations...); err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("record not found")
+	// Build the query selecting the mapped columns in declaration order
+	qb := p.mapper.NewQueryBuilder()
+	qb.WriteSelect(columns...).
+		WriteFrom(table).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(id)))
+
+	query, args := qb.Build()
+
+	// Execute the query through the middleware and scan the values straight into the
+	// struct fields, on a replica when there are any
+	run := p.reader(ctx)
+	op := &core.Op{Kind: core.OpFind, Table: table, Model: model, SQL: query, Args: args}
+	err = run.intercept(ctx, op, func(ctx context.Context, op *core.Op) error {
+		row := run.QueryRowContext(ctx, op.SQL, op.Args...)
+		if err := row.Scan(p.mapper.ScanDestinations(val, columns)...); err != nil {
+			if err == sql.ErrNoRows {
+				return core.ErrNotFound
+			}
+			return fmt.Errorf("error scanning values: %w", err)
 		}
-		return fmt.Errorf("error scanning values: %w", err)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Set the values in the struct fields
-	for i, column := range columns {
-		// Get the destination value
-		destVal := reflect.ValueOf(destinations[i]).Elem().Interface()
-		// Set the value in the struct field
-		if err := utils.SetStructField(model, column, destVal); err != nil {
-			return fmt.Errorf("error setting value for field %s: %w", column, err)
-		}
+	// Load the relations requested with core.WithPreload
+	if err := p.preloadFromContext(ctx, model); err != nil {
+		return err
 	}
 
 	return nil
@@ -189,8 +379,15 @@ func (p *PostgresORM) FindByID(ctx context.Context, model core.ModelWithPrimaryK
 
 // FindAll retrieves all records of a model
 func (p *PostgresORM) FindAll(ctx context.Context, model core.Model, dest interface{}) error {
-	if p.db == nil {
-		return errors.New("connection not established")
+	return p.runner(p.db).instrument(ctx, operation(p.mapper, "find_all", model), func(ctx context.Context) error {
+		return p.findAll(ctx, model, dest)
+	})
+}
+
+// findAll runs FindAll within its span
+func (p *PostgresORM) findAll(ctx context.Context, model core.Model, dest interface{}) error {
+	if err := p.connected(); err != nil {
+		return err
 	}
 
 	// Verify that the destination is a slice pointer
@@ -203,13 +400,38 @@ func (p *PostgresORM) FindAll(ctx context.Context, model core.Model, dest interf
 		return errors.New("destination must be a pointer to a slice")
 	}
 
+	table, err := p.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
 	// Build the query
-	qb := utils.NewQueryBuilder()
-	qb.WriteSelect().WriteFrom(model.TableName())
+	qb := p.mapper.NewQueryBuilder()
+	qb.WriteSelect().WriteFrom(table)
 	query, args := qb.Build()
 
-	// Execute the query
-	rows, err := p.db.QueryContext(ctx, query, args...)
+	// Execute the query through the middleware, which may also fill the destination, on
+	// a replica when there are any
+	run := p.reader(ctx)
+	op := &core.Op{Kind: core.OpFind, Table: table, Model: dest, SQL: query, Args: args}
+	err = run.intercept(ctx, op, func(ctx context.Context, op *core.Op) error {
+		return p.scanAll(ctx, run, op, destVal)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Load the relations requested with core.WithPreload
+	if err := p.preloadFromContext(ctx, dest); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// scanAll runs the query of FindAll and appends the rows to the destination slice
+func (p *PostgresORM) scanAll(ctx context.Context, run runner, op *core.Op, destVal reflect.Value) error {
+	rows, err := run.QueryContext(ctx, op.SQL, op.Args...)
 	if err != nil {
 		return fmt.Errorf("error executing query: %w", err)
 	}
@@ -231,21 +453,7 @@ func (p *PostgresORM) FindAll(ctx context.Context, model core.Model, dest interf
 		elemVal := reflect.New(elemType.Elem()).Elem()
 
 		// Prepare destinations for scanning
-		destinations := make([]interface{}, len(columns))
-		for i, column := range columns {
-			// Create a destination for each column
-			field := elemVal.FieldByNameFunc(func(name string) bool {
-				return strings.EqualFold(name, column) || strings.EqualFold(utils.GetTagName(elemType.Elem(), name, "db"), column)
-			})
-
-			if field.IsValid() && field.CanAddr() {
-				destinations[i] = field.Addr().Interface()
-			} else {
-				// Use a disposable destination if the field is not found
-				var dest interface{}
-				destinations[i] = &dest
-			}
-		}
+		destinations := p.mapper.ScanDestinations(elemVal, columns)
 
 		// Scan the values
 		if err := rows.Scan(destinations...); err != nil {
@@ -254,7 +462,7 @@ func (p *PostgresORM) FindAll(ctx context.Context, model core.Model, dest interf
 
 		// Add the element to the destination slice
 		destVal.Set(reflect.Append(destVal, reflect.New(elemType.Elem())))
-		destVal.Index(destVal.Len()-1).Set(elemVal.Addr())
+		destVal.Index(destVal.Len() - 1).Set(elemVal.Addr())
 	}
 
 	if err := rows.Err(); err != nil {
@@ -265,237 +473,390 @@ func (p *PostgresORM) FindAll(ctx context.Context, model core.Model, dest interf
 }
 
 // Update updates an existing record
-func (p *PostgresORM) Update(ctx context.Context, model core.ModelWithPrimaryKey) error {
-	if p.db == nil {
-		return errors.New("connection not established")
+func (p *PostgresORM) Update(ctx context.Context, model core.Model) error {
+	return p.runner(p.db).instrument(ctx, operation(p.mapper, "update", model), func(ctx context.Context) error {
+		return p.update(ctx, model)
+	})
+}
+
+// update runs Update within its span
+func (p *PostgresORM) update(ctx context.Context, model core.Model) error {
+	if err := p.connected(); err != nil {
+		return err
 	}
 
 	// Get the struct fields
-	fields, err := utils.GetStructFields(model)
+	fields, err := p.mapper.StructFields(model)
 	if err != nil {
 		return fmt.Errorf("error retrieving struct fields: %w", err)
 	}
 
+	table, err := p.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
 	// Remove the primary key from the fields to be updated
-	primaryKey := model.PrimaryKey()
-	primaryKeyValue := model.PrimaryKeyValue()
+	primaryKey, primaryKeyValue, err := p.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
 	delete(fields, primaryKey)
 
 	// Prepare the update query
-	qb := utils.NewQueryBuilder()
+	qb := p.mapper.NewQueryBuilder()
 	columns := make([]string, 0, len(fields))
 	values := make([]interface{}, 0, len(fields))
 
-	for column, value := range fields {
+	for _, column := range p.mapper.OrderedColumns(model, fields) {
+		value := fields[column]
 		columns = append(columns, column)
 		values = append(values, value)
 	}
 
-	qb.WriteUpdate(model.TableName(), columns, values).
-		WriteWhere(fmt.Sprintf("%s = %s", primaryKey, qb.AddParam(primaryKeyValue)))
+	qb.WriteUpdate(table, columns, values).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(primaryKeyValue)))
 
 	query, args := qb.Build()
-
-	// Execute the query
-	result, err := p.db.ExecContext(ctx, query, args...)
+	sensitive := sensitiveColumns(p.mapper, model, columns)
+
+	// Execute the query through the middleware
+	run := p.runner(p.db)
+	op := &core.Op{Kind: core.OpUpdate, Table: table, Model: model, SQL: query, Args: args}
+	err = run.intercept(ctx, op, func(ctx context.Context, op *core.Op) (err error) {
+		op.Result, err = run.exec(ctx, op.SQL, op.Args, sensitive)
+		return translateError(err)
+	})
 	if err != nil {
 		return fmt.Errorf("error updating record: %w", err)
 	}
+	if op.Result == nil {
+		return nil // Completed by a middleware
+	}
 
 	// Check if any rows were affected
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := op.Result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("error retrieving affected rows count: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no records were updated")
+		return fmt.Errorf("no records were updated: %w", core.ErrNotFound)
 	}
 
 	return nil
 }
 
 // Delete removes a record from the database
-func (p *PostgresORM) Delete(ctx context.Context, model core.ModelWithPrimaryKey) error {
-	if p.db == nil {
-		return errors.New("connection not established")
+func (p *PostgresORM) Delete(ctx context.Context, model core.Model) error {
+	return p.runner(p.db).instrument(ctx, operation(p.mapper, "delete", model), func(ctx context.Context) error {
+		return p.delete(ctx, model)
+	})
+}
+
+// delete runs Delete within its span
+func (p *PostgresORM) delete(ctx context.Context, model core.Model) error {
+	if err := p.connected(); err != nil {
+		return err
+	}
+
+	table, err := p.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+	primaryKey, primaryKeyValue, err := p.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
 	}
 
 	// Build the query
-	qb := utils.NewQueryBuilder()
-	qb.WriteDelete(model.TableName()).
-		WriteWhere(fmt.Sprintf("%s = %s", model.PrimaryKey(), qb.AddParam(model.PrimaryKeyValue())))
+	qb := p.mapper.NewQueryBuilder()
+	qb.WriteDelete(table).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(primaryKeyValue)))
 
 	query, args := qb.Build()
 
-	// Execute the query
-	result, err := p.db.ExecContext(ctx, query, args...)
+	// Execute the query through the middleware
+	run := p.runner(p.db)
+	op := &core.Op{Kind: core.OpDelete, Table: table, Model: model, SQL: query, Args: args}
+	err = run.intercept(ctx, op, func(ctx context.Context, op *core.Op) (err error) {
+		op.Result, err = run.ExecContext(ctx, op.SQL, op.Args...)
+		return translateError(err)
+	})
 	if err != nil {
 		return fmt.Errorf("error deleting record: %w", err)
 	}
+	if op.Result == nil {
+		return nil // Completed by a middleware
+	}
 
 	// Check if any rows were affected
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := op.Result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("error retrieving affected rows count: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no records were deleted")
+		return fmt.Errorf("no records were deleted: %w", core.ErrNotFound)
 	}
 
 	return nil
 }
 
-// Query executes a custom SQL query
+// Query executes a custom SQL query. With replicas, read-only SELECT queries run on a
+// replica when the context carries core.WithReplica, and on the primary otherwise.
 func (p *PostgresORM) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	if p.db == nil {
-		return nil, errors.New("connection not established")
-	}
-	return p.db.QueryContext(ctx, query, args...)
+	if err := p.connected(); err != nil {
+		return nil, err
+	}
+	run := p.runner(p.db)
+	if core.ReplicaFromContext(ctx) && readOnly(query) {
+		run = p.reader(ctx)
+	}
+	op := &core.Op{Kind: core.OpQuery, SQL: query, Args: args}
+	err := run.instrument(ctx, core.Operation{Name: "query", Statement: query}, func(ctx context.Context) error {
+		return run.intercept(ctx, op, queryHandler(run))
+	})
+	return op.Rows, err
 }
 
 // Exec executes a custom SQL command
 func (p *PostgresORM) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	if p.db == nil {
-		return nil, errors.New("connection not established")
+	if err := p.connected(); err != nil {
+		return nil, err
+	}
+	run := p.runner(p.db)
+	op := &core.Op{Kind: core.OpExec, SQL: query, Args: args}
+	err := run.instrument(ctx, core.Operation{Name: "exec", Statement: query}, func(ctx context.Context) error {
+		return run.intercept(ctx, op, execHandler(run))
+	})
+	return op.Result, err
+}
+
+// Preload loads the given relations into an already loaded model or slice of models
+func (p *PostgresORM) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	return p.runner(p.db).instrument(ctx, operation(p.mapper, "preload", dest), func(ctx context.Context) error {
+		return p.preload(ctx, dest, relations...)
+	})
+}
+
+// preload runs Preload within its span
+func (p *PostgresORM) preload(ctx context.Context, dest interface{}, relations ...string) error {
+	if err := p.connected(); err != nil {
+		return err
+	}
+	if err := p.mapper.Preload(ctx, p.reader(ctx), dest, relations...); err != nil {
+		return fmt.Errorf("error preloading relations: %w", err)
+	}
+	return nil
+}
+
+// preloadFromContext loads the relations registered in the context by core.WithPreload
+func (p *PostgresORM) preloadFromContext(ctx context.Context, dest interface{}) error {
+	relations := core.PreloadFromContext(ctx)
+	if len(relations) == 0 {
+		return nil
 	}
-	return p.db.ExecContext(ctx, query, args...)
+	return p.Preload(ctx, dest, relations...)
 }
 
 // Transaction starts a new transaction
 func (p *PostgresORM) Transaction(ctx context.Context) (core.Transaction, error) {
-	if p.db == nil {
-		return nil, errors.New("connection not established")
+	if err := p.connected(); err != nil {
+		return nil, err
 	}
 
-	tx, err := p.db.BeginTx(ctx, nil)
+	var tx *sql.Tx
+	var run runner
+	err := p.runner(p.db).instrument(ctx, core.Operation{Name: "begin"}, func(ctx context.Context) (err error) {
+		tx, run, err = p.begin(ctx)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error starting transaction: %w", err)
+		return nil, err
 	}
 
-	return &PostgresTransaction{tx: tx}, nil
+	return &PostgresTransaction{ctx: ctx, tx: tx, run: run, mapper: p.mapper}, nil
+}
+
+// begin starts a transaction, setting its search_path when the context carries one
+// registered by core.WithSearchPath, and returns the runner of its statements
+func (p *PostgresORM) begin(ctx context.Context) (*sql.Tx, runner, error) {
+	var tx *sql.Tx
+	err := p.runner(p.db).control(ctx, "BEGIN", func() (err error) {
+		tx, err = p.db.BeginTx(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return nil, runner{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	run := p.runner(tx)
+
+	if schemas := core.SearchPathFromContext(ctx); len(schemas) > 0 {
+		if _, err := run.ExecContext(ctx, "SET LOCAL search_path TO "+searchPathValue(schemas)); err != nil {
+			tx.Rollback()
+			return nil, runner{}, fmt.Errorf("error setting search_path: %w", err)
+		}
+	}
+	return tx, run, nil
 }
 
 // PostgresTransaction is the PostgreSQL transaction implementation
 type PostgresTransaction struct {
-	tx *sql.Tx
+	ctx    context.Context // context of Transaction, reported with COMMIT and ROLLBACK
+	tx     *sql.Tx
+	run    runner
+	mapper *utils.Mapper
 }
 
 // Commit commits the transaction
 func (t *PostgresTransaction) Commit() error {
-	return t.tx.Commit()
+	return t.run.instrument(t.ctx, core.Operation{Name: "commit"}, func(ctx context.Context) error {
+		return t.run.control(ctx, "COMMIT", t.tx.Commit)
+	})
 }
 
-// Rollback rolls back the transaction
+// Rollback rolls back the transaction. Rolling back a finished transaction ends its
+// span without error, so deferred rollbacks are not traced as failures.
 func (t *PostgresTransaction) Rollback() error {
-	return t.tx.Rollback()
+	var err error
+	t.run.instrument(t.ctx, core.Operation{Name: "rollback"}, func(ctx context.Context) error {
+		if err = t.run.control(ctx, "ROLLBACK", t.tx.Rollback); txDone(err) {
+			return nil
+		}
+		return err
+	})
+	return err
 }
 
 // Create inserts a new record within the transaction
 func (t *PostgresTransaction) Create(ctx context.Context, model core.Model) error {
-	// Get the struct fields
-	fields, err := utils.GetStructFields(model)
-	if err != nil {
-		return fmt.Errorf("error retrieving struct fields: %w", err)
-	}
-
-	// Prepare the insert query
-	qb := utils.NewQueryBuilder()
-	columns := make([]string, 0, len(fields))
-	values := make([]interface{}, 0, len(fields))
-
-	for column, value := range fields {
-		columns = append(columns, column)
-		values = append(values, value)
-	}
-
-	qb.WriteInsert(model.TableName(), columns, values)
-	query, args := qb.Build()
-
-	// Execute the query
-	_, err = t.tx.ExecContext(ctx, query, args...)
-	if err != nil {
-		// Check for unique constraint violation
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			return fmt.Errorf("record already exists: %w", err)
-		}
-		return fmt.Errorf("error inserting record: %w", err)
-	}
+	return t.run.instrument(ctx, operation(t.mapper, "create", model), func(ctx context.Context) error {
+		return t.create(ctx, model)
+	})
+}
 
-	return nil
+// create runs Create within its span
+func (t *PostgresTransaction) create(ctx context.Context, model core.Model) error {
+	return insert(ctx, t.run, t.mapper, model)
 }
 
 // Update updates a record within the transaction
-func (t *PostgresTransaction) Update(ctx context.Context, model core.ModelWithPrimaryKey) error {
+func (t *PostgresTransaction) Update(ctx context.Context, model core.Model) error {
+	return t.run.instrument(ctx, operation(t.mapper, "update", model), func(ctx context.Context) error {
+		return t.update(ctx, model)
+	})
+}
+
+// update runs Update within its span
+func (t *PostgresTransaction) update(ctx context.Context, model core.Model) error {
 	// Get the struct fields
-	fields, err := utils.GetStructFields(model)
+	fields, err := t.mapper.StructFields(model)
 	if err != nil {
 		return fmt.Errorf("error retrieving struct fields: %w", err)
 	}
 
+	table, err := t.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+
 	// Remove the primary key from the fields to be updated
-	primaryKey := model.PrimaryKey()
-	primaryKeyValue := model.PrimaryKeyValue()
+	primaryKey, primaryKeyValue, err := t.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
 	delete(fields, primaryKey)
 
 	// Prepare the update query
-	qb := utils.NewQueryBuilder()
+	qb := t.mapper.NewQueryBuilder()
 	columns := make([]string, 0, len(fields))
 	values := make([]interface{}, 0, len(fields))
 
-	for column, value := range fields {
+	for _, column := range t.mapper.OrderedColumns(model, fields) {
+		value := fields[column]
 		columns = append(columns, column)
 		values = append(values, value)
 	}
 
-	qb.WriteUpdate(model.TableName(), columns, values).
-		WriteWhere(fmt.Sprintf("%s = %s", primaryKey, qb.AddParam(primaryKeyValue)))
+	qb.WriteUpdate(table, columns, values).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(primaryKeyValue)))
 
 	query, args := qb.Build()
-
-	// Execute the query
-	result, err := t.tx.ExecContext(ctx, query, args...)
+	sensitive := sensitiveColumns(t.mapper, model, columns)
+
+	// Execute the query through the middleware
+	op := &core.Op{Kind: core.OpUpdate, Table: table, Model: model, SQL: query, Args: args}
+	err = t.run.intercept(ctx, op, func(ctx context.Context, op *core.Op) (err error) {
+		op.Result, err = t.run.exec(ctx, op.SQL, op.Args, sensitive)
+		return translateError(err)
+	})
 	if err != nil {
 		return fmt.Errorf("error updating record: %w", err)
 	}
+	if op.Result == nil {
+		return nil // Completed by a middleware
+	}
 
 	// Check if any rows were affected
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := op.Result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("error retrieving affected rows count: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no records were updated")
+		return fmt.Errorf("no records were updated: %w", core.ErrNotFound)
 	}
 
 	return nil
 }
 
 // Delete removes a record within the transaction
-func (t *PostgresTransaction) Delete(ctx context.Context, model core.ModelWithPrimaryKey) error {
+func (t *PostgresTransaction) Delete(ctx context.Context, model core.Model) error {
+	return t.run.instrument(ctx, operation(t.mapper, "delete", model), func(ctx context.Context) error {
+		return t.delete(ctx, model)
+	})
+}
+
+// delete runs Delete within its span
+func (t *PostgresTransaction) delete(ctx context.Context, model core.Model) error {
+	table, err := t.mapper.ModelTableName(model)
+	if err != nil {
+		return fmt.Errorf("error resolving table name: %w", err)
+	}
+	primaryKey, primaryKeyValue, err := t.mapper.PrimaryKey(model)
+	if err != nil {
+		return fmt.Errorf("error resolving primary key: %w", err)
+	}
+
 	// Build the query
-	qb := utils.NewQueryBuilder()
-	qb.WriteDelete(model.TableName()).
-		WriteWhere(fmt.Sprintf("%s = %s", model.PrimaryKey(), qb.AddParam(model.PrimaryKeyValue())))
+	qb := t.mapper.NewQueryBuilder()
+	qb.WriteDelete(table).
+		WriteWhere(fmt.Sprintf("%s = %s", qb.Quote(primaryKey), qb.AddParam(primaryKeyValue)))
 
 	query, args := qb.Build()
 
-	// Execute the query
-	result, err := t.tx.ExecContext(ctx, query, args...)
+	// Execute the query through the middleware
+	op := &core.Op{Kind: core.OpDelete, Table: table, Model: model, SQL: query, Args: args}
+	err = t.run.intercept(ctx, op, func(ctx context.Context, op *core.Op) (err error) {
+		op.Result, err = t.run.ExecContext(ctx, op.SQL, op.Args...)
+		return translateError(err)
+	})
 	if err != nil {
 		return fmt.Errorf("error deleting record: %w", err)
 	}
+	if op.Result == nil {
+		return nil // Completed by a middleware
+	}
 
 	// Check if any rows were affected
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := op.Result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("error retrieving affected rows count: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no records were deleted")
+		return fmt.Errorf("no records were deleted: %w", core.ErrNotFound)
 	}
 
 	return nil
@@ -503,10 +864,28 @@ func (t *PostgresTransaction) Delete(ctx context.Context, model core.ModelWithPr
 
 // Query executes a custom SQL query within the transaction
 func (t *PostgresTransaction) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return t.tx.QueryContext(ctx, query, args...)
+	op := &core.Op{Kind: core.OpQuery, SQL: query, Args: args}
+	err := t.run.instrument(ctx, core.Operation{Name: "query", Statement: query}, func(ctx context.Context) error {
+		return t.run.intercept(ctx, op, queryHandler(t.run))
+	})
+	return op.Rows, err
 }
 
 // Exec executes a custom SQL command within the transaction
 func (t *PostgresTransaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return t.tx.ExecContext(ctx, query, args...)
-}
\ No newline at end of file
+	op := &core.Op{Kind: core.OpExec, SQL: query, Args: args}
+	err := t.run.instrument(ctx, core.Operation{Name: "exec", Statement: query}, func(ctx context.Context) error {
+		return t.run.intercept(ctx, op, execHandler(t.run))
+	})
+	return op.Result, err
+}
+
+// Preload loads the given relations within the transaction
+func (t *PostgresTransaction) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	return t.run.instrument(ctx, operation(t.mapper, "preload", dest), func(ctx context.Context) error {
+		if err := t.mapper.Preload(ctx, t.run, dest, relations...); err != nil {
+			return fmt.Errorf("error preloading relations: %w", err)
+		}
+		return nil
+	})
+}
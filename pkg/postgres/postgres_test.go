@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+type Invoice struct {
+	Number string `db:"number,primary"`
+	Total  int    `db:"total"`
+}
+
+type Token struct {
+	ID    string `db:"id"`
+	Owner string `db:"owner"`
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("StringPrimaryKey", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		orm := NewPostgresORMFromDB(rec.DB())
+
+		// A chave informada pelo modelo é inserida sem RETURNING
+		rec.ExpectExec(sqltest.Exact(`INSERT INTO "invoices" ("number", "total") VALUES ($1, $2)`)).WithArgs("A-1", 10)
+
+		invoice := &Invoice{Number: "A-1", Total: 10}
+		if err := orm.Create(ctx, invoice); err != nil {
+			t.Fatal(err)
+		}
+		if invoice.Number != "A-1" {
+			t.Errorf("Expected number A-1, got %s", invoice.Number)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("GeneratedStringKey", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		orm := NewPostgresORMFromDB(rec.DB())
+
+		// A chave gerada pelo banco é lida no tipo do campo
+		rec.ExpectQuery(sqltest.Exact(`INSERT INTO "tokens" ("owner") VALUES ($1) RETURNING "id"`)).
+			WithArgs("ana").
+			WillReturnRows(sqltest.NewRows("id").AddRow("6f1c2a"))
+
+		token := &Token{Owner: "ana"}
+		if err := orm.Create(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+		if token.ID != "6f1c2a" {
+			t.Errorf("Expected id 6f1c2a, got %s", token.ID)
+		}
+	})
+
+	t.Run("GeneratedIntegerKey", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		orm := NewPostgresORMFromDB(rec.DB())
+
+		rec.ExpectQuery(sqltest.Regexp(`^INSERT INTO "credentials" .* RETURNING "id"$`)).
+			WillReturnRows(sqltest.NewRows("id").AddRow(int64(7)))
+
+		credential := &Credential{Login: "ana"}
+		if err := orm.Create(ctx, credential); err != nil {
+			t.Fatal(err)
+		}
+		if credential.ID != 7 {
+			t.Errorf("Expected id 7, got %d", credential.ID)
+		}
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		rec.ExpectBegin()
+		tx, err := NewPostgresORMFromDB(rec.DB()).Transaction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A transação omite a chave zerada e lê a chave gerada, como o ORM
+		rec.ExpectQuery(sqltest.Exact(`INSERT INTO "roles" ("name") VALUES ($1) RETURNING "id"`)).
+			WithArgs("admin").
+			WillReturnRows(sqltest.NewRows("id").AddRow(int64(4)))
+		rec.ExpectCommit()
+
+		role := &Role{Name: "admin"}
+		if err := tx.Create(ctx, role); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if role.ID != 4 {
+			t.Errorf("Expected id 4, got %d", role.ID)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
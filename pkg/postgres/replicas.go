@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rodolfocoding/night-orm/pkg/core"
+)
+
+// defaultReplicaRetry is how long a replica that failed is skipped by default
+const defaultReplicaRetry = 30 * time.Second
+
+// replica is a read replica pool
+type replica struct {
+	db         *sql.DB
+	closeDB    bool
+	statements *stmtCache
+	downUntil  atomic.Int64 // UnixNano until which the replica is skipped
+}
+
+// replicaSet balances the reads over the healthy replicas in round robin
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	retry    time.Duration
+}
+
+// addReplica adds a replica pool to the set, creating the set when needed
+func (p *PostgresORM) addReplica(db *sql.DB, closeDB bool) *replica {
+	if p.replicas == nil {
+		retry := p.replicaRetry
+		if retry <= 0 {
+			retry = defaultReplicaRetry
+		}
+		p.replicas = &replicaSet{retry: retry}
+	}
+	r := &replica{db: db, closeDB: closeDB, statements: newStmtCache(db, p.stmtCacheSize)}
+	p.replicas.replicas = append(p.replicas.replicas, r)
+	return r
+}
+
+// connectReplicas opens the pools of the replicas given with WithReplicaDSNs. Replicas
+// that do not answer are kept, but skipped until they recover.
+func (p *PostgresORM) connectReplicas(ctx context.Context) error {
+	opened := make([]*sql.DB, 0, len(p.replicaDSNs))
+	for _, dsn := range p.replicaDSNs {
+		db, err := p.open(dsn)
+		if err != nil {
+			for _, db := range opened {
+				db.Close()
+			}
+			return fmt.Errorf("error opening replica: %w", err)
+		}
+		opened = append(opened, db)
+	}
+
+	for _, db := range opened {
+		r := p.addReplica(db, true)
+		if err := db.PingContext(ctx); err != nil {
+			p.replicas.markDown(r)
+		}
+	}
+	return nil
+}
+
+// pick returns the next healthy replica, or nil when none is healthy
+func (s *replicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	now := time.Now().UnixNano()
+	for i := uint64(0); i < n; i++ {
+		if r := s.replicas[(start+i)%n]; r.downUntil.Load() <= now {
+			return r
+		}
+	}
+	return nil
+}
+
+// markDown skips the replica until the retry interval elapses
+func (s *replicaSet) markDown(r *replica) {
+	r.downUntil.Store(time.Now().Add(s.retry).UnixNano())
+}
+
+// close closes the cached statements of the replicas and the pools opened by Connect
+func (s *replicaSet) close() error {
+	var errs []error
+	for _, r := range s.replicas {
+		if r.statements != nil {
+			r.statements.Close()
+		}
+		if r.closeDB {
+			errs = append(errs, r.db.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeOwned closes the replica pools opened by Connect and removes them from the set
+func (s *replicaSet) closeOwned() {
+	kept := s.replicas[:0]
+	for _, r := range s.replicas {
+		if !r.closeDB {
+			kept = append(kept, r)
+			continue
+		}
+		if r.statements != nil {
+			r.statements.Close()
+		}
+		r.db.Close()
+	}
+	s.replicas = kept
+}
+
+// CheckReplicas pings every replica, skipping the ones that fail until the retry
+// interval elapses and restoring the ones that answer. It returns the errors of the
+// failed replicas, so it can back a readiness probe.
+func (p *PostgresORM) CheckReplicas(ctx context.Context) error {
+	if p.replicas == nil {
+		return nil
+	}
+	var errs []error
+	for i, r := range p.replicas.replicas {
+		if err := r.db.PingContext(ctx); err != nil {
+			p.replicas.markDown(r)
+			errs = append(errs, fmt.Errorf("replica %d: %w", i, err))
+			continue
+		}
+		r.downUntil.Store(0)
+	}
+	return errors.Join(errs...)
+}
+
+// reader returns the runner of a read, sent to a healthy replica unless the context
+// carries core.WithPrimary or no replica is healthy
+func (p *PostgresORM) reader(ctx context.Context) runner {
+	run := p.runner(p.db)
+	if p.replicas == nil || core.PrimaryFromContext(ctx) {
+		return run
+	}
+	r := p.replicas.pick()
+	if r == nil {
+		return run
+	}
+
+	var q querier = r.db
+	if r.statements != nil {
+		q = r.statements.querier(q)
+	}
+	run.q = replicaQuerier{replicas: p.replicas, replica: r, q: q, primary: run.q}
+	return run
+}
+
+// sideEffects matches the calls of the functions that write or take locks, which a
+// SELECT can run but a replica cannot
+var sideEffects = regexp.MustCompile(`(?i)\b(nextval|setval|set_config|pg_notify|txid_current|pg_current_xact_id|pg_(try_)?advisory_\w*|lo_\w+)\s*\(`)
+
+// readOnly reports whether a custom query can run on a replica: a SELECT without a
+// locking clause, an INTO clause or a call of a function with side effects
+func readOnly(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) == 0 || fields[0] != "SELECT" || sideEffects.MatchString(query) {
+		return false
+	}
+	for i := 1; i < len(fields); i++ {
+		if fields[i] == "INTO" {
+			return false
+		}
+		if fields[i] == "FOR" && i+1 < len(fields) {
+			switch fields[i+1] {
+			case "UPDATE", "SHARE", "NO", "KEY":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// replicaQuerier runs the reads on a replica, falling back to the primary when the
+// replica cannot be reached
+type replicaQuerier struct {
+	replicas *replicaSet
+	replica  *replica
+	q        querier
+	primary  querier
+}
+
+// ExecContext implements querier
+func (r replicaQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if r.failover(ctx, err) {
+		return r.primary.ExecContext(ctx, query, args...)
+	}
+	return result, err
+}
+
+// QueryContext implements querier
+func (r replicaQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if r.failover(ctx, err) {
+		return r.primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+// QueryRowContext implements querier
+func (r replicaQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := r.q.QueryRowContext(ctx, query, args...)
+	if r.failover(ctx, row.Err()) {
+		return r.primary.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// failover marks the replica down and reports whether the read should run on the
+// primary, when err shows the replica cannot be reached
+func (r replicaQuerier) failover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || !unavailable(err) {
+		return false
+	}
+	r.replicas.markDown(r.replica)
+	return true
+}
+
+// unavailable reports whether err shows the server cannot be reached or is not
+// accepting queries, as opposed to an error of the query itself
+func unavailable(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 is connection_exception; 57P01 to 57P03 are the shutdown and
+		// cannot_connect_now errors of a restarting server
+		class := pqErr.Code.Class()
+		return class == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+// newReplicatedORM cria um ORM com um primário e duas réplicas gravados
+func newReplicatedORM(t *testing.T) (*PostgresORM, *sqltest.Recorder, []*sqltest.Recorder) {
+	t.Helper()
+	primary := sqltest.New()
+	replicas := []*sqltest.Recorder{sqltest.New(), sqltest.New()}
+	t.Cleanup(func() {
+		primary.Close()
+		for _, rec := range replicas {
+			rec.Close()
+		}
+	})
+	orm := NewPostgresORMFromDB(primary.DB(), WithReplicas(replicas[0].DB(), replicas[1].DB()))
+	return orm, primary, replicas
+}
+
+// credentialRows retorna uma linha de credencial
+func credentialRows(id int64) *sqltest.Rows {
+	return sqltest.NewRows("id", "login", "password").AddRow(id, "ana", "x")
+}
+
+func TestReplicas(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Routing", func(t *testing.T) {
+		orm, primary, replicas := newReplicatedORM(t)
+		for _, rec := range replicas {
+			rec.ExpectQuery(sqltest.Regexp(`^SELECT .* FROM "credentials"`)).WillReturnRows(credentialRows(1))
+			rec.ExpectQuery(sqltest.Exact(`SELECT count(*) FROM "credentials"`))
+		}
+		primary.ExpectQuery(sqltest.Exact(`SELECT count(*) FROM "credentials"`))
+		primary.ExpectQuery(sqltest.Exact(`SELECT nextval('credentials_id_seq')`))
+		primary.ExpectExec(sqltest.Regexp(`^UPDATE`))
+		primary.ExpectQuery(sqltest.Regexp(`FOR UPDATE$`))
+		primary.ExpectQuery(sqltest.Regexp(`^SELECT .* FROM "credentials"`)).WillReturnRows(credentialRows(1))
+		primary.ExpectBegin()
+		primary.ExpectQuery(sqltest.Regexp(`^SELECT`))
+		primary.ExpectRollback()
+
+		// As leituras se alternam entre as réplicas
+		for i := 0; i < 2; i++ {
+			if err := orm.FindByID(ctx, &Credential{}, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 2; i++ {
+			rows, err := orm.Query(core.WithReplica(ctx), `SELECT count(*) FROM "credentials"`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows.Close()
+		}
+
+		// As consultas personalizadas sem WithReplica, ou com efeitos colaterais, as
+		// escritas, as leituras com bloqueio, as leituras forçadas e as transações usam o
+		// primário
+		rows, err := orm.Query(ctx, `SELECT count(*) FROM "credentials"`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		rows, err = orm.Query(core.WithReplica(ctx), `SELECT nextval('credentials_id_seq')`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		if err := orm.Update(ctx, &Credential{ID: 1, Login: "bia"}); err != nil {
+			t.Fatal(err)
+		}
+		rows, err = orm.Query(core.WithReplica(ctx), `SELECT * FROM "credentials" WHERE "id" = $1 FOR UPDATE`, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		if err := orm.FindByID(core.WithPrimary(ctx), &Credential{}, 1); err != nil {
+			t.Fatal(err)
+		}
+		tx, err := orm.Transaction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err = tx.Query(ctx, "SELECT 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		tx.Rollback()
+
+		for i, rec := range append(replicas, primary) {
+			if err := rec.ExpectationsWereMet(); err != nil {
+				t.Errorf("Recorder %d: %v", i, err)
+			}
+		}
+	})
+
+	t.Run("Failover", func(t *testing.T) {
+		orm, primary, replicas := newReplicatedORM(t)
+		down := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+		// A réplica que falha é ignorada, e a leitura é repetida no primário
+		for _, rec := range replicas {
+			rec.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnError(down)
+		}
+		primary.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnRows(credentialRows(1))
+		primary.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnRows(credentialRows(2))
+		primary.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnRows(credentialRows(3))
+
+		for i := 0; i < 3; i++ {
+			if err := orm.FindByID(ctx, &Credential{}, i+1); err != nil {
+				t.Fatalf("Expected read to fall back to the primary, got %v", err)
+			}
+		}
+		for i, rec := range append(replicas, primary) {
+			if err := rec.ExpectationsWereMet(); err != nil {
+				t.Errorf("Recorder %d: %v", i, err)
+			}
+		}
+
+		// CheckReplicas restaura as réplicas que respondem
+		if err := orm.CheckReplicas(ctx); err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range replicas {
+			rec.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnRows(credentialRows(4))
+		}
+		for i := 0; i < 2; i++ {
+			if err := orm.FindByID(ctx, &Credential{}, 4); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i, rec := range replicas {
+			if err := rec.ExpectationsWereMet(); err != nil {
+				t.Errorf("Replica %d: %v", i, err)
+			}
+		}
+	})
+
+	t.Run("Connect", func(t *testing.T) {
+		primary, replica := sqltest.New(), sqltest.New()
+		defer primary.Close()
+		defer replica.Close()
+
+		// Connect abre os pools das réplicas informadas por DSN
+		orm := NewPostgresORM(WithDriverName(sqltest.DriverName), WithReplicaDSNs(replica.DataSourceName()))
+		if err := orm.Connect(ctx, primary.DataSourceName()); err != nil {
+			t.Fatal(err)
+		}
+		replica.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnRows(credentialRows(1))
+		if err := orm.FindByID(ctx, &Credential{}, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := replica.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		// Conectar de novo substitui as réplicas abertas pela conexão anterior
+		if err := orm.Connect(ctx, primary.DataSourceName()); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(orm.replicas.replicas); n != 1 {
+			t.Errorf("Expected 1 replica after reconnecting, got %d", n)
+		}
+		if err := orm.Close(); err != nil {
+			t.Errorf("Expected Close to close the replica pool, got %v", err)
+		}
+	})
+
+	t.Run("QueryErrors", func(t *testing.T) {
+		orm, primary, replicas := newReplicatedORM(t)
+
+		// Erros da própria consulta não são repetidos no primário
+		for _, rec := range replicas {
+			rec.ExpectQuery(sqltest.Regexp(`^SELECT`)).WillReturnError(errors.New("relation does not exist"))
+		}
+		if err := orm.FindByID(ctx, &Credential{}, 1); err == nil {
+			t.Error("Expected the replica error")
+		}
+		if statements := primary.Statements(); len(statements) != 0 {
+			t.Errorf("Expected no statements on the primary, got %v", statements)
+		}
+	})
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := map[string]bool{
+		`SELECT * FROM "users"`:                          true,
+		"  select id from users where name = 'for'":      true,
+		"SELECT * FROM users FOR UPDATE":                 false,
+		"SELECT * FROM users FOR NO KEY UPDATE":          false,
+		"select * from users for share":                  false,
+		"INSERT INTO users DEFAULT VALUES RETURNING id":  false,
+		"SELECT * INTO archived FROM users":              false,
+		"SELECT nextval('users_id_seq')":                 false,
+		"SELECT setval('users_id_seq', 10)":              false,
+		"SELECT pg_advisory_lock(1)":                     false,
+		"SELECT PG_ADVISORY_XACT_LOCK (1)":               false,
+		"SELECT pg_try_advisory_lock(1)":                 false,
+		"SELECT set_config('search_path', 'app', false)": false,
+		"SELECT pg_notify('jobs', 'run')":                false,
+		"WITH deleted AS (DELETE FROM users) SELECT 1":   false,
+		"": false,
+	}
+	for query, expected := range tests {
+		if got := readOnly(query); got != expected {
+			t.Errorf("Expected readOnly(%q) %v, got %v", query, expected, got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// runner runs the statements of the ORM or of a transaction, reporting each one to the
+// logger. It implements querier, so it can stand in for the pool or the transaction.
+type runner struct {
+	q               querier
+	logger          core.Logger
+	slowQuery       time.Duration
+	instrumentation core.Instrumentation
+	middleware      []core.Middleware
+}
+
+// runner returns the runner of the statements sent through q
+func (p *PostgresORM) runner(q querier) runner {
+	if p.statements != nil {
+		q = p.statements.querier(q)
+	}
+	return runner{q: q, logger: p.logger, slowQuery: p.slowQuery, instrumentation: p.instrumentation, middleware: p.middleware}
+}
+
+// ExecContext runs a statement that returns no rows
+func (r runner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.exec(ctx, query, args, nil)
+}
+
+// QueryContext runs a query that returns rows
+func (r runner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	r.log(ctx, query, args, nil, start, -1, err)
+	return rows, err
+}
+
+// QueryRowContext runs a query that returns at most one row
+func (r runner) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.queryRow(ctx, query, args, nil)
+}
+
+// exec runs a statement that returns no rows, redacting the arguments marked sensitive
+// in the logs
+func (r runner) exec(ctx context.Context, query string, args []interface{}, sensitive []bool) (sql.Result, error) {
+	start := time.Now()
+	result, err := r.q.ExecContext(ctx, query, args...)
+	rowsAffected := int64(-1)
+	if err == nil && r.logger != nil {
+		if n, err := result.RowsAffected(); err == nil {
+			rowsAffected = n
+		}
+	}
+	r.log(ctx, query, args, sensitive, start, rowsAffected, err)
+	return result, err
+}
+
+// queryRow runs a query that returns at most one row, redacting the arguments marked
+// sensitive in the logs
+func (r runner) queryRow(ctx context.Context, query string, args []interface{}, sensitive []bool) *sql.Row {
+	start := time.Now()
+	row := r.q.QueryRowContext(ctx, query, args...)
+	r.log(ctx, query, args, sensitive, start, -1, row.Err())
+	return row
+}
+
+// control runs a transaction control statement, such as COMMIT, reporting it like the
+// others. Rolling back a finished transaction is not reported, so deferred rollbacks
+// stay quiet.
+func (r runner) control(ctx context.Context, statement string, run func() error) error {
+	start := time.Now()
+	err := run()
+	if !(statement == "ROLLBACK" && txDone(err)) {
+		r.log(ctx, statement, nil, nil, start, -1, err)
+	}
+	return err
+}
+
+// log reports a statement to the span of the operation running it and to the logger
+func (r runner) log(ctx context.Context, query string, args []interface{}, sensitive []bool, start time.Time, rowsAffected int64, err error) {
+	if span, ok := ctx.Value(spanKey{}).(core.Span); ok {
+		span.SetStatement(query)
+	}
+	if r.logger == nil {
+		return
+	}
+	duration := time.Since(start)
+
+	logged := args
+	if sensitive != nil {
+		logged = append([]interface{}(nil), args...)
+		for i, redact := range sensitive {
+			if redact && i < len(logged) {
+				logged[i] = core.Redacted
+			}
+		}
+	}
+
+	r.logger.LogQuery(ctx, core.QueryEvent{
+		SQL:          query,
+		Args:         logged,
+		Duration:     duration,
+		RowsAffected: rowsAffected,
+		Err:          err,
+		Slow:         r.slowQuery > 0 && duration >= r.slowQuery,
+	})
+}
+
+// txDone reports whether err comes from rolling back a finished transaction
+func txDone(err error) bool {
+	return errors.Is(err, sql.ErrTxDone)
+}
+
+// sensitiveColumns marks the columns tagged sensitive among the given ones, or returns
+// nil when there are none
+func sensitiveColumns(mapper *utils.Mapper, model core.Model, columns []string) []bool {
+	var sensitive []bool
+	for _, field := range mapper.Fields(reflect.TypeOf(model)) {
+		if !field.IsSensitive() {
+			continue
+		}
+		for i, column := range columns {
+			if column == field.Column {
+				if sensitive == nil {
+					sensitive = make([]bool, len(columns))
+				}
+				sensitive[i] = true
+			}
+		}
+	}
+	return sensitive
+}
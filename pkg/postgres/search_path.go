@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// searchPathValue returns the schemas quoted and separated by commas, as expected by
+// SET search_path and the search_path connection parameter
+func searchPathValue(schemas []string) string {
+	quoted := make([]string, len(schemas))
+	for i, schema := range schemas {
+		quoted[i] = utils.QuoteIdentifier(schema)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// withSearchPath adds the search_path run-time parameter to a connection string
+func withSearchPath(connectionString string, schemas []string) (string, error) {
+	return withParameters(connectionString, []parameter{{"search_path", searchPathValue(schemas)}})
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parameter is a run-time parameter sent to the server when a connection opens
+type parameter struct {
+	name  string
+	value string
+}
+
+// withParameters adds run-time parameters to a connection string in URL or key=value
+// form; lib/pq sends unknown parameters to the server on connect
+func withParameters(connectionString string, parameters []parameter) (string, error) {
+	if len(parameters) == 0 {
+		return connectionString, nil
+	}
+
+	if strings.HasPrefix(connectionString, "postgres://") || strings.HasPrefix(connectionString, "postgresql://") {
+		u, err := url.Parse(connectionString)
+		if err != nil {
+			return "", err
+		}
+		query := u.Query()
+		for _, param := range parameters {
+			query.Set(param.name, param.value)
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, param := range parameters {
+		connectionString += " " + param.name + "='" + escape.Replace(param.value) + "'"
+	}
+	return strings.TrimSpace(connectionString), nil
+}
+
+// sessionConnector wraps a connector so each new connection sets the search_path and
+// session parameters of the options, which Connect sends in the connection string
+func (p *PostgresORM) sessionConnector(connector driver.Connector) driver.Connector {
+	parameters := p.session
+	if len(p.searchPath) > 0 {
+		parameters = append([]parameter{{"search_path", searchPathValue(p.searchPath)}}, parameters...)
+	}
+	if len(parameters) == 0 {
+		return connector
+	}
+	return settingConnector{Connector: connector, parameters: parameters}
+}
+
+// settingConnector sets run-time parameters on the connections it opens with
+// set_config, which parses the values like the connection parameters do
+type settingConnector struct {
+	driver.Connector
+	parameters []parameter
+}
+
+// setConfig is the statement run for each parameter of a settingConnector
+const setConfig = "SELECT set_config($1, $2, false)"
+
+// Connect implements driver.Connector
+func (c settingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, param := range c.parameters {
+		if err := setParameter(ctx, conn, param); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error setting %s: %w", param.name, err)
+		}
+	}
+	return conn, nil
+}
+
+// setParameter runs set_config for the parameter on the connection
+func setParameter(ctx context.Context, conn driver.Conn, param parameter) error {
+	args := []driver.NamedValue{{Ordinal: 1, Value: param.name}, {Ordinal: 2, Value: param.value}}
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, setConfig, args)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	stmt, err := conn.Prepare(setConfig)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if stmtExecer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = stmtExecer.ExecContext(ctx, args)
+		return err
+	}
+	_, err = stmt.Exec([]driver.Value{param.name, param.value})
+	return err
+}
+
+// configurePool applies the pool settings given as options
+func (p *PostgresORM) configurePool(db *sql.DB) {
+	for _, setting := range p.poolSettings {
+		setting(db)
+	}
+}
+
+// Stats returns the statistics of the connection pool, or zero values before Connect
+func (p *PostgresORM) Stats() sql.DBStats {
+	if p.db == nil {
+		return sql.DBStats{}
+	}
+	return p.db.Stats()
+}
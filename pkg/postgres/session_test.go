@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+func TestSessionParameters(t *testing.T) {
+	p := NewPostgresORM(
+		WithApplicationName("billing"),
+		WithStatementTimeout(30*time.Second),
+		WithTimezone("America/Sao_Paulo"),
+		WithSessionParameter("lock_timeout", "5s"),
+	)
+
+	tests := []struct {
+		name     string
+		dsn      string
+		expected string
+	}{
+		{
+			"URL",
+			"postgres://app@localhost/app?sslmode=disable",
+			"postgres://app@localhost/app?TimeZone=America%2FSao_Paulo&application_name=billing&lock_timeout=5s&sslmode=disable&statement_timeout=30000",
+		},
+		{
+			"KeyValue",
+			"host=localhost dbname=app",
+			"host=localhost dbname=app application_name='billing' statement_timeout='30000' TimeZone='America/Sao_Paulo' lock_timeout='5s'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withParameters(tt.dsn, p.session)
+			if err != nil {
+				t.Fatalf("withParameters returned error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	// Valores com aspas são escapados na forma chave=valor
+	got, _ := withParameters("dbname=app", []parameter{{"application_name", `it's`}})
+	if got != `dbname=app application_name='it\'s'` {
+		t.Errorf("Expected escaped value, got %s", got)
+	}
+}
+
+func TestSessionConnector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Connector", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Cada nova conexão define o search_path e os parâmetros com set_config
+		p := NewPostgresORMFromConnector(rec.Connector(),
+			WithSearchPath("billing", "public"),
+			WithApplicationName("billing"),
+		)
+		defer p.Close()
+		rec.ExpectExec(sqltest.Exact(setConfig)).WithArgs("search_path", `"billing", "public"`)
+		rec.ExpectExec(sqltest.Exact(setConfig)).WithArgs("application_name", "billing")
+		rec.ExpectExec(sqltest.Exact("VACUUM"))
+
+		if _, err := p.Exec(ctx, "VACUUM"); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ConnectorError", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		p := NewPostgresORMFromConnector(rec.Connector(), WithTimezone("Mars/Olympus"))
+		defer p.Close()
+		rec.ExpectExec(sqltest.Exact(setConfig)).WillReturnError(errors.New(`invalid value for parameter "TimeZone"`))
+
+		if _, err := p.Exec(ctx, "VACUUM"); err == nil || !strings.Contains(err.Error(), "error setting TimeZone") {
+			t.Errorf("Expected error setting TimeZone, got %v", err)
+		}
+	})
+
+	t.Run("SharedPool", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Os pools abertos pelo chamador não recebem os parâmetros, então a combinação
+		// é rejeitada por Connect e pelas operações
+		p := NewPostgresORMFromDB(rec.DB(), WithStatementTimeout(time.Second))
+		if _, err := p.Exec(ctx, "VACUUM"); !errors.Is(err, ErrCallerOwnedPool) {
+			t.Errorf("Expected ErrCallerOwnedPool, got %v", err)
+		}
+		if err := p.Create(ctx, &Role{Name: "admin"}); !errors.Is(err, ErrCallerOwnedPool) {
+			t.Errorf("Expected ErrCallerOwnedPool, got %v", err)
+		}
+
+		p = NewPostgresORM(WithSearchPath("billing"), WithReplicas(rec.DB()))
+		if err := p.Connect(ctx, "postgres://localhost/app"); !errors.Is(err, ErrCallerOwnedPool) {
+			t.Errorf("Expected ErrCallerOwnedPool, got %v", err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestPoolSettings(t *testing.T) {
+	rec := sqltest.New()
+	defer rec.Close()
+
+	p := NewPostgresORMFromConnector(rec.Connector(), WithMaxOpenConns(4), WithMaxIdleConns(0))
+	defer p.Close()
+	if stats := p.Stats(); stats.MaxOpenConnections != 4 {
+		t.Errorf("Expected MaxOpenConnections 4, got %d", stats.MaxOpenConnections)
+	}
+
+	if stats := NewPostgresORM().Stats(); stats.MaxOpenConnections != 0 || stats.OpenConnections != 0 {
+		t.Errorf("Expected zero stats before Connect, got %+v", stats)
+	}
+}
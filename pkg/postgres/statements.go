@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+)
+
+// stmtCache is a bounded LRU cache of the statements prepared on the pool, keyed by
+// their SQL. database/sql prepares each statement once per connection that runs it.
+type stmtCache struct {
+	db   *sql.DB
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *cachedStmt
+	order   *list.List               // most recently used first
+}
+
+// cachedStmt is a statement of the cache. It is closed once evicted and no longer in
+// use, so evictions never break a running call.
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// newStmtCache creates a cache of up to size statements, or returns nil when size is
+// not positive
+func newStmtCache(db *sql.DB, size int) *stmtCache {
+	if size <= 0 {
+		return nil
+	}
+	return &stmtCache{db: db, size: size, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// acquire returns the statement prepared for query, preparing it when missing and
+// prepare is set. It returns nil when the statement is missing or fails to prepare.
+func (c *stmtCache) acquire(ctx context.Context, query string, prepare bool) *cachedStmt {
+	c.mu.Lock()
+	if element, ok := c.entries[query]; ok {
+		c.order.MoveToFront(element)
+		entry := element.Value.(*cachedStmt)
+		entry.refs++
+		c.mu.Unlock()
+		return entry
+	}
+	c.mu.Unlock()
+	if !prepare {
+		return nil
+	}
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[query]; ok {
+		// Prepared concurrently by another call; keep the cached one
+		stmt.Close()
+		c.order.MoveToFront(element)
+		entry := element.Value.(*cachedStmt)
+		entry.refs++
+		return entry
+	}
+
+	entry := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.evict(c.order.Back())
+	}
+	return entry
+}
+
+// release ends a use of the statement, closing it if it was evicted meanwhile
+func (c *stmtCache) release(entry *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// invalidate evicts the statement, so the next call prepares it again
+func (c *stmtCache) invalidate(entry *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[entry.query]; ok && element.Value == entry {
+		c.evict(element)
+	}
+}
+
+// evict removes the element from the cache, closing its statement when no longer in
+// use; the caller holds c.mu
+func (c *stmtCache) evict(element *list.Element) {
+	entry := c.order.Remove(element).(*cachedStmt)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// Len returns the number of cached statements
+func (c *stmtCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Close evicts every statement
+func (c *stmtCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+// statement is implemented by *sql.Stmt and by unprepared
+type statement interface {
+	ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row
+}
+
+// unprepared runs a query without preparing it
+type unprepared struct {
+	q     querier
+	query string
+}
+
+func (u unprepared) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return u.q.ExecContext(ctx, u.query, args...)
+}
+
+func (u unprepared) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	return u.q.QueryContext(ctx, u.query, args...)
+}
+
+func (u unprepared) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
+	return u.q.QueryRowContext(ctx, u.query, args...)
+}
+
+// run calls fn with the statement prepared for query, bound to the transaction with
+// tx.StmtContext when q is one. Transactions only use the statements already cached,
+// so they never wait for a second connection to prepare one; missing statements, and
+// statements that fail to prepare, run unprepared, which reports their errors.
+//
+// A statement whose plan was invalidated by a schema change is evicted, and the call
+// is retried once with a new statement outside transactions, since the error aborts a
+// transaction.
+func (c *stmtCache) run(ctx context.Context, q querier, query string, fn func(stmt statement) error) error {
+	tx, inTx := q.(*sql.Tx)
+	for attempt := 0; ; attempt++ {
+		entry := c.acquire(ctx, query, !inTx)
+		if entry == nil {
+			return fn(unprepared{q: q, query: query})
+		}
+		var stmt statement = entry.stmt
+		if inTx {
+			// Closed with the transaction
+			stmt = tx.StmtContext(ctx, entry.stmt)
+		}
+		err := fn(stmt)
+		c.release(entry)
+
+		if err == nil || !staleStatement(err) {
+			return err
+		}
+		c.invalidate(entry)
+		if inTx || attempt > 0 {
+			return err
+		}
+	}
+}
+
+// staleStatement reports whether err comes from a prepared statement the server can no
+// longer run, such as one whose result type was changed by ALTER TABLE or one lost by
+// a connection pooler
+func staleStatement(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "cached plan must not change result type") ||
+		(strings.Contains(message, "prepared statement") && strings.Contains(message, "does not exist"))
+}
+
+// querier returns a querier running the statements with arguments of q through the
+// cache. Statements without arguments run unprepared, so scripts holding several
+// statements keep working.
+func (c *stmtCache) querier(q querier) querier {
+	return cachedQuerier{cache: c, q: q}
+}
+
+// cachedQuerier runs the statements through a stmtCache
+type cachedQuerier struct {
+	cache *stmtCache
+	q     querier
+}
+
+// ExecContext implements querier
+func (c cachedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if len(args) == 0 {
+		return c.q.ExecContext(ctx, query)
+	}
+	var result sql.Result
+	err := c.cache.run(ctx, c.q, query, func(stmt statement) (err error) {
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryContext implements querier
+func (c cachedQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if len(args) == 0 {
+		return c.q.QueryContext(ctx, query)
+	}
+	var rows *sql.Rows
+	err := c.cache.run(ctx, c.q, query, func(stmt statement) (err error) {
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext implements querier
+func (c cachedQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if len(args) == 0 {
+		return c.q.QueryRowContext(ctx, query)
+	}
+	var row *sql.Row
+	c.cache.run(ctx, c.q, query, func(stmt statement) error {
+		row = stmt.QueryRowContext(ctx, args...)
+		return row.Err()
+	})
+	return row
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+// prepared retorna o início das instruções preparadas pelo gravador
+func prepared(rec *sqltest.Recorder) []string {
+	var statements []string
+	for _, query := range rec.Prepared() {
+		statements = append(statements, strings.Fields(query)[0])
+	}
+	return statements
+}
+
+func TestStatementCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LRU", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		orm := NewPostgresORMFromDB(rec.DB(), WithStatementCache(2))
+
+		rec.ExpectQuery(sqltest.Regexp(`^INSERT`)).WillReturnRows(sqltest.NewRows("id").AddRow(1))
+		rec.ExpectQuery(sqltest.Regexp(`^INSERT`)).WillReturnRows(sqltest.NewRows("id").AddRow(2))
+		rec.ExpectExec(sqltest.Regexp(`^UPDATE`))
+		rec.ExpectExec(sqltest.Regexp(`^DELETE`))
+		rec.ExpectQuery(sqltest.Regexp(`^INSERT`)).WillReturnRows(sqltest.NewRows("id").AddRow(3))
+		rec.ExpectExec(sqltest.Exact("VACUUM"))
+
+		// A segunda inserção reutiliza a instrução; a exclusão descarta a inserção, a
+		// menos usada recentemente
+		orm.Create(ctx, &Credential{Login: "ana"})
+		orm.Create(ctx, &Credential{Login: "bia"})
+		orm.Update(ctx, &Credential{ID: 1, Login: "ana"})
+		orm.Delete(ctx, &Credential{ID: 2})
+		orm.Create(ctx, &Credential{Login: "caio"})
+		// Instruções sem argumentos não são preparadas
+		orm.Exec(ctx, "VACUUM")
+
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"INSERT", "UPDATE", "DELETE", "INSERT"}
+		if got := prepared(rec); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected prepared %v, got %v", expected, got)
+		}
+		if orm.statements.Len() != 2 {
+			t.Errorf("Expected 2 cached statements, got %d", orm.statements.Len())
+		}
+
+		orm.Close()
+		if orm.statements.Len() != 0 {
+			t.Errorf("Expected no cached statements after Close, got %d", orm.statements.Len())
+		}
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		first, second := sqltest.New(), sqltest.New()
+		defer first.Close()
+		defer second.Close()
+		orm := NewPostgresORM(WithDriverName(sqltest.DriverName), WithStatementCache(8))
+		if err := orm.Connect(ctx, first.DataSourceName()); err != nil {
+			t.Fatal(err)
+		}
+		first.ExpectExec(sqltest.Regexp(`^UPDATE`))
+		if err := orm.Update(ctx, &Credential{ID: 1, Login: "ana"}); err != nil {
+			t.Fatal(err)
+		}
+		previous, db := orm.statements, orm.DB()
+
+		// Conectar de novo fecha as instruções e o pool da conexão anterior
+		if err := orm.Connect(ctx, second.DataSourceName()); err != nil {
+			t.Fatal(err)
+		}
+		if previous.Len() != 0 {
+			t.Errorf("Expected the previous statements to be closed, got %d", previous.Len())
+		}
+		if err := db.PingContext(ctx); err == nil {
+			t.Error("Expected the previous pool to be closed")
+		}
+		second.ExpectExec(sqltest.Regexp(`^UPDATE`))
+		if err := orm.Update(ctx, &Credential{ID: 1, Login: "ana"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := second.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		orm.Close()
+	})
+
+	t.Run("Invalidation", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		orm := NewPostgresORMFromDB(rec.DB(), WithStatementCache(8))
+
+		// A alteração do esquema invalida a instrução, que é preparada de novo
+		stale := errors.New("pq: cached plan must not change result type")
+		rec.ExpectExec(sqltest.Regexp(`^UPDATE`)).WillReturnError(stale)
+		rec.ExpectExec(sqltest.Regexp(`^UPDATE`))
+
+		if err := orm.Update(ctx, &Credential{ID: 1, Login: "ana"}); err != nil {
+			t.Fatalf("Expected the update to be retried, got %v", err)
+		}
+		if got := prepared(rec); !reflect.DeepEqual(got, []string{"UPDATE", "UPDATE"}) {
+			t.Errorf("Expected UPDATE prepared twice, got %v", got)
+		}
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+		orm := NewPostgresORMFromDB(rec.DB(), WithStatementCache(8))
+
+		rec.ExpectExec(sqltest.Regexp(`^UPDATE`))
+		rec.ExpectBegin()
+		rec.ExpectExec(sqltest.Regexp(`^UPDATE`)).WillReturnError(errors.New("pq: cached plan must not change result type"))
+		rec.ExpectExec(sqltest.Regexp(`^INSERT`))
+		rec.ExpectRollback()
+
+		if err := orm.Update(ctx, &Credential{ID: 1, Login: "ana"}); err != nil {
+			t.Fatal(err)
+		}
+		tx, err := orm.Transaction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// O erro aborta a transação, então a instrução não é repetida
+		if err := tx.Update(ctx, &Credential{ID: 1, Login: "bia"}); err == nil {
+			t.Error("Expected error within the transaction")
+		}
+		if orm.statements.Len() != 0 {
+			t.Errorf("Expected the stale statement to be evicted, got %d cached", orm.statements.Len())
+		}
+		// Instruções ausentes do cache são executadas sem preparo na transação
+		tx.Create(ctx, &Credential{ID: 2, Login: "caio"})
+		tx.Rollback()
+
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+		if got := prepared(rec); !reflect.DeepEqual(got, []string{"UPDATE"}) {
+			t.Errorf("Expected only UPDATE prepared, got %v", got)
+		}
+	})
+}
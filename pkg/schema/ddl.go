@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// CreateTableSQL returns the statement creating the table with its columns and primary key
+func CreateTableSQL(t *Table) string {
+	definitions := make([]string, 0, len(t.Columns)+1)
+	for _, column := range t.Columns {
+		definitions = append(definitions, columnDefinition(column))
+	}
+	if primaryKey := t.PrimaryKey(); len(primaryKey) > 0 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteColumns(primaryKey)))
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n    %s\n)", t.QuotedName(), strings.Join(definitions, ",\n    "))
+}
+
+// DropTableSQL returns the statement dropping the table
+func DropTableSQL(t *Table) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s", t.QuotedName())
+}
+
+// AddColumnSQL returns the statement adding the column to the table
+func AddColumnSQL(t *Table, column Column) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", t.QuotedName(), columnDefinition(column))
+}
+
+// DropColumnSQL returns the statement dropping the column from the table
+func DropColumnSQL(t *Table, column string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", t.QuotedName(), utils.QuoteIdentifier(column))
+}
+
+// CreateIndexSQL returns the statement creating the index on the table
+func CreateIndexSQL(t *Table, index Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", unique, utils.QuoteIdentifier(index.Name), t.QuotedName(), quoteColumns(index.Columns))
+}
+
+// DropIndexSQL returns the statement dropping the index of the table
+func DropIndexSQL(t *Table, index string) string {
+	index = utils.QuoteIdentifier(index)
+	if t.Schema != "" {
+		index = utils.QuoteIdentifier(t.Schema) + "." + index
+	}
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s", index)
+}
+
+// columnDefinition returns the column definition used by CREATE TABLE and ADD COLUMN
+func columnDefinition(column Column) string {
+	definition := utils.QuoteIdentifier(column.Name) + " " + column.Type
+	if column.NotNull && !column.PrimaryKey {
+		definition += " NOT NULL"
+	}
+	if column.Default != "" {
+		definition += " DEFAULT " + column.Default
+	}
+	return definition
+}
+
+// quoteColumns returns the quoted column names separated by commas
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = utils.QuoteIdentifier(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// AlterColumnTypeSQL returns the statement changing the type of the column
+func AlterColumnTypeSQL(t *Table, column, sqlType string) string {
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", t.QuotedName(), utils.QuoteIdentifier(column), sqlType)
+}
+
+// SetNotNullSQL returns the statement adding or removing NOT NULL from the column
+func SetNotNullSQL(t *Table, column string, notNull bool) string {
+	action := "DROP"
+	if notNull {
+		action = "SET"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s NOT NULL", t.QuotedName(), utils.QuoteIdentifier(column), action)
+}
+
+// SetDefaultSQL returns the statement setting the default of the column; an empty
+// expression drops the default
+func SetDefaultSQL(t *Table, column, expression string) string {
+	if expression == "" {
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", t.QuotedName(), utils.QuoteIdentifier(column))
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", t.QuotedName(), utils.QuoteIdentifier(column), expression)
+}
+
+// AddForeignKeySQL returns the statement adding the foreign key constraint to the table
+func AddForeignKeySQL(t *Table, foreignKey ForeignKey) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		t.QuotedName(), utils.QuoteIdentifier(foreignKey.Name), quoteColumns(foreignKey.Columns),
+		utils.QuoteQualified(foreignKey.RefTable), quoteColumns(foreignKey.RefColumns))
+}
+
+// DropForeignKeySQL returns the statement dropping the foreign key constraint of the table
+func DropForeignKeySQL(t *Table, name string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s", t.QuotedName(), utils.QuoteIdentifier(name))
+}
@@ -0,0 +1,356 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// ChangeKind identifies a difference between a model and the database
+type ChangeKind string
+
+const (
+	CreateTable       ChangeKind = "create_table"
+	AddColumn         ChangeKind = "add_column"
+	DropColumn        ChangeKind = "drop_column"
+	AlterType         ChangeKind = "alter_type"
+	SetNotNull        ChangeKind = "set_not_null"
+	DropNotNull       ChangeKind = "drop_not_null"
+	SetDefault        ChangeKind = "set_default"
+	DropDefault       ChangeKind = "drop_default"
+	CreateIndex       ChangeKind = "create_index"
+	DropIndex         ChangeKind = "drop_index"
+	AddForeignKey     ChangeKind = "add_foreign_key"
+	DropForeignKey    ChangeKind = "drop_foreign_key"
+	ReplaceIndex      ChangeKind = "replace_index"
+	ReplaceForeignKey ChangeKind = "replace_foreign_key"
+)
+
+// Change is a single drift between a model and the database, with the statements
+// that fix it (Up) and undo the fix (Down)
+type Change struct {
+	// Kind is the kind of drift
+	Kind ChangeKind `json:"kind"`
+	// Table is the affected table
+	Table string `json:"table"`
+	// Column is the affected column, if any
+	Column string `json:"column,omitempty"`
+	// Name is the affected index or constraint, if any
+	Name string `json:"name,omitempty"`
+	// Expected is the definition declared by the model
+	Expected string `json:"expected,omitempty"`
+	// Actual is the definition found in the database
+	Actual string `json:"actual,omitempty"`
+	// Destructive reports whether applying the change may lose data
+	Destructive bool `json:"destructive"`
+	// Up are the statements bringing the database in line with the model
+	Up []string `json:"up"`
+	// Down are the statements reverting Up
+	Down []string `json:"down"`
+}
+
+// Report is the machine-readable result of comparing models with the database
+type Report struct {
+	// Changes are the drifts found, in the order their statements must run
+	Changes []Change `json:"changes"`
+}
+
+// HasDrift reports whether any difference was found
+func (r *Report) HasDrift() bool {
+	return len(r.Changes) > 0
+}
+
+// JSON returns the report encoded as indented JSON
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// UpSQL returns the migration applying every change
+func (r *Report) UpSQL() string {
+	statements := make([]string, 0, len(r.Changes))
+	for _, change := range r.Changes {
+		statements = append(statements, change.Up...)
+	}
+	return joinStatements(statements)
+}
+
+// DownSQL returns the migration reverting UpSQL, in reverse order
+func (r *Report) DownSQL() string {
+	statements := make([]string, 0, len(r.Changes))
+	for i := len(r.Changes) - 1; i >= 0; i-- {
+		statements = append(statements, r.Changes[i].Down...)
+	}
+	return joinStatements(statements)
+}
+
+// joinStatements formats the statements as a SQL script
+func joinStatements(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, ";\n\n") + ";\n"
+}
+
+// DiffModels compares the models with the tables found in the database, resolving
+// their names with utils.DefaultMapper
+func DiffModels(ctx context.Context, q utils.Querier, models ...interface{}) (*Report, error) {
+	return DiffModelsWith(ctx, utils.DefaultMapper, q, models...)
+}
+
+// DiffModelsWith compares the models with the tables found in the database like
+// DiffModels, resolving their names with the naming strategy and default schema of the
+// given mapper, such as the Mapper of the ORM
+func DiffModelsWith(ctx context.Context, mapper *utils.Mapper, q utils.Querier, models ...interface{}) (*Report, error) {
+	desired := make([]*Table, 0, len(models))
+	for _, model := range models {
+		table, err := FromModelWith(mapper, model)
+		if err != nil {
+			return nil, fmt.Errorf("error describing model %T: %w", model, err)
+		}
+		desired = append(desired, table)
+	}
+
+	current := make([]*Table, len(desired))
+	for i, table := range desired {
+		inspected, err := InspectTable(ctx, q, table.Schema, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		current[i] = inspected
+	}
+	return Diff(desired, current), nil
+}
+
+// Diff compares each desired table with the current table at the same position; a nil
+// current table means it does not exist. Changes are ordered so that tables and columns
+// are created before the indexes and foreign keys using them, and drops come last.
+func Diff(desired, current []*Table) *Report {
+	phases := make([][]Change, 4)
+	for i, table := range desired {
+		var existing *Table
+		if i < len(current) {
+			existing = current[i]
+		}
+		for _, change := range DiffTable(table, existing) {
+			phase := 0
+			switch change.Kind {
+			case CreateIndex, ReplaceIndex:
+				phase = 1
+			case AddForeignKey, ReplaceForeignKey:
+				phase = 2
+			case DropForeignKey, DropIndex, DropColumn:
+				phase = 3
+			}
+			phases[phase] = append(phases[phase], change)
+		}
+	}
+
+	report := &Report{Changes: make([]Change, 0)}
+	for _, changes := range phases {
+		report.Changes = append(report.Changes, changes...)
+	}
+	return report
+}
+
+// DiffTable returns the changes bringing the current table in line with the desired one
+func DiffTable(desired, current *Table) []Change {
+	name := desired.QualifiedName()
+	if current == nil {
+		changes := []Change{{
+			Kind:  CreateTable,
+			Table: name,
+			Up:    []string{CreateTableSQL(desired)},
+			Down:  []string{DropTableSQL(desired)},
+		}}
+		for _, index := range desired.Indexes {
+			changes = append(changes, createIndexChange(desired, index))
+		}
+		for _, foreignKey := range desired.ForeignKeys {
+			changes = append(changes, addForeignKeyChange(desired, foreignKey))
+		}
+		return changes
+	}
+
+	changes := make([]Change, 0)
+	for _, column := range desired.Columns {
+		existing, ok := current.Column(column.Name)
+		if !ok {
+			changes = append(changes, Change{
+				Kind:     AddColumn,
+				Table:    name,
+				Column:   column.Name,
+				Expected: columnDefinition(column),
+				Up:       []string{AddColumnSQL(desired, column)},
+				Down:     []string{DropColumnSQL(desired, column.Name)},
+			})
+			continue
+		}
+		changes = append(changes, diffColumn(desired, column, existing)...)
+	}
+
+	for _, column := range current.Columns {
+		if _, ok := desired.Column(column.Name); !ok {
+			changes = append(changes, Change{
+				Kind:        DropColumn,
+				Table:       name,
+				Column:      column.Name,
+				Actual:      columnDefinition(column),
+				Destructive: true,
+				Up:          []string{DropColumnSQL(desired, column.Name)},
+				Down:        []string{AddColumnSQL(desired, column)},
+			})
+		}
+	}
+
+	for _, index := range desired.Indexes {
+		existing, ok := current.Index(index.Name)
+		switch {
+		case !ok:
+			changes = append(changes, createIndexChange(desired, index))
+		case existing.Unique != index.Unique || !reflect.DeepEqual(existing.Columns, index.Columns):
+			changes = append(changes, Change{
+				Kind:     ReplaceIndex,
+				Table:    name,
+				Name:     index.Name,
+				Expected: CreateIndexSQL(desired, index),
+				Actual:   CreateIndexSQL(desired, existing),
+				Up:       []string{DropIndexSQL(desired, index.Name), CreateIndexSQL(desired, index)},
+				Down:     []string{DropIndexSQL(desired, index.Name), CreateIndexSQL(desired, existing)},
+			})
+		}
+	}
+	for _, index := range current.Indexes {
+		if _, ok := desired.Index(index.Name); !ok {
+			changes = append(changes, Change{
+				Kind:   DropIndex,
+				Table:  name,
+				Name:   index.Name,
+				Actual: CreateIndexSQL(desired, index),
+				Up:     []string{DropIndexSQL(desired, index.Name)},
+				Down:   []string{CreateIndexSQL(desired, index)},
+			})
+		}
+	}
+
+	for _, foreignKey := range desired.ForeignKeys {
+		existing, ok := current.ForeignKey(foreignKey.Name)
+		switch {
+		case !ok:
+			changes = append(changes, addForeignKeyChange(desired, foreignKey))
+		case !sameForeignKey(existing, foreignKey):
+			changes = append(changes, Change{
+				Kind:     ReplaceForeignKey,
+				Table:    name,
+				Name:     foreignKey.Name,
+				Expected: AddForeignKeySQL(desired, foreignKey),
+				Actual:   AddForeignKeySQL(desired, existing),
+				Up:       []string{DropForeignKeySQL(desired, foreignKey.Name), AddForeignKeySQL(desired, foreignKey)},
+				Down:     []string{DropForeignKeySQL(desired, foreignKey.Name), AddForeignKeySQL(desired, existing)},
+			})
+		}
+	}
+	for _, foreignKey := range current.ForeignKeys {
+		if _, ok := desired.ForeignKey(foreignKey.Name); !ok {
+			changes = append(changes, Change{
+				Kind:   DropForeignKey,
+				Table:  name,
+				Name:   foreignKey.Name,
+				Actual: AddForeignKeySQL(desired, foreignKey),
+				Up:     []string{DropForeignKeySQL(desired, foreignKey.Name)},
+				Down:   []string{AddForeignKeySQL(desired, foreignKey)},
+			})
+		}
+	}
+
+	return changes
+}
+
+// diffColumn compares the type, nullability and default of an existing column
+func diffColumn(table *Table, desired, current Column) []Change {
+	name := table.QualifiedName()
+	changes := make([]Change, 0)
+
+	if NormalizeType(desired.Type) != NormalizeType(current.Type) {
+		changes = append(changes, Change{
+			Kind:        AlterType,
+			Table:       name,
+			Column:      desired.Name,
+			Expected:    desired.Type,
+			Actual:      current.Type,
+			Destructive: true,
+			Up:          []string{AlterColumnTypeSQL(table, desired.Name, NormalizeType(desired.Type))},
+			Down:        []string{AlterColumnTypeSQL(table, desired.Name, current.Type)},
+		})
+	}
+
+	if desired.NotNull != current.NotNull && !desired.PrimaryKey {
+		kind := DropNotNull
+		if desired.NotNull {
+			kind = SetNotNull
+		}
+		changes = append(changes, Change{
+			Kind:     kind,
+			Table:    name,
+			Column:   desired.Name,
+			Expected: fmt.Sprint(desired.NotNull),
+			Actual:   fmt.Sprint(current.NotNull),
+			Up:       []string{SetNotNullSQL(table, desired.Name, desired.NotNull)},
+			Down:     []string{SetNotNullSQL(table, desired.Name, current.NotNull)},
+		})
+	}
+
+	// Serial columns get their nextval() default from the database
+	serial := isSerial(desired.Type) && strings.HasPrefix(current.Default, "nextval(")
+	if !serial && NormalizeDefault(desired.Default) != NormalizeDefault(current.Default) {
+		kind := SetDefault
+		if desired.Default == "" {
+			kind = DropDefault
+		}
+		changes = append(changes, Change{
+			Kind:     kind,
+			Table:    name,
+			Column:   desired.Name,
+			Expected: desired.Default,
+			Actual:   current.Default,
+			Up:       []string{SetDefaultSQL(table, desired.Name, desired.Default)},
+			Down:     []string{SetDefaultSQL(table, desired.Name, current.Default)},
+		})
+	}
+
+	return changes
+}
+
+// createIndexChange returns the change creating a missing index
+func createIndexChange(table *Table, index Index) Change {
+	return Change{
+		Kind:     CreateIndex,
+		Table:    table.QualifiedName(),
+		Name:     index.Name,
+		Expected: CreateIndexSQL(table, index),
+		Up:       []string{CreateIndexSQL(table, index)},
+		Down:     []string{DropIndexSQL(table, index.Name)},
+	}
+}
+
+// addForeignKeyChange returns the change adding a missing foreign key
+func addForeignKeyChange(table *Table, foreignKey ForeignKey) Change {
+	return Change{
+		Kind:     AddForeignKey,
+		Table:    table.QualifiedName(),
+		Name:     foreignKey.Name,
+		Expected: AddForeignKeySQL(table, foreignKey),
+		Up:       []string{AddForeignKeySQL(table, foreignKey)},
+		Down:     []string{DropForeignKeySQL(table, foreignKey.Name)},
+	}
+}
+
+// sameForeignKey reports whether two foreign keys reference the same columns
+func sameForeignKey(a, b ForeignKey) bool {
+	return a.RefTable == b.RefTable &&
+		reflect.DeepEqual(a.Columns, b.Columns) &&
+		reflect.DeepEqual(a.RefColumns, b.RefColumns)
+}
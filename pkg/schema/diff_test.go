@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+func TestDiffTable(t *testing.T) {
+	desired, err := FromModel(&Account{})
+	if err != nil {
+		t.Fatalf("FromModel returned error: %v", err)
+	}
+
+	t.Run("NoDrift", func(t *testing.T) {
+		// The table as reported by PostgreSQL, with aliases, casts and the serial default
+		current := &Table{
+			Name: "accounts",
+			Columns: []Column{
+				{Name: "id", Type: "bigint", NotNull: true, Default: "nextval('accounts_id_seq'::regclass)", PrimaryKey: true},
+				{Name: "email", Type: "character varying(255)", NotNull: true},
+				{Name: "balance", Type: "numeric(12,2)", Default: "0"},
+				{Name: "tenant", Type: "text"},
+				{Name: "created_at", Type: "timestamptz", NotNull: true, Default: "now()"},
+				{Name: "tags", Type: "text[]"},
+				{Name: "nickname", Type: "text"},
+			},
+			Indexes: desired.Indexes,
+		}
+		report := Diff([]*Table{desired}, []*Table{current})
+		if report.HasDrift() {
+			t.Errorf("Expected no drift, got %+v", report.Changes)
+		}
+		if report.UpSQL() != "" || report.DownSQL() != "" {
+			t.Errorf("Expected empty migration, got %q / %q", report.UpSQL(), report.DownSQL())
+		}
+	})
+
+	t.Run("Drift", func(t *testing.T) {
+		current := &Table{
+			Name: "accounts",
+			Columns: []Column{
+				{Name: "id", Type: "bigint", NotNull: true, PrimaryKey: true},
+				{Name: "email", Type: "text"},
+				{Name: "balance", Type: "numeric(12,2)", Default: "1"},
+				{Name: "tenant", Type: "text"},
+				{Name: "created_at", Type: "timestamp with time zone", NotNull: true, Default: "now()"},
+				{Name: "tags", Type: "text[]"},
+				{Name: "legacy", Type: "integer"},
+			},
+			Indexes: []Index{
+				{Name: "accounts_email_key", Columns: []string{"email"}, Unique: true},
+				{Name: "accounts_legacy_idx", Columns: []string{"legacy"}},
+			},
+		}
+		report := Diff([]*Table{desired}, []*Table{current})
+
+		kinds := make([]string, 0, len(report.Changes))
+		for _, change := range report.Changes {
+			kinds = append(kinds, string(change.Kind)+":"+change.Column+change.Name)
+		}
+		expected := []string{
+			"alter_type:email",
+			"set_not_null:email",
+			"set_default:balance",
+			"add_column:nickname",
+			"create_index:accounts_tenant_created_idx",
+			"drop_column:legacy",
+			"drop_index:accounts_legacy_idx",
+		}
+		if strings.Join(kinds, " ") != strings.Join(expected, " ") {
+			t.Errorf("Expected changes %v, got %v", expected, kinds)
+		}
+
+		up := report.UpSQL()
+		if !strings.Contains(up, `ALTER TABLE "accounts" ALTER COLUMN "email" TYPE character varying(255);`) {
+			t.Errorf("Expected type change in up migration, got:\n%s", up)
+		}
+		if !strings.Contains(up, `ALTER TABLE "accounts" DROP COLUMN IF EXISTS "legacy";`) {
+			t.Errorf("Expected dropped column in up migration, got:\n%s", up)
+		}
+
+		down := report.DownSQL()
+		if !strings.HasPrefix(down, `CREATE INDEX IF NOT EXISTS "accounts_legacy_idx" ON "accounts" ("legacy");`) {
+			t.Errorf("Expected down migration to start by reverting the last change, got:\n%s", down)
+		}
+		if !strings.Contains(down, `ALTER TABLE "accounts" ALTER COLUMN "email" TYPE text;`) {
+			t.Errorf("Expected type revert in down migration, got:\n%s", down)
+		}
+
+		data, err := report.JSON()
+		if err != nil {
+			t.Fatalf("JSON returned error: %v", err)
+		}
+		var decoded Report
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Invalid JSON report: %v", err)
+		}
+		if len(decoded.Changes) != len(report.Changes) || !decoded.Changes[5].Destructive {
+			t.Errorf("Expected destructive drop_column in JSON report, got %s", data)
+		}
+	})
+
+	t.Run("MissingTable", func(t *testing.T) {
+		report := Diff([]*Table{desired}, []*Table{nil})
+		if len(report.Changes) != 3 || report.Changes[0].Kind != CreateTable {
+			t.Fatalf("Expected create_table and two indexes, got %+v", report.Changes)
+		}
+		if !strings.HasSuffix(report.DownSQL(), "DROP TABLE IF EXISTS \"accounts\";\n") {
+			t.Errorf("Expected table drop at the end of down migration, got:\n%s", report.DownSQL())
+		}
+	})
+}
+
+type Ledger struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+func TestDiffModelsWith(t *testing.T) {
+	rec := sqltest.New()
+	defer rec.Close()
+
+	// The table is inspected under the prefix and default schema of the mapper
+	mapper := utils.NewMapper(utils.SnakeCaseNaming{TablePrefix: "app_"}).WithSchema("billing")
+	rec.ExpectQuery(sqltest.Regexp(`FROM information_schema\.columns`)).
+		WithArgs("billing", "app_ledgers").
+		WillReturnRows(sqltest.NewRows("column_name", "data_type", "udt_name", "character_maximum_length",
+			"numeric_precision", "numeric_scale", "nullable", "column_default"))
+
+	report, err := DiffModelsWith(context.Background(), mapper, rec.DB(), &Ledger{})
+	if err != nil {
+		t.Fatalf("DiffModelsWith returned error: %v", err)
+	}
+	if len(report.Changes) == 0 || report.Changes[0].Kind != CreateTable {
+		t.Fatalf("Expected the table to be created, got %+v", report.Changes)
+	}
+	if up := report.UpSQL(); !strings.Contains(up, `"billing"."app_ledgers"`) {
+		t.Errorf("Expected the qualified table in the migration, got %s", up)
+	}
+	if err := rec.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	types := map[string]string{
+		"varchar(80)":           "character varying(80)",
+		"INT4":                  "integer",
+		"bigserial":             "bigint",
+		"timestamptz":           "timestamp with time zone",
+		"numeric(12, 2)":        "numeric(12,2)",
+		"int[]":                 "integer[]",
+		"double   precision":    "double precision",
+		"character varying(80)": "character varying(80)",
+	}
+	for input, expected := range types {
+		if got := NormalizeType(input); got != expected {
+			t.Errorf("Expected NormalizeType(%q) = %q, got %q", input, expected, got)
+		}
+	}
+
+	defaults := map[string]string{
+		"'active'::text":                         "'active'",
+		"('x'::character varying)":               "'x'",
+		"0":                                      "0",
+		"(0)":                                    "0",
+		"now()":                                  "now()",
+		"'{}'::text[]":                           "'{}'",
+		"'2020-01-01'::timestamp with time zone": "'2020-01-01'",
+	}
+	for input, expected := range defaults {
+		if got := NormalizeDefault(input); got != expected {
+			t.Errorf("Expected NormalizeDefault(%q) = %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(&Account{}, Account{})
+	if models := Models(); len(models) != 1 {
+		t.Errorf("Expected 1 registered model, got %d", len(models))
+	}
+}
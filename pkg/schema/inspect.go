@@ -0,0 +1,218 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// columnsQuery lists the columns of a table from information_schema
+const columnsQuery = `SELECT c.column_name, c.data_type, c.udt_name, c.character_maximum_length,
+       c.numeric_precision, c.numeric_scale, c.is_nullable = 'YES', COALESCE(c.column_default, '')
+FROM information_schema.columns c
+WHERE c.table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND c.table_name = $2
+ORDER BY c.ordinal_position`
+
+// primaryKeyQuery lists the primary key columns of a table from information_schema
+const primaryKeyQuery = `SELECT k.column_name
+FROM information_schema.table_constraints t
+JOIN information_schema.key_column_usage k
+  ON k.constraint_schema = t.constraint_schema AND k.constraint_name = t.constraint_name
+WHERE t.constraint_type = 'PRIMARY KEY'
+  AND t.table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND t.table_name = $2
+ORDER BY k.ordinal_position`
+
+// indexesQuery lists the secondary indexes of a table; information_schema does not expose indexes
+const indexesQuery = `SELECT i.relname, ix.indisunique, array_agg(a.attname ORDER BY k.ord)
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND t.relname = $2 AND NOT ix.indisprimary
+GROUP BY i.relname, ix.indisunique
+ORDER BY i.relname`
+
+// foreignKeysQuery lists the foreign keys of a table with their columns in order
+const foreignKeysQuery = `SELECT con.conname,
+       array(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+             JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.ord),
+       CASE WHEN rn.nspname = n.nspname THEN rc.relname ELSE rn.nspname || '.' || rc.relname END,
+       array(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+             JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.ord)
+FROM pg_constraint con
+JOIN pg_class c ON c.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_class rc ON rc.oid = con.confrelid
+JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+WHERE con.contype = 'f' AND n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND c.relname = $2
+ORDER BY con.conname`
+
+// tablesQuery lists the base tables of a schema
+const tablesQuery = `SELECT table_name
+FROM information_schema.tables
+WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_type = 'BASE TABLE'
+ORDER BY table_name`
+
+// InspectTables returns the names of the tables of the given schema; an empty schema
+// means the current schema
+func InspectTables(ctx context.Context, q utils.Querier, schemaName string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, tablesQuery, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("error scanning table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// InspectTable reads the description of a table from the database.
+// It returns nil without error when the table does not exist.
+func InspectTable(ctx context.Context, q utils.Querier, schemaName, tableName string) (*Table, error) {
+	table := &Table{Schema: schemaName, Name: tableName}
+
+	rows, err := q.QueryContext(ctx, columnsQuery, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting columns of %s: %w", table.QualifiedName(), err)
+	}
+	for rows.Next() {
+		var (
+			column                   Column
+			dataType, udtName        string
+			length, precision, scale sql.NullInt64
+			nullable                 bool
+		)
+		if err := rows.Scan(&column.Name, &dataType, &udtName, &length, &precision, &scale, &nullable, &column.Default); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning column of %s: %w", table.QualifiedName(), err)
+		}
+		column.Type = formatType(dataType, udtName, length, precision, scale)
+		column.NotNull = !nullable
+		table.Columns = append(table.Columns, column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(table.Columns) == 0 {
+		return nil, nil
+	}
+
+	primaryKey, err := queryStrings(ctx, q, primaryKeyQuery, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting primary key of %s: %w", table.QualifiedName(), err)
+	}
+	for _, name := range primaryKey {
+		for i := range table.Columns {
+			if table.Columns[i].Name == name {
+				table.Columns[i].PrimaryKey = true
+			}
+		}
+	}
+
+	rows, err = q.QueryContext(ctx, indexesQuery, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting indexes of %s: %w", table.QualifiedName(), err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var index Index
+		var columns pq.StringArray
+		if err := rows.Scan(&index.Name, &index.Unique, &columns); err != nil {
+			return nil, fmt.Errorf("error scanning index of %s: %w", table.QualifiedName(), err)
+		}
+		index.Columns = columns
+		table.Indexes = append(table.Indexes, index)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, foreignKeysQuery, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting foreign keys of %s: %w", table.QualifiedName(), err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var foreignKey ForeignKey
+		var columns, refColumns pq.StringArray
+		if err := rows.Scan(&foreignKey.Name, &columns, &foreignKey.RefTable, &refColumns); err != nil {
+			return nil, fmt.Errorf("error scanning foreign key of %s: %w", table.QualifiedName(), err)
+		}
+		foreignKey.Columns = columns
+		foreignKey.RefColumns = refColumns
+		table.ForeignKeys = append(table.ForeignKeys, foreignKey)
+	}
+	return table, rows.Err()
+}
+
+// queryStrings runs a query returning a single text column
+func queryStrings(ctx context.Context, q utils.Querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// udtNames maps the internal PostgreSQL type names to their SQL names
+var udtNames = map[string]string{
+	"int2":        "smallint",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"bpchar":      "character",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+	"timetz":      "time with time zone",
+}
+
+// formatType rebuilds the full SQL type of a column from its information_schema fields
+func formatType(dataType, udtName string, length, precision, scale sql.NullInt64) string {
+	switch dataType {
+	case "ARRAY":
+		element := strings.TrimPrefix(udtName, "_")
+		if name, ok := udtNames[element]; ok {
+			element = name
+		}
+		return element + "[]"
+	case "USER-DEFINED":
+		return udtName
+	case "character varying", "character":
+		if length.Valid {
+			return fmt.Sprintf("%s(%d)", dataType, length.Int64)
+		}
+	case "numeric":
+		if precision.Valid && scale.Valid {
+			return fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+	}
+	return dataType
+}
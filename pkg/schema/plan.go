@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMissingDefault is returned by Plan for a NOT NULL column missing from an existing
+// table and declared without a default, which PostgreSQL cannot add to a table holding
+// rows
+var ErrMissingDefault = errors.New("NOT NULL column added without a default")
+
+// PlanOptions controls the destructive statements produced by Plan
+type PlanOptions struct {
+	// DropColumns drops the columns that exist in the database but not in the model
+	DropColumns bool
+	// DropIndexes drops the indexes that exist in the database but not in the model
+	DropIndexes bool
+}
+
+// Plan returns the statements that bring the current table to the desired description.
+// Missing tables are created and missing columns and indexes are added; nothing is
+// dropped unless requested in the options. A nil current table means it does not exist.
+// Missing NOT NULL columns need a default=<expression> in their tag, which fills the
+// existing rows; otherwise Plan returns ErrMissingDefault.
+func Plan(desired, current *Table, opts PlanOptions) ([]string, error) {
+	statements := make([]string, 0)
+	if current == nil {
+		statements = append(statements, CreateTableSQL(desired))
+		for _, index := range desired.Indexes {
+			statements = append(statements, CreateIndexSQL(desired, index))
+		}
+		return statements, nil
+	}
+
+	for _, column := range desired.Columns {
+		if _, ok := current.Column(column.Name); ok {
+			continue
+		}
+		if column.NotNull && !column.PrimaryKey && column.Default == "" {
+			return nil, fmt.Errorf("%w: %s.%s", ErrMissingDefault, desired.QualifiedName(), column.Name)
+		}
+		statements = append(statements, AddColumnSQL(desired, column))
+	}
+	for _, index := range desired.Indexes {
+		if _, ok := current.Index(index.Name); !ok {
+			statements = append(statements, CreateIndexSQL(desired, index))
+		}
+	}
+
+	if opts.DropIndexes {
+		for _, index := range current.Indexes {
+			if _, ok := desired.Index(index.Name); !ok {
+				statements = append(statements, DropIndexSQL(desired, index.Name))
+			}
+		}
+	}
+	if opts.DropColumns {
+		for _, column := range current.Columns {
+			if _, ok := desired.Column(column.Name); !ok {
+				statements = append(statements, DropColumnSQL(desired, column.Name))
+			}
+		}
+	}
+	return statements, nil
+}
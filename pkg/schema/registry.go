@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   []interface{}
+)
+
+// Register records models whose tables are compared with the database by tools
+// such as the schema diff. Registering the same model type twice has no effect.
+func Register(models ...interface{}) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, model := range models {
+		if !registered(model) {
+			registry = append(registry, model)
+		}
+	}
+}
+
+// Models returns the registered models in registration order
+func Models() []interface{} {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	models := make([]interface{}, len(registry))
+	copy(models, registry)
+	return models
+}
+
+// registered reports whether a model of the same type was already registered
+func registered(model interface{}) bool {
+	for _, existing := range registry {
+		if sameType(existing, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameType reports whether both models have the same struct type, ignoring pointers
+func sameType(a, b interface{}) bool {
+	return elemType(reflect.TypeOf(a)) == elemType(reflect.TypeOf(b))
+}
+
+// elemType removes the pointer indirections of a type
+func elemType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
@@ -0,0 +1,228 @@
+// Package schema describes database tables, builds those descriptions from model
+// struct tags and reads them back from a live PostgreSQL database.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// Table describes a database table
+type Table struct {
+	// Schema is the schema the table belongs to; empty means the current schema
+	Schema string
+	// Name is the table name
+	Name string
+	// Columns are the table columns in declaration order
+	Columns []Column
+	// Indexes are the secondary indexes of the table, excluding the primary key
+	Indexes []Index
+	// ForeignKeys are the foreign key constraints of the table
+	ForeignKeys []ForeignKey
+}
+
+// Column describes a table column
+type Column struct {
+	// Name is the column name
+	Name string
+	// Type is the SQL type of the column
+	Type string
+	// NotNull reports whether the column rejects NULL values
+	NotNull bool
+	// Default is the SQL expression used as default value, if any
+	Default string
+	// PrimaryKey reports whether the column is part of the primary key
+	PrimaryKey bool
+}
+
+// Index describes a table index
+type Index struct {
+	// Name is the index name
+	Name string
+	// Columns are the indexed columns in order
+	Columns []string
+	// Unique reports whether the index enforces uniqueness
+	Unique bool
+}
+
+// ForeignKey describes a foreign key constraint
+type ForeignKey struct {
+	// Name is the constraint name
+	Name string
+	// Columns are the referencing columns
+	Columns []string
+	// RefTable is the referenced table, qualified by its schema when it differs
+	RefTable string
+	// RefColumns are the referenced columns
+	RefColumns []string
+}
+
+// Column returns the column with the given name
+func (t *Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Index returns the index with the given name
+func (t *Table) Index(name string) (Index, bool) {
+	for _, index := range t.Indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return Index{}, false
+}
+
+// ForeignKey returns the foreign key constraint with the given name
+func (t *Table) ForeignKey(name string) (ForeignKey, bool) {
+	for _, foreignKey := range t.ForeignKeys {
+		if foreignKey.Name == name {
+			return foreignKey, true
+		}
+	}
+	return ForeignKey{}, false
+}
+
+// PrimaryKey returns the primary key columns
+func (t *Table) PrimaryKey() []string {
+	columns := make([]string, 0, 1)
+	for _, column := range t.Columns {
+		if column.PrimaryKey {
+			columns = append(columns, column.Name)
+		}
+	}
+	return columns
+}
+
+// QualifiedName returns the table name prefixed by its schema, when set
+func (t *Table) QualifiedName() string {
+	return utils.QualifiedName(t.Schema, t.Name)
+}
+
+// QuotedName returns the qualified table name with each part quoted for use in SQL
+func (t *Table) QuotedName() string {
+	if t.Schema == "" {
+		return utils.QuoteIdentifier(t.Name)
+	}
+	return utils.QuoteIdentifier(t.Schema) + "." + utils.QuoteIdentifier(t.Name)
+}
+
+// FromModel builds the table description of a model from its struct tags.
+// The "db" tag accepts the options type=<sql type>, notnull, default=<expression>,
+// unique[=<index name>] and index[=<index name>]; fields sharing an index name
+// produce a composite index. Each belongs_to relation produces a foreign key.
+func FromModel(model interface{}) (*Table, error) {
+	return FromModelWith(utils.DefaultMapper, model)
+}
+
+// FromModelWith builds the table description of a model like FromModel, resolving
+// table names with the naming strategy of the given mapper
+func FromModelWith(mapper *utils.Mapper, model interface{}) (*Table, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model cannot be nil")
+	}
+	typ := reflect.TypeOf(model)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct or a pointer to a struct")
+	}
+
+	schemaName, name, err := mapper.TableSchema(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	primaryKey, err := mapper.PrimaryKeyOf(typ)
+	if err != nil {
+		primaryKey = ""
+	}
+
+	table := &Table{Schema: schemaName, Name: name}
+	indexes := make(map[string]*Index)
+	indexOrder := make([]string, 0)
+	addIndex := func(name string, column string, unique bool) {
+		index, ok := indexes[name]
+		if !ok {
+			index = &Index{Name: name, Unique: unique}
+			indexes[name] = index
+			indexOrder = append(indexOrder, name)
+		}
+		index.Columns = append(index.Columns, column)
+	}
+
+	for _, field := range mapper.Fields(typ) {
+		isPrimary := field.Column == primaryKey
+		column := Column{
+			Name:       field.Column,
+			Type:       field.Options.Get("type"),
+			NotNull:    isPrimary || field.Options.Has("notnull"),
+			Default:    field.Options.Get("default"),
+			PrimaryKey: isPrimary,
+		}
+		if column.Type == "" {
+			column.Type = SQLType(field.Type)
+			if isPrimary && column.Default == "" {
+				column.Type = serialType(column.Type)
+			}
+		}
+		table.Columns = append(table.Columns, column)
+
+		if field.Options.Has("unique") {
+			addIndex(indexName(name, field.Options.Get("unique"), field.Column, "key"), field.Column, true)
+		}
+		if field.Options.Has("index") {
+			addIndex(indexName(name, field.Options.Get("index"), field.Column, "idx"), field.Column, false)
+		}
+	}
+
+	for _, indexName := range indexOrder {
+		table.Indexes = append(table.Indexes, *indexes[indexName])
+	}
+
+	relations, err := utils.GetRelations(typ)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if relation.Kind != utils.BelongsTo {
+			continue
+		}
+		ownerColumn, targetColumn, err := mapper.KeyColumns(relation, typ)
+		if err != nil {
+			return nil, err
+		}
+		refSchema, refTable, err := mapper.TableSchema(relation.Target)
+		if err != nil {
+			return nil, err
+		}
+		if refSchema != schemaName {
+			refTable = utils.QualifiedName(refSchema, refTable)
+		}
+		table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+			Name:       indexName(name, "", ownerColumn, "fkey"),
+			Columns:    []string{ownerColumn},
+			RefTable:   refTable,
+			RefColumns: []string{targetColumn},
+		})
+	}
+	return table, nil
+}
+
+// indexName returns the explicit index name or the PostgreSQL-style default
+// "<table>_<column>_<suffix>"
+func indexName(table, explicit, column, suffix string) string {
+	if explicit != "" {
+		return explicit
+	}
+	table = table[strings.LastIndex(table, ".")+1:]
+	return fmt.Sprintf("%s_%s_%s", table, column, suffix)
+}
@@ -0,0 +1,226 @@
+package schema
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+type Account struct {
+	ID        int64     `db:"id,primary"`
+	Email     string    `db:"email,type=varchar(255),notnull,unique"`
+	Balance   float64   `db:"balance,type=numeric(12,2),default=0"`
+	Tenant    string    `db:"tenant,index=accounts_tenant_created_idx"`
+	CreatedAt time.Time `db:"created_at,notnull,default=now(),index=accounts_tenant_created_idx"`
+	Tags      []string  `db:"tags"`
+	Nickname  sql.NullString
+}
+
+func (a *Account) TableName() string { return "accounts" }
+
+func TestFromModel(t *testing.T) {
+	table, err := FromModel(&Account{})
+	if err != nil {
+		t.Fatalf("FromModel returned error: %v", err)
+	}
+
+	expected := []Column{
+		{Name: "id", Type: "bigserial", NotNull: true, PrimaryKey: true},
+		{Name: "email", Type: "varchar(255)", NotNull: true},
+		{Name: "balance", Type: "numeric(12,2)", Default: "0"},
+		{Name: "tenant", Type: "text"},
+		{Name: "created_at", Type: "timestamp with time zone", NotNull: true, Default: "now()"},
+		{Name: "tags", Type: "text[]"},
+		{Name: "nickname", Type: "text"},
+	}
+	if !reflect.DeepEqual(table.Columns, expected) {
+		t.Errorf("Expected columns %+v, got %+v", expected, table.Columns)
+	}
+
+	expectedIndexes := []Index{
+		{Name: "accounts_email_key", Columns: []string{"email"}, Unique: true},
+		{Name: "accounts_tenant_created_idx", Columns: []string{"tenant", "created_at"}},
+	}
+	if !reflect.DeepEqual(table.Indexes, expectedIndexes) {
+		t.Errorf("Expected indexes %+v, got %+v", expectedIndexes, table.Indexes)
+	}
+}
+
+type Invoice struct {
+	_         struct{} `schema:"billing"`
+	ID        int64    `db:"id,primary"`
+	AccountID int64    `db:"account_id"`
+	Account   *Account `rel:"belongs_to,fk=account_id"`
+}
+
+type Payment struct {
+	_         struct{} `table:"payments" schema:"billing"`
+	ID        int64    `db:"id,primary"`
+	InvoiceID int64    `db:"invoice_id"`
+	Invoice   *Invoice `rel:"belongs_to,fk=invoice_id"`
+}
+
+func TestFromModelSchema(t *testing.T) {
+	table, err := FromModelWith(utils.DefaultMapper.WithSchema("app"), &Invoice{})
+	if err != nil {
+		t.Fatalf("FromModelWith returned error: %v", err)
+	}
+	if table.Schema != "billing" || table.Name != "invoices" {
+		t.Errorf("Expected table billing.invoices, got %s", table.QualifiedName())
+	}
+
+	// Tables of another schema are referenced by their qualified name
+	if len(table.ForeignKeys) != 1 || table.ForeignKeys[0].RefTable != "app.accounts" {
+		t.Fatalf("Expected foreign key to app.accounts, got %+v", table.ForeignKeys)
+	}
+	expected := `ALTER TABLE "billing"."invoices" ADD CONSTRAINT "invoices_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "app"."accounts" ("id")`
+	if statement := AddForeignKeySQL(table, table.ForeignKeys[0]); statement != expected {
+		t.Errorf("Expected statement:\n%s\ngot:\n%s", expected, statement)
+	}
+	if statement := DropIndexSQL(table, "invoices_idx"); statement != `DROP INDEX IF EXISTS "billing"."invoices_idx"` {
+		t.Errorf("Unexpected drop index statement: %s", statement)
+	}
+
+	// Tables of the same schema are referenced without the schema, as introspection reports them
+	payments, err := FromModel(&Payment{})
+	if err != nil {
+		t.Fatalf("FromModel returned error: %v", err)
+	}
+	if len(payments.ForeignKeys) != 1 || payments.ForeignKeys[0].RefTable != "invoices" {
+		t.Errorf("Expected foreign key to invoices, got %+v", payments.ForeignKeys)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	desired, err := FromModel(&Account{})
+	if err != nil {
+		t.Fatalf("FromModel returned error: %v", err)
+	}
+
+	t.Run("MissingTable", func(t *testing.T) {
+		statements, err := Plan(desired, nil, PlanOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statements) != 3 {
+			t.Fatalf("Expected 3 statements, got %d: %v", len(statements), statements)
+		}
+		expected := `CREATE TABLE IF NOT EXISTS "accounts" (
+    "id" bigserial,
+    "email" varchar(255) NOT NULL,
+    "balance" numeric(12,2) DEFAULT 0,
+    "tenant" text,
+    "created_at" timestamp with time zone NOT NULL DEFAULT now(),
+    "tags" text[],
+    "nickname" text,
+    PRIMARY KEY ("id")
+)`
+		if statements[0] != expected {
+			t.Errorf("Expected statement:\n%s\ngot:\n%s", expected, statements[0])
+		}
+		if statements[1] != `CREATE UNIQUE INDEX IF NOT EXISTS "accounts_email_key" ON "accounts" ("email")` {
+			t.Errorf("Unexpected index statement: %s", statements[1])
+		}
+	})
+
+	t.Run("ExistingTable", func(t *testing.T) {
+		current := &Table{
+			Name: "accounts",
+			Columns: []Column{
+				{Name: "id", Type: "bigint", NotNull: true, PrimaryKey: true},
+				{Name: "email", Type: "character varying(255)", NotNull: true},
+				{Name: "legacy", Type: "text"},
+			},
+			Indexes: []Index{{Name: "accounts_email_key", Columns: []string{"email"}, Unique: true}},
+		}
+
+		statements, err := Plan(desired, current, PlanOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{
+			`ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "balance" numeric(12,2) DEFAULT 0`,
+			`ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "tenant" text`,
+			`ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "created_at" timestamp with time zone NOT NULL DEFAULT now()`,
+			`ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "tags" text[]`,
+			`ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "nickname" text`,
+			`CREATE INDEX IF NOT EXISTS "accounts_tenant_created_idx" ON "accounts" ("tenant", "created_at")`,
+		}
+		if !reflect.DeepEqual(statements, expected) {
+			t.Errorf("Expected statements %v, got %v", expected, statements)
+		}
+
+		// Columns are only dropped when requested
+		statements, _ = Plan(desired, current, PlanOptions{DropColumns: true})
+		if last := statements[len(statements)-1]; last != `ALTER TABLE "accounts" DROP COLUMN IF EXISTS "legacy"` {
+			t.Errorf("Expected last statement to drop the legacy column, got %s", last)
+		}
+	})
+
+	t.Run("NotNullWithoutDefault", func(t *testing.T) {
+		// A NOT NULL column without a default cannot be added to a table holding rows
+		current := &Table{
+			Name:    "accounts",
+			Columns: []Column{{Name: "id", Type: "bigint", NotNull: true, PrimaryKey: true}},
+		}
+		if _, err := Plan(desired, current, PlanOptions{}); !errors.Is(err, ErrMissingDefault) {
+			t.Errorf("Expected ErrMissingDefault for the email column, got %v", err)
+		}
+	})
+}
+
+// Money is a value type stored by its driver.Valuer implementation
+type Money struct {
+	cents int64
+}
+
+func (m Money) Value() (driver.Value, error) { return m.cents, nil }
+
+func TestSQLType(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{sql.NullInt32{}, "integer"},
+		{sql.Null[int64]{}, "bigint"},
+		{sql.Null[time.Time]{}, "timestamp with time zone"},
+		{struct {
+			Time  time.Time
+			Valid bool
+		}{}, "timestamp with time zone"},
+		{Money{}, "text"},
+		{struct{ Street string }{}, "jsonb"},
+		{map[string]int{}, "jsonb"},
+		{[]int32{}, "integer[]"},
+		{[]byte{}, "bytea"},
+	}
+	for _, test := range tests {
+		if got := SQLType(reflect.TypeOf(test.value)); got != test.expected {
+			t.Errorf("Expected %T to map to %s, got %s", test.value, test.expected, got)
+		}
+	}
+}
+
+func TestFormatType(t *testing.T) {
+	tests := []struct {
+		dataType, udtName        string
+		length, precision, scale sql.NullInt64
+		expected                 string
+	}{
+		{dataType: "integer", udtName: "int4", expected: "integer"},
+		{dataType: "ARRAY", udtName: "_int8", expected: "bigint[]"},
+		{dataType: "character varying", udtName: "varchar", length: sql.NullInt64{Int64: 80, Valid: true}, expected: "character varying(80)"},
+		{dataType: "numeric", udtName: "numeric", precision: sql.NullInt64{Int64: 10, Valid: true}, scale: sql.NullInt64{Int64: 2, Valid: true}, expected: "numeric(10,2)"},
+		{dataType: "USER-DEFINED", udtName: "citext", expected: "citext"},
+	}
+	for _, test := range tests {
+		if got := formatType(test.dataType, test.udtName, test.length, test.precision, test.scale); got != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package schema
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	valuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// nullTypes maps the database/sql null wrappers to their PostgreSQL types
+var nullTypes = map[reflect.Type]string{
+	reflect.TypeOf(sql.NullString{}):  "text",
+	reflect.TypeOf(sql.NullInt64{}):   "bigint",
+	reflect.TypeOf(sql.NullInt32{}):   "integer",
+	reflect.TypeOf(sql.NullInt16{}):   "smallint",
+	reflect.TypeOf(sql.NullByte{}):    "smallint",
+	reflect.TypeOf(sql.NullFloat64{}): "double precision",
+	reflect.TypeOf(sql.NullBool{}):    "boolean",
+	reflect.TypeOf(sql.NullTime{}):    "timestamp with time zone",
+}
+
+// SQLType returns the PostgreSQL type used to store values of the given Go type.
+// Null wrappers such as sql.Null[T] or pq.NullTime, structs with a Valid field and a
+// value field, map to the type of their value; other structs implementing
+// driver.Valuer map to text, since their database type is unknown, and the remaining
+// structs and maps to jsonb. Use the type= tag option for a different type.
+func SQLType(typ reflect.Type) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if sqlType, ok := nullTypes[typ]; ok {
+		return sqlType
+	}
+
+	switch typ {
+	case timeType:
+		return "timestamp with time zone"
+	case rawMessageType:
+		return "jsonb"
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint"
+	case reflect.Int32, reflect.Uint16:
+		return "integer"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "bigint"
+	case reflect.Float32:
+		return "real"
+	case reflect.Float64:
+		return "double precision"
+	case reflect.String:
+		return "text"
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "bytea"
+		}
+		return SQLType(typ.Elem()) + "[]"
+	case reflect.Struct:
+		if value, ok := nullValue(typ); ok {
+			return SQLType(value)
+		}
+		if typ.Implements(valuerType) || reflect.PointerTo(typ).Implements(valuerType) {
+			return "text"
+		}
+		return "jsonb"
+	case reflect.Map:
+		return "jsonb"
+	}
+	return "text"
+}
+
+// nullValue returns the type of the value held by a null wrapper: a struct with a
+// Valid bool field and one other field, as sql.Null[T] and pq.NullTime
+func nullValue(typ reflect.Type) (reflect.Type, bool) {
+	if typ.NumField() != 2 {
+		return nil, false
+	}
+	for i := 0; i < 2; i++ {
+		valid, value := typ.Field(i), typ.Field(1-i)
+		if valid.Name == "Valid" && valid.Type.Kind() == reflect.Bool && value.IsExported() {
+			return value.Type, true
+		}
+	}
+	return nil, false
+}
+
+// serialType returns the auto-incrementing variant of an integer type
+func serialType(sqlType string) string {
+	switch sqlType {
+	case "smallint":
+		return "smallserial"
+	case "integer":
+		return "serial"
+	case "bigint":
+		return "bigserial"
+	}
+	return sqlType
+}
+
+// typeAliases maps PostgreSQL type aliases to the names reported by the database
+var typeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"bool":        "boolean",
+	"float8":      "double precision",
+	"float":       "double precision",
+	"float4":      "real",
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"decimal":     "numeric",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+}
+
+// NormalizeType returns the canonical spelling of a PostgreSQL type, so that aliases
+// such as varchar(80) and character varying(80) compare equal
+func NormalizeType(sqlType string) string {
+	sqlType = strings.Join(strings.Fields(strings.ToLower(sqlType)), " ")
+
+	if strings.HasSuffix(sqlType, "[]") {
+		return NormalizeType(strings.TrimSuffix(sqlType, "[]")) + "[]"
+	}
+
+	base, modifier := sqlType, ""
+	if i := strings.Index(sqlType, "("); i >= 0 {
+		base, modifier = strings.TrimSpace(sqlType[:i]), strings.ReplaceAll(sqlType[i:], " ", "")
+	}
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	return base + modifier
+}
+
+// isSerial reports whether the type is one of the auto-incrementing integer types
+func isSerial(sqlType string) bool {
+	switch strings.ToLower(strings.TrimSpace(sqlType)) {
+	case "smallserial", "serial", "bigserial", "serial2", "serial4", "serial8":
+		return true
+	}
+	return false
+}
+
+// castSuffix matches the type cast PostgreSQL appends to literal defaults, as in 'x'::text
+var castSuffix = regexp.MustCompile(`::[a-z ]+(\(\d+(,\d+)?\))?(\[\])?$`)
+
+// NormalizeDefault returns the canonical spelling of a default expression, removing
+// the casts and parentheses PostgreSQL adds when storing it
+func NormalizeDefault(expression string) string {
+	expression = strings.TrimSpace(expression)
+	for {
+		trimmed := castSuffix.ReplaceAllString(expression, "")
+		if strings.HasPrefix(trimmed, "(") && strings.HasSuffix(trimmed, ")") && !strings.Contains(trimmed[1:len(trimmed)-1], ")") {
+			trimmed = trimmed[1 : len(trimmed)-1]
+		}
+		if trimmed == expression {
+			break
+		}
+		expression = trimmed
+	}
+	return strings.ToLower(expression)
+}
@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// TimeLayout is the layout of the time values written by the ORM. SQLite has no date
+// type; values are stored as UTC text that sorts chronologically and is understood by
+// the SQLite date and time functions.
+const TimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// timeLayouts are the layouts accepted when reading a time value stored as text
+var timeLayouts = []string{
+	TimeLayout,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// SQLiteDialect is the SQLite dialect: numbered ?NNN placeholders, double quotes, RETURNING and
+// ON CONFLICT. It implements utils.TypeConverter, storing booleans as 0/1 integers and
+// times as text in TimeLayout.
+type SQLiteDialect struct {
+	// NoReturning disables RETURNING, for SQLite releases older than 3.35
+	NoReturning bool
+}
+
+// Name returns "sqlite"
+func (SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+// Placeholder returns "?index"; numbered placeholders let a statement reuse a parameter
+func (SQLiteDialect) Placeholder(index int) string {
+	return "?" + strconv.Itoa(index)
+}
+
+// QuoteIdentifier quotes the identifier with double quotes
+func (SQLiteDialect) QuoteIdentifier(name string) string {
+	return utils.QuoteIdentifier(name)
+}
+
+// Returning reports whether INSERT supports RETURNING
+func (d SQLiteDialect) Returning() bool {
+	return !d.NoReturning
+}
+
+// Upsert returns "ON CONFLICT (...) DO UPDATE SET column = excluded.column" or
+// "ON CONFLICT (...) DO NOTHING"
+func (d SQLiteDialect) Upsert(conflict, update []string) string {
+	return utils.ConflictUpsert(d, conflict, update, "excluded")
+}
+
+// Pagination returns "LIMIT n OFFSET m", writing LIMIT -1 when only an offset is given
+func (SQLiteDialect) Pagination(limit, offset int) string {
+	return utils.LimitOffset(limit, offset, "-1")
+}
+
+// BindValue converts booleans to 0/1 and times to UTC text in TimeLayout
+func (SQLiteDialect) BindValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	case time.Time:
+		return formatTime(v)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return formatTime(*v)
+	case sql.NullTime:
+		if !v.Valid {
+			return nil
+		}
+		return formatTime(v.Time)
+	}
+	return value
+}
+
+// ScanDestination wraps time destinations so they accept the text, integer and time
+// values SQLite returns depending on the column type affinity. Booleans need no
+// wrapping: database/sql already converts 0/1 integers.
+func (SQLiteDialect) ScanDestination(dest interface{}) interface{} {
+	switch d := dest.(type) {
+	case *time.Time:
+		return &timeScanner{dest: d}
+	case **time.Time:
+		return &nullTimeScanner{dest: d}
+	case *sql.NullTime:
+		return &sqlNullTimeScanner{dest: d}
+	}
+	return dest
+}
+
+// formatTime formats a time in UTC with TimeLayout
+func formatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// parseTime converts a value read from SQLite into a time. Integers are Unix seconds.
+func parseTime(src interface{}) (time.Time, error) {
+	switch v := src.(type) {
+	case time.Time:
+		return v, nil
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Second))).UTC(), nil
+	case []byte:
+		return parseTimeText(string(v))
+	case string:
+		return parseTimeText(v)
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", src)
+}
+
+// parseTimeText parses a time stored as text with any of the accepted layouts
+func parseTimeText(text string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	if seconds, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time.Time", text)
+}
+
+// timeScanner scans a SQLite value into a time.Time field
+type timeScanner struct {
+	dest *time.Time
+}
+
+// Scan implements sql.Scanner; NULL leaves the zero time
+func (s *timeScanner) Scan(src interface{}) error {
+	if src == nil {
+		*s.dest = time.Time{}
+		return nil
+	}
+	t, err := parseTime(src)
+	if err != nil {
+		return err
+	}
+	*s.dest = t
+	return nil
+}
+
+// nullTimeScanner scans a SQLite value into a *time.Time field
+type nullTimeScanner struct {
+	dest **time.Time
+}
+
+// Scan implements sql.Scanner; NULL sets the field to nil
+func (s *nullTimeScanner) Scan(src interface{}) error {
+	if src == nil {
+		*s.dest = nil
+		return nil
+	}
+	t, err := parseTime(src)
+	if err != nil {
+		return err
+	}
+	*s.dest = &t
+	return nil
+}
+
+// sqlNullTimeScanner scans a SQLite value into a sql.NullTime field
+type sqlNullTimeScanner struct {
+	dest *sql.NullTime
+}
+
+// Scan implements sql.Scanner; NULL sets Valid to false
+func (s *sqlNullTimeScanner) Scan(src interface{}) error {
+	if src == nil {
+		*s.dest = sql.NullTime{}
+		return nil
+	}
+	t, err := parseTime(src)
+	if err != nil {
+		return err
+	}
+	*s.dest = sql.NullTime{Time: t, Valid: true}
+	return nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// errorCodes maps SQLite extended result codes to the ORM typed errors
+var errorCodes = map[int]error{
+	sqlite3.SQLITE_CONSTRAINT_UNIQUE:     core.ErrDuplicate,
+	sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY: core.ErrDuplicate,
+	sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY: core.ErrForeignKey,
+	sqlite3.SQLITE_CONSTRAINT_NOTNULL:    core.ErrNotNull,
+	sqlite3.SQLITE_BUSY:                  core.ErrDeadlock,
+	sqlite3.SQLITE_LOCKED:                core.ErrDeadlock,
+}
+
+// errorMessages recognizes the errors of other SQLite drivers by their message
+var errorMessages = map[string]error{
+	"UNIQUE constraint failed":      core.ErrDuplicate,
+	"FOREIGN KEY constraint failed": core.ErrForeignKey,
+	"NOT NULL constraint failed":    core.ErrNotNull,
+}
+
+// translateError wraps driver errors with the matching ORM typed error, keeping the
+// original error in the chain
+func translateError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		if typed, ok := errorCodes[sqliteErr.Code()]; ok {
+			return fmt.Errorf("%w: %w", typed, err)
+		}
+		return err
+	}
+	for message, typed := range errorMessages {
+		if strings.Contains(err.Error(), message) {
+			return fmt.Errorf("%w: %w", typed, err)
+		}
+	}
+	return err
+}
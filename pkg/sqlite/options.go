@@ -0,0 +1,31 @@
+package sqlite
+
+import "github.com/rodolfocoding/night-orm/pkg/utils"
+
+// Option configures a SQLiteORM
+type Option func(*SQLiteORM)
+
+// WithNamingStrategy sets the strategy deriving the table, column and join table
+// names that models do not declare explicitly
+func WithNamingStrategy(naming utils.NamingStrategy) Option {
+	return func(s *SQLiteORM) {
+		s.naming = naming
+	}
+}
+
+// WithDriverName sets the database/sql driver used by Connect. The default is "sqlite",
+// registered by the pure-Go modernc.org/sqlite driver; any driver speaking SQLite works,
+// as long as the program imports it.
+func WithDriverName(name string) Option {
+	return func(s *SQLiteORM) {
+		s.driverName = name
+	}
+}
+
+// WithoutReturning makes Create read generated keys with sql.Result.LastInsertId instead
+// of RETURNING, for drivers linking a SQLite release older than 3.35
+func WithoutReturning() Option {
+	return func(s *SQLiteORM) {
+		s.dialect.NoReturning = true
+	}
+}
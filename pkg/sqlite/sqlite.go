@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/internal/sqlbase"
+	"github.com/rodolfocoding/night-orm/pkg/utils"
+)
+
+// SQLiteORM is the SQLite ORM implementation
+type SQLiteORM struct {
+	db         *sql.DB
+	mapper     *utils.Mapper
+	backend    *sqlbase.Backend
+	naming     utils.NamingStrategy
+	dialect    SQLiteDialect
+	driverName string
+}
+
+// NewSQLiteORM creates a new instance of the SQLite ORM
+func NewSQLiteORM(opts ...Option) *SQLiteORM {
+	s := &SQLiteORM{driverName: "sqlite"}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mapper = utils.NewMapper(s.naming).WithDialect(s.dialect)
+	s.backend = &sqlbase.Backend{Mapper: s.mapper, TranslateError: translateError, DefaultValues: "DEFAULT VALUES"}
+	return s
+}
+
+// Connect creates a SQLite ORM with the given options and opens the database at the
+// data source, such as "app.db" or ":memory:"
+func Connect(ctx context.Context, dataSource string, opts ...Option) (*SQLiteORM, error) {
+	orm := NewSQLiteORM(opts...)
+	if err := orm.Connect(ctx, dataSource); err != nil {
+		return nil, err
+	}
+	return orm, nil
+}
+
+// Mapper returns the mapper resolving table names and primary keys of the models
+func (s *SQLiteORM) Mapper() *utils.Mapper {
+	return s.mapper
+}
+
+// Dialect returns the SQLite dialect used to build the queries
+func (s *SQLiteORM) Dialect() utils.Dialect {
+	return s.mapper.Dialect()
+}
+
+// Connect opens the SQLite database at the given data source, such as "app.db",
+// "file:app.db?_pragma=foreign_keys(1)" or ":memory:". In-memory databases are private
+// to each connection, so the pool is limited to a single connection.
+func (s *SQLiteORM) Connect(ctx context.Context, dataSource string) error {
+	db, err := sql.Open(s.driverName, dataSource)
+	if err != nil {
+		return fmt.Errorf("error opening SQLite database: %w", err)
+	}
+	if isMemory(dataSource) {
+		db.SetMaxOpenConns(1)
+	}
+
+	// Test the connection
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("error pinging SQLite database: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// isMemory reports whether the data source names an in-memory database
+func isMemory(dataSource string) bool {
+	return dataSource == "" || strings.Contains(dataSource, ":memory:") || strings.Contains(dataSource, "mode=memory")
+}
+
+// Close closes the database connection
+func (s *SQLiteORM) Close() error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.db.Close()
+}
+
+// DB returns the underlying database connection
+func (s *SQLiteORM) DB() *sql.DB {
+	return s.db
+}
+
+// Create inserts a new record into the database, setting the generated primary key
+func (s *SQLiteORM) Create(ctx context.Context, model core.Model) error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.backend.Create(ctx, s.db, model)
+}
+
+// FindByID retrieves a record by ID
+func (s *SQLiteORM) FindByID(ctx context.Context, model core.Model, id interface{}) error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.backend.FindByID(ctx, s.db, model, id)
+}
+
+// FindAll retrieves all records of a model
+func (s *SQLiteORM) FindAll(ctx context.Context, model core.Model, dest interface{}) error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.backend.FindAll(ctx, s.db, model, dest)
+}
+
+// Update updates an existing record
+func (s *SQLiteORM) Update(ctx context.Context, model core.Model) error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.backend.Update(ctx, s.db, model)
+}
+
+// Delete removes a record from the database
+func (s *SQLiteORM) Delete(ctx context.Context, model core.Model) error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.backend.Delete(ctx, s.db, model)
+}
+
+// Query executes a custom SQL query
+func (s *SQLiteORM) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if s.db == nil {
+		return nil, sqlbase.ErrNotConnected
+	}
+	return s.db.QueryContext(ctx, query, args...)
+}
+
+// Exec executes a custom SQL command
+func (s *SQLiteORM) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if s.db == nil {
+		return nil, sqlbase.ErrNotConnected
+	}
+	return s.db.ExecContext(ctx, query, args...)
+}
+
+// Preload loads the given relations into an already loaded model or slice of models
+func (s *SQLiteORM) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	if s.db == nil {
+		return sqlbase.ErrNotConnected
+	}
+	return s.backend.Preload(ctx, s.db, dest, relations...)
+}
+
+// Transaction starts a new transaction
+func (s *SQLiteORM) Transaction(ctx context.Context) (core.Transaction, error) {
+	if s.db == nil {
+		return nil, sqlbase.ErrNotConnected
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	return &SQLiteTransaction{tx: tx, orm: s}, nil
+}
+
+// SQLiteTransaction is the SQLite transaction implementation
+type SQLiteTransaction struct {
+	tx  *sql.Tx
+	orm *SQLiteORM
+}
+
+// Commit commits the transaction
+func (t *SQLiteTransaction) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback rolls back the transaction
+func (t *SQLiteTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Create inserts a new record within the transaction, setting the generated primary key
+func (t *SQLiteTransaction) Create(ctx context.Context, model core.Model) error {
+	return t.orm.backend.Create(ctx, t.tx, model)
+}
+
+// Update updates a record within the transaction
+func (t *SQLiteTransaction) Update(ctx context.Context, model core.Model) error {
+	return t.orm.backend.Update(ctx, t.tx, model)
+}
+
+// Delete removes a record within the transaction
+func (t *SQLiteTransaction) Delete(ctx context.Context, model core.Model) error {
+	return t.orm.backend.Delete(ctx, t.tx, model)
+}
+
+// Query executes a custom SQL query within the transaction
+func (t *SQLiteTransaction) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// Exec executes a custom SQL command within the transaction
+func (t *SQLiteTransaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+// Preload loads the given relations within the transaction
+func (t *SQLiteTransaction) Preload(ctx context.Context, dest interface{}, relations ...string) error {
+	return t.orm.backend.Preload(ctx, t.tx, dest, relations...)
+}
+
+// Association returns the operations for the given many_to_many relation of the model
+func (t *SQLiteTransaction) Association(model core.Model, relation string) (core.Association, error) {
+	association, err := t.orm.backend.Association(t.tx, model, relation)
+	if err != nil {
+		return nil, err
+	}
+	return association, nil
+}
@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rodolfocoding/night-orm/pkg/core"
+)
+
+type Author struct {
+	ID        int64      `db:"id,primary"`
+	Name      string     `db:"name"`
+	Active    bool       `db:"active"`
+	CreatedAt time.Time  `db:"created_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+	Books     []*Book    `rel:"has_many,fk=author_id"`
+	Genres    []Genre    `rel:"many_to_many,join=author_genres,fk=author_id,target_fk=genre_id"`
+}
+
+type Book struct {
+	ID       int64  `db:"id,primary"`
+	AuthorID int64  `db:"author_id"`
+	Title    string `db:"title"`
+}
+
+type Genre struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+const testSchema = `
+CREATE TABLE authors (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	active INTEGER NOT NULL,
+	created_at TEXT NOT NULL,
+	deleted_at TEXT
+);
+CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER NOT NULL, title TEXT NOT NULL);
+CREATE TABLE genres (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+CREATE TABLE author_genres (author_id INTEGER, genre_id INTEGER, PRIMARY KEY (author_id, genre_id));
+`
+
+// openTestORM abre um banco em memória com as tabelas dos modelos de teste
+func openTestORM(t *testing.T, opts ...Option) *SQLiteORM {
+	t.Helper()
+	orm, err := Connect(context.Background(), ":memory:", opts...)
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	t.Cleanup(func() { orm.Close() })
+	if _, err := orm.Exec(context.Background(), testSchema); err != nil {
+		t.Fatalf("Error creating schema: %v", err)
+	}
+	return orm
+}
+
+func TestSQLiteORM(t *testing.T) {
+	ctx := context.Background()
+	orm := openTestORM(t)
+	createdAt := time.Date(2024, 3, 15, 10, 30, 0, 123456789, time.FixedZone("BRT", -3*3600))
+
+	author := &Author{Name: "Machado", Active: true, CreatedAt: createdAt}
+	if err := orm.Create(ctx, author); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if author.ID == 0 {
+		t.Fatal("Expected generated ID to be set")
+	}
+
+	t.Run("Conversions", func(t *testing.T) {
+		// Booleanos são gravados como 0/1 e datas como texto em UTC
+		var active int64
+		var stored string
+		row := orm.DB().QueryRow("SELECT active, created_at FROM authors WHERE id = ?", author.ID)
+		if err := row.Scan(&active, &stored); err != nil {
+			t.Fatal(err)
+		}
+		if active != 1 || stored != "2024-03-15 13:30:00.123456789+00:00" {
+			t.Errorf("Expected 1 and UTC text, got %d and %q", active, stored)
+		}
+
+		found := &Author{}
+		if err := orm.FindByID(ctx, found, author.ID); err != nil {
+			t.Fatalf("FindByID returned error: %v", err)
+		}
+		if found.Name != "Machado" || !found.Active || !found.CreatedAt.Equal(createdAt) || found.DeletedAt != nil {
+			t.Errorf("Expected stored author, got %+v", found)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		deletedAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+		author.Active = false
+		author.DeletedAt = &deletedAt
+		if err := orm.Update(ctx, author); err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+
+		var authors []*Author
+		if err := orm.FindAll(ctx, &Author{}, &authors); err != nil {
+			t.Fatalf("FindAll returned error: %v", err)
+		}
+		if len(authors) != 1 || authors[0].Active || authors[0].DeletedAt == nil || !authors[0].DeletedAt.Equal(deletedAt) {
+			t.Errorf("Expected updated author, got %+v", authors)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		err := orm.Create(ctx, &Author{Name: "Machado", CreatedAt: createdAt})
+		if !errors.Is(err, core.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		other := &Author{Name: "Clarice", CreatedAt: createdAt}
+		if err := orm.Create(ctx, other); err != nil {
+			t.Fatal(err)
+		}
+		if err := orm.Delete(ctx, other); err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+		if err := orm.FindByID(ctx, &Author{}, other.ID); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := orm.Delete(ctx, other); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for a deleted record, got %v", err)
+		}
+	})
+}
+
+func TestSQLiteWithoutReturning(t *testing.T) {
+	ctx := context.Background()
+	orm := openTestORM(t, WithoutReturning())
+	if orm.Dialect().Returning() {
+		t.Error("Expected dialect without RETURNING")
+	}
+
+	first := &Book{AuthorID: 1, Title: "Dom Casmurro"}
+	second := &Book{AuthorID: 1, Title: "Helena"}
+	for _, book := range []*Book{first, second} {
+		if err := orm.Create(ctx, book); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+	// A chave gerada vem de LastInsertId
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("Expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+}
+
+func TestSQLiteTransaction(t *testing.T) {
+	ctx := context.Background()
+	orm := openTestORM(t)
+	author := &Author{Name: "Cecília", CreatedAt: time.Now()}
+	if err := orm.Create(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Rollback", func(t *testing.T) {
+		tx, err := orm.Transaction(ctx)
+		if err != nil {
+			t.Fatalf("Transaction returned error: %v", err)
+		}
+		book := &Book{AuthorID: author.ID, Title: "Romanceiro"}
+		if err := tx.Create(ctx, book); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if book.ID == 0 {
+			t.Error("Expected generated ID within the transaction")
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+
+		var books []*Book
+		if err := orm.FindAll(ctx, &Book{}, &books); err != nil {
+			t.Fatal(err)
+		}
+		if len(books) != 0 {
+			t.Errorf("Expected no books after rollback, got %d", len(books))
+		}
+	})
+
+	t.Run("Association", func(t *testing.T) {
+		poetry, novel := &Genre{Name: "Poesia"}, &Genre{Name: "Romance"}
+		for _, genre := range []*Genre{poetry, novel} {
+			if err := orm.Create(ctx, genre); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tx, err := orm.Transaction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Create(ctx, &Book{AuthorID: author.ID, Title: "Viagem"}); err != nil {
+			t.Fatal(err)
+		}
+		association, err := tx.Association(author, "Genres")
+		if err != nil {
+			t.Fatalf("Association returned error: %v", err)
+		}
+		if err := association.Append(ctx, poetry, novel, poetry); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+		if err := association.Replace(ctx, novel); err != nil {
+			t.Fatalf("Replace returned error: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		found := &Author{}
+		if err := orm.FindByID(core.WithPreload(ctx, "Books", "Genres"), found, author.ID); err != nil {
+			t.Fatalf("FindByID returned error: %v", err)
+		}
+		if len(found.Books) != 1 || found.Books[0].Title != "Viagem" {
+			t.Errorf("Expected preloaded book, got %+v", found.Books)
+		}
+		if len(found.Genres) != 1 || found.Genres[0].Name != "Romance" {
+			t.Errorf("Expected only the replaced genre, got %+v", found.Genres)
+		}
+	})
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	d := SQLiteDialect{}
+	if got := d.Placeholder(3); got != "?3" {
+		t.Errorf("Expected ?3, got %s", got)
+	}
+	if got := d.Pagination(0, 20); got != "LIMIT -1 OFFSET 20" {
+		t.Errorf("Expected LIMIT -1 OFFSET 20, got %s", got)
+	}
+	if got := d.Upsert([]string{"id"}, []string{"name"}); got != `ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name"` {
+		t.Errorf("Unexpected upsert clause: %s", got)
+	}
+
+	// Datas são lidas de texto, inteiros (segundos Unix) e valores já convertidos pelo driver
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, src := range []interface{}{"2024-01-02 03:04:05", "2024-01-02T03:04:05Z", []byte("2024-01-02 03:04:05+00:00"), expected.Unix(), expected} {
+		var got time.Time
+		if err := d.ScanDestination(&got).(interface{ Scan(interface{}) error }).Scan(src); err != nil {
+			t.Errorf("Scan(%v) returned error: %v", src, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("Expected %v from %v, got %v", expected, src, got)
+		}
+	}
+	if got := d.BindValue(true); got != int64(1) {
+		t.Errorf("Expected 1 for true, got %v", got)
+	}
+}
@@ -0,0 +1,168 @@
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// DriverName is the name the driver is registered with in database/sql. Its data
+// source names are those of the recorders created by New.
+const DriverName = "sqltest"
+
+func init() {
+	sql.Register(DriverName, Driver{})
+}
+
+// Driver is the recording database/sql driver
+type Driver struct{}
+
+// Open opens a connection answered by the recorder with the given name
+func (Driver) Open(name string) (driver.Conn, error) {
+	r, ok := recorders.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("sqltest: unknown recorder %q", name)
+	}
+	return &conn{recorder: r.(*Recorder)}, nil
+}
+
+// connector opens connections of a recorder
+type connector struct {
+	recorder *Recorder
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{recorder: c.recorder}, nil
+}
+
+func (c connector) Driver() driver.Driver { return Driver{} }
+
+// conn is a connection of a recorder
+type conn struct {
+	recorder *Recorder
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+	c.recorder.prepared = append(c.recorder.prepared, query)
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Prepare(query)
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.recorder.next(KindBegin, "BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return tx{recorder: c.recorder}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.recorder.next(KindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return e.result(), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.recorder.next(KindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.rows == nil {
+		return &rows{}, nil
+	}
+	return &rows{columns: e.rows.columns, values: e.rows.values, err: e.rows.err}, nil
+}
+
+// stmt is a prepared statement; it is recorded each time it runs
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// named converts positional values into ordinal named values
+func named(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
+
+// tx is a transaction of a recorder
+type tx struct {
+	recorder *Recorder
+}
+
+func (t tx) Commit() error {
+	_, err := t.recorder.next(KindCommit, "COMMIT", nil)
+	return err
+}
+
+func (t tx) Rollback() error {
+	_, err := t.recorder.next(KindRollback, "ROLLBACK", nil)
+	return err
+}
+
+// result is the scripted result of an Exec
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// rows iterates over the scripted rows of a query
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,395 @@
+// Package sqltest provides a database/sql driver that records the statements it
+// receives and answers them from scripted expectations, so tests can assert the exact
+// SQL generated by the ORM without a database:
+//
+//	rec := sqltest.New()
+//	defer rec.Close()
+//	orm := postgres.NewPostgresORMFromDB(rec.DB())
+//
+//	rec.ExpectQuery(sqltest.Exact(`INSERT INTO "users" ("name") VALUES ($1) RETURNING "id"`)).
+//		WithArgs("Ana").
+//		WillReturnRows(sqltest.NewRows("id").AddRow(1))
+//
+//	err := orm.Create(ctx, &User{Name: "Ana"})
+//	err = rec.ExpectationsWereMet()
+//
+// Statements must run in the order of the expectations; any other statement fails with
+// ErrUnexpected and is reported by ExpectationsWereMet.
+package sqltest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnexpected is returned for a statement that does not match the next expectation
+var ErrUnexpected = errors.New("sqltest: unexpected statement")
+
+// Kind identifies how a statement reached the driver
+type Kind string
+
+const (
+	KindExec     Kind = "exec"
+	KindQuery    Kind = "query"
+	KindBegin    Kind = "begin"
+	KindCommit   Kind = "commit"
+	KindRollback Kind = "rollback"
+)
+
+// Statement is a statement received by the driver, with its arguments after the
+// database/sql conversion to driver values
+type Statement struct {
+	Kind Kind
+	SQL  string
+	Args []driver.Value
+}
+
+// Recorder records the statements run on its connection pool and answers them from
+// its expectations. It is safe for concurrent use.
+type Recorder struct {
+	mu           sync.Mutex
+	name         string
+	db           *sql.DB
+	expectations []*Expectation
+	statements   []Statement
+	prepared     []string
+	failures     []error
+}
+
+// recorders maps the data source names opened by the driver to their recorders
+var (
+	recorders sync.Map
+	sequence  atomic.Int64
+)
+
+// New creates a recorder and opens a connection pool on the driver
+func New() *Recorder {
+	r := &Recorder{name: fmt.Sprintf("recorder-%d", sequence.Add(1))}
+	recorders.Store(r.name, r)
+
+	// Opening a registered driver only fails for an unknown driver name
+	r.db, _ = sql.Open(DriverName, r.name)
+	return r
+}
+
+// DB returns the connection pool answered by the recorder
+func (r *Recorder) DB() *sql.DB {
+	return r.db
+}
+
+// DataSourceName returns the data source name opening the recorder with sql.Open and
+// DriverName
+func (r *Recorder) DataSourceName() string {
+	return r.name
+}
+
+// Connector returns a connector opening connections answered by the recorder, for
+// sql.OpenDB
+func (r *Recorder) Connector() driver.Connector {
+	return connector{recorder: r}
+}
+
+// Close closes the connection pool
+func (r *Recorder) Close() error {
+	recorders.Delete(r.name)
+	return r.db.Close()
+}
+
+// Statements returns the statements received so far, including unexpected ones
+func (r *Recorder) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Statement(nil), r.statements...)
+}
+
+// Queries returns the SQL of the statements received so far
+func (r *Recorder) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queries := make([]string, len(r.statements))
+	for i, statement := range r.statements {
+		queries[i] = statement.SQL
+	}
+	return queries
+}
+
+// Prepared returns the SQL of the statements prepared so far, on any connection
+func (r *Recorder) Prepared() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.prepared...)
+}
+
+// ExpectExec expects a statement run with Exec. By default it affects one row.
+func (r *Recorder) ExpectExec(query Matcher) *Expectation {
+	return r.expect(KindExec, query)
+}
+
+// ExpectQuery expects a statement run with Query or QueryRow. By default it returns
+// no rows.
+func (r *Recorder) ExpectQuery(query Matcher) *Expectation {
+	return r.expect(KindQuery, query)
+}
+
+// ExpectBegin expects the start of a transaction
+func (r *Recorder) ExpectBegin() *Expectation {
+	return r.expect(KindBegin, Exact("BEGIN"))
+}
+
+// ExpectCommit expects the commit of a transaction
+func (r *Recorder) ExpectCommit() *Expectation {
+	return r.expect(KindCommit, Exact("COMMIT"))
+}
+
+// ExpectRollback expects the rollback of a transaction
+func (r *Recorder) ExpectRollback() *Expectation {
+	return r.expect(KindRollback, Exact("ROLLBACK"))
+}
+
+// expect appends an expectation
+func (r *Recorder) expect(kind Kind, query Matcher) *Expectation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := &Expectation{kind: kind, query: query}
+	r.expectations = append(r.expectations, e)
+	return e
+}
+
+// ExpectationsWereMet returns an error listing the unexpected statements and the
+// expectations that were not run
+func (r *Recorder) ExpectationsWereMet() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := append([]error(nil), r.failures...)
+	for _, e := range r.expectations {
+		errs = append(errs, fmt.Errorf("sqltest: expected %s was not run", e))
+	}
+	return errors.Join(errs...)
+}
+
+// next records a statement and consumes the expectation it matches
+func (r *Recorder) next(kind Kind, query string, args []driver.NamedValue) (*Expectation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	r.statements = append(r.statements, Statement{Kind: kind, SQL: query, Args: values})
+
+	if len(r.expectations) == 0 {
+		err := fmt.Errorf("%w: %s %s %v; no more statements were expected", ErrUnexpected, kind, query, values)
+		r.failures = append(r.failures, err)
+		return nil, err
+	}
+	e := r.expectations[0]
+	if reason := e.mismatch(kind, query, values); reason != "" {
+		err := fmt.Errorf("%w: %s %s %v; expected %s: %s", ErrUnexpected, kind, query, values, e, reason)
+		r.failures = append(r.failures, err)
+		return nil, err
+	}
+	r.expectations = r.expectations[1:]
+	return e, e.err
+}
+
+// Expectation is an expected statement and its scripted answer
+type Expectation struct {
+	kind         Kind
+	query        Matcher
+	args         []interface{}
+	checkArgs    bool
+	lastInsertID int64
+	rowsAffected int64
+	resultSet    bool
+	rows         *Rows
+	err          error
+}
+
+// WithArgs expects the given arguments. Values are compared after the database/sql
+// conversion, so int matches int64; an Argument, such as AnyArg, matches on its own.
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.checkArgs = true
+	return e
+}
+
+// WillReturnResult sets the result of an Exec
+func (e *Expectation) WillReturnResult(lastInsertID, rowsAffected int64) *Expectation {
+	e.lastInsertID = lastInsertID
+	e.rowsAffected = rowsAffected
+	e.resultSet = true
+	return e
+}
+
+// WillReturnRows sets the rows of a Query
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnError makes the statement fail with err, which reaches the caller unwrapped
+// so drivers' error types can be scripted
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// String describes the expectation
+func (e *Expectation) String() string {
+	if e.checkArgs {
+		return fmt.Sprintf("%s %s with args %v", e.kind, e.query, e.args)
+	}
+	return fmt.Sprintf("%s %s", e.kind, e.query)
+}
+
+// mismatch returns why a statement does not match the expectation, or "" when it does
+func (e *Expectation) mismatch(kind Kind, query string, args []driver.Value) string {
+	if kind != e.kind {
+		return "got " + string(kind)
+	}
+	if !e.query.Match(query) {
+		return "query does not match"
+	}
+	if !e.checkArgs {
+		return ""
+	}
+	if len(args) != len(e.args) {
+		return fmt.Sprintf("expected %d args, got %d", len(e.args), len(args))
+	}
+	for i, expected := range e.args {
+		if argument, ok := expected.(Argument); ok {
+			if !argument.Match(args[i]) {
+				return fmt.Sprintf("arg %d does not match %v", i+1, argument)
+			}
+			continue
+		}
+		converted, err := driver.DefaultParameterConverter.ConvertValue(expected)
+		if err != nil {
+			return fmt.Sprintf("invalid expected arg %d: %v", i+1, err)
+		}
+		if !equalValues(converted, args[i]) {
+			return fmt.Sprintf("arg %d: expected %v, got %v", i+1, converted, args[i])
+		}
+	}
+	return ""
+}
+
+// result returns the scripted result of an Exec
+func (e *Expectation) result() driver.Result {
+	if !e.resultSet {
+		return result{rowsAffected: 1}
+	}
+	return result{lastInsertID: e.lastInsertID, rowsAffected: e.rowsAffected}
+}
+
+// equalValues compares driver values, times by instant and bytes with strings by content
+func equalValues(expected, actual driver.Value) bool {
+	switch expected := expected.(type) {
+	case time.Time:
+		actual, ok := actual.(time.Time)
+		return ok && expected.Equal(actual)
+	case []byte:
+		if actual, ok := actual.(string); ok {
+			return string(expected) == actual
+		}
+	case string:
+		if actual, ok := actual.([]byte); ok {
+			return expected == string(actual)
+		}
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// Matcher matches the SQL of a statement
+type Matcher interface {
+	Match(query string) bool
+	String() string
+}
+
+// Exact matches a statement with the same SQL, ignoring differences in whitespace
+func Exact(query string) Matcher {
+	return exactMatcher(normalize(query))
+}
+
+type exactMatcher string
+
+func (m exactMatcher) Match(query string) bool { return normalize(query) == string(m) }
+func (m exactMatcher) String() string          { return fmt.Sprintf("%q", string(m)) }
+
+// Regexp matches a statement whose SQL contains a match of the pattern. It panics
+// if the pattern does not compile, like regexp.MustCompile.
+func Regexp(pattern string) Matcher {
+	return regexpMatcher{regexp.MustCompile(pattern)}
+}
+
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexpMatcher) Match(query string) bool { return m.re.MatchString(query) }
+func (m regexpMatcher) String() string          { return "/" + m.re.String() + "/" }
+
+// normalize collapses runs of whitespace into single spaces
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// Argument matches an argument on its own rule
+type Argument interface {
+	Match(value driver.Value) bool
+}
+
+// AnyArg returns an Argument matching any value
+func AnyArg() Argument {
+	return anyArgument{}
+}
+
+type anyArgument struct{}
+
+func (anyArgument) Match(driver.Value) bool { return true }
+func (anyArgument) String() string          { return "<any>" }
+
+// Rows are the scripted rows of a query
+type Rows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+// NewRows creates an empty result set with the given columns
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// AddRow appends a row. Values are converted like arguments, so int becomes int64;
+// it panics if the number of values differs from the number of columns.
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	if len(values) != len(r.columns) {
+		panic(fmt.Sprintf("sqltest: row has %d values for %d columns", len(values), len(r.columns)))
+	}
+	row := make([]driver.Value, len(values))
+	for i, value := range values {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			panic(fmt.Sprintf("sqltest: invalid value for column %s: %v", r.columns[i], err))
+		}
+		row[i] = converted
+	}
+	r.values = append(r.values, row)
+	return r
+}
+
+// RowError makes the iteration fail with err after the rows added so far
+func (r *Rows) RowError(err error) *Rows {
+	r.err = err
+	return r
+}
@@ -0,0 +1,212 @@
+package sqltest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rodolfocoding/night-orm/pkg/core"
+	"github.com/rodolfocoding/night-orm/pkg/postgres"
+)
+
+type User struct {
+	ID        int64     `db:"id,primary"`
+	Name      string    `db:"name"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// newTestORM cria um ORM do PostgreSQL sobre um gravador exclusivo do teste
+func newTestORM(t *testing.T) (*postgres.PostgresORM, *Recorder) {
+	t.Helper()
+	rec := New()
+	t.Cleanup(func() { rec.Close() })
+	return postgres.NewPostgresORMFromDB(rec.DB()), rec
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Create", func(t *testing.T) {
+		orm, rec := newTestORM(t)
+		rec.ExpectQuery(Exact(`INSERT INTO "users" ("name", "email", "created_at")
+			VALUES ($1, $2, $3) RETURNING "id"`)).
+			WithArgs("Ana", "ana@example.com", createdAt).
+			WillReturnRows(NewRows("id").AddRow(7))
+
+		user := &User{Name: "Ana", Email: "ana@example.com", CreatedAt: createdAt}
+		if err := orm.Create(ctx, user); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if user.ID != 7 {
+			t.Errorf("Expected ID 7, got %d", user.ID)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindByID", func(t *testing.T) {
+		orm, rec := newTestORM(t)
+		rec.ExpectQuery(Regexp(`^SELECT .* FROM "users" WHERE "id" = \$1$`)).
+			WithArgs(3).
+			WillReturnRows(NewRows("id", "name", "email", "created_at").AddRow(3, "Bia", []byte("bia@example.com"), createdAt))
+		rec.ExpectQuery(Regexp(`FROM "users"`)).WithArgs(AnyArg())
+
+		user := &User{}
+		if err := orm.FindByID(ctx, user, 3); err != nil {
+			t.Fatalf("FindByID returned error: %v", err)
+		}
+		if user.Name != "Bia" || user.Email != "bia@example.com" || !user.CreatedAt.Equal(createdAt) {
+			t.Errorf("Unexpected user: %+v", user)
+		}
+		// Consultas sem linhas roteirizadas não retornam linhas
+		if err := orm.FindByID(ctx, &User{}, 4); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("ScriptedErrors", func(t *testing.T) {
+		orm, rec := newTestORM(t)
+		rec.ExpectExec(Regexp(`^UPDATE "users" SET`)).WillReturnResult(0, 0)
+		rec.ExpectExec(Exact(`DELETE FROM "users" WHERE "id" = $1`)).
+			WithArgs(int64(5)).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		if err := orm.Update(ctx, &User{ID: 5, Name: "Ana"}); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := orm.Delete(ctx, &User{ID: 5}); !errors.Is(err, core.ErrForeignKey) {
+			t.Errorf("Expected ErrForeignKey, got %v", err)
+		}
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		orm, rec := newTestORM(t)
+		rec.ExpectBegin()
+		rec.ExpectQuery(Exact(`INSERT INTO "users" ("name", "email", "created_at") VALUES ($1, $2, $3) RETURNING "id"`)).
+			WillReturnRows(NewRows("id").AddRow(int64(8)))
+		rec.ExpectRollback()
+
+		tx, err := orm.Transaction(ctx)
+		if err != nil {
+			t.Fatalf("Transaction returned error: %v", err)
+		}
+		user := &User{Name: "Carla"}
+		if err := tx.Create(ctx, user); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if user.ID != 8 {
+			t.Errorf("Expected id 8, got %d", user.ID)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Kind{KindBegin, KindQuery, KindRollback}
+		statements := rec.Statements()
+		for i, kind := range expected {
+			if i >= len(statements) || statements[i].Kind != kind {
+				t.Fatalf("Expected statement kinds %v, got %+v", expected, statements)
+			}
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Unexpected", func(t *testing.T) {
+		orm, rec := newTestORM(t)
+		rec.ExpectExec(Exact(`DELETE FROM "users" WHERE "id" = $1`)).WithArgs(1)
+		rec.ExpectCommit()
+
+		// Argumentos diferentes falham a instrução com ErrUnexpected
+		if err := orm.Delete(ctx, &User{ID: 2}); !errors.Is(err, ErrUnexpected) {
+			t.Errorf("Expected ErrUnexpected, got %v", err)
+		}
+		if _, err := orm.Exec(ctx, "TRUNCATE users"); !errors.Is(err, ErrUnexpected) {
+			t.Errorf("Expected ErrUnexpected, got %v", err)
+		}
+
+		err := rec.ExpectationsWereMet()
+		if err == nil {
+			t.Fatal("Expected unmet expectations")
+		}
+		for _, part := range []string{"arg 1: expected 1, got 2", "TRUNCATE users", "commit \"COMMIT\" was not run"} {
+			if !strings.Contains(err.Error(), part) {
+				t.Errorf("Expected error to mention %q, got %v", part, err)
+			}
+		}
+		if queries := rec.Queries(); len(queries) != 2 || queries[1] != "TRUNCATE users" {
+			t.Errorf("Expected unexpected statements to be recorded, got %v", queries)
+		}
+	})
+
+	t.Run("Construction", func(t *testing.T) {
+		rec := New()
+		defer rec.Close()
+
+		// Close mantém aberto o pool compartilhado, a menos que WithClosePool(true) seja informado
+		if err := postgres.NewPostgresORMFromDB(rec.DB()).Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.DB().PingContext(ctx); err != nil {
+			t.Errorf("Expected shared pool to stay open, got %v", err)
+		}
+
+		orm := postgres.NewPostgresORMFromConnector(rec.Connector())
+		rec.ExpectExec(Exact("SELECT 1"))
+		if _, err := orm.Exec(ctx, "SELECT 1"); err != nil {
+			t.Errorf("Exec through the connector returned error: %v", err)
+		}
+		orm.Close()
+		if err := orm.DB().PingContext(ctx); err == nil {
+			t.Error("Expected the connector pool to be closed")
+		}
+
+		orm = postgres.NewPostgresORM(postgres.WithDriverName(DriverName))
+		if err := orm.Connect(ctx, rec.DataSourceName()); err != nil {
+			t.Fatalf("Connect returned error: %v", err)
+		}
+		rec.ExpectExec(Exact("SELECT 2"))
+		if _, err := orm.Exec(ctx, "SELECT 2"); err != nil {
+			t.Errorf("Exec through the driver name returned error: %v", err)
+		}
+		orm.Close()
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Prepared", func(t *testing.T) {
+		_, rec := newTestORM(t)
+		rec.ExpectQuery(Exact("SELECT name FROM users WHERE id = $1")).
+			WithArgs(1).
+			WillReturnRows(NewRows("name").AddRow("Ana").RowError(errors.New("connection reset")))
+
+		stmt, err := rec.DB().PrepareContext(ctx, "SELECT name FROM users WHERE id = $1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		rows, err := stmt.QueryContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("QueryContext returned error: %v", err)
+		}
+		defer rows.Close()
+
+		var names []string
+		for rows.Next() {
+			var name string
+			rows.Scan(&name)
+			names = append(names, name)
+		}
+		if len(names) != 1 || rows.Err() == nil {
+			t.Errorf("Expected one row and then the scripted error, got %v and %v", names, rows.Err())
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package utils
+
+import "reflect"
+
+// FieldAccessor é implementado pelos models com acessores gerados (night-orm gen accessors),
+// permitindo que o ORM leia e preencha os campos sem reflexão
+type FieldAccessor interface {
+	// FieldValues retorna o valor de cada coluna mapeada, como está no campo; os slices
+	// mapeados para arrays são convertidos pelo Mapper
+	FieldValues() map[string]interface{}
+	// FieldPointer retorna o ponteiro para o campo mapeado para a coluna, ou nil
+	FieldPointer(column string) interface{}
+}
+
+// fieldAccessor retorna os acessores gerados do valor de estrutura, se houver
+func fieldAccessor(val reflect.Value) (FieldAccessor, bool) {
+	if !val.CanAddr() {
+		return nil, false
+	}
+	accessor, ok := val.Addr().Interface().(FieldAccessor)
+	return accessor, ok
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// ErrArrayUnsupported indica que um campo de array foi usado com um dialeto sem colunas
+// de array, como o SQLite ou o MySQL
+var ErrArrayUnsupported = errors.New("colunas de array são suportadas apenas pelo dialeto postgres")
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// isArrayType verifica se o tipo é um slice que deve ser mapeado para um array do PostgreSQL.
+// Slices de bytes e tipos que já implementam driver.Valuer ou sql.Scanner são mantidos como estão.
+func isArrayType(typ reflect.Type) bool {
+	if typ == nil || typ.Kind() != reflect.Slice {
+		return false
+	}
+	if typ.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	return !typ.Implements(valuerType) && !reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// ArrayValue envolve um slice com pq.Array para ser enviado como parâmetro
+func ArrayValue(value interface{}) interface{} {
+	return pq.Array(value)
+}
+
+// FieldValue retorna o valor do campo pronto para ser enviado ao PostgreSQL
+func FieldValue(field reflect.Value, info FieldInfo) interface{} {
+	if !field.CanInterface() {
+		return nil
+	}
+	if info.IsArray() {
+		return pq.Array(field.Interface())
+	}
+	return field.Interface()
+}
+
+// ScanDestination retorna o destino usado por Scan para o campo informado, lido do PostgreSQL
+func ScanDestination(field reflect.Value, info FieldInfo) interface{} {
+	if info.IsArray() {
+		return pq.Array(field.Addr().Interface())
+	}
+	return field.Addr().Interface()
+}
+
+// arrays indica se o dialeto do Mapper possui colunas de array, enviadas com pq.Array
+func (m *Mapper) arrays() bool {
+	return m.dialect.Name() == PostgresDialect{}.Name()
+}
+
+// fieldValue retorna o valor do campo pronto para ser enviado pelo dialeto do Mapper
+func (m *Mapper) fieldValue(field reflect.Value, info FieldInfo) (interface{}, error) {
+	if !field.CanInterface() {
+		return nil, nil
+	}
+	if info.IsArray() {
+		return m.arrayValue(field.Interface(), info)
+	}
+	return field.Interface(), nil
+}
+
+// arrayValue envolve o valor de um campo de array com pq.Array, ou retorna
+// ErrArrayUnsupported quando o dialeto não possui arrays
+func (m *Mapper) arrayValue(value interface{}, info FieldInfo) (interface{}, error) {
+	if !m.arrays() {
+		return nil, fmt.Errorf("%w: campo %s (%s) com o dialeto %s", ErrArrayUnsupported, info.Name, info.Type, m.dialect.Name())
+	}
+	return pq.Array(value), nil
+}
+
+// arrayDestination envolve o destino de Scan de um campo de array com pq.Array; quando o
+// dialeto não possui arrays, o destino falha com ErrArrayUnsupported
+func (m *Mapper) arrayDestination(dest interface{}, info FieldInfo) interface{} {
+	if !m.arrays() {
+		return unsupportedArray{info: info, dialect: m.dialect.Name()}
+	}
+	return pq.Array(dest)
+}
+
+// unsupportedArray é o destino de Scan de um campo de array em um dialeto sem arrays
+type unsupportedArray struct {
+	info    FieldInfo
+	dialect string
+}
+
+// Scan retorna ErrArrayUnsupported
+func (u unsupportedArray) Scan(interface{}) error {
+	return fmt.Errorf("%w: campo %s (%s) com o dialeto %s", ErrArrayUnsupported, u.info.Name, u.info.Type, u.dialect)
+}
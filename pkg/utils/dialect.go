@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect descreve as diferenças de sintaxe entre bancos de dados consultadas pelo
+// QueryBuilder. Cada implementação de ORM seleciona o seu; o padrão é PostgresDialect.
+type Dialect interface {
+	// Name retorna o nome do banco de dados, como "postgres"
+	Name() string
+	// Placeholder retorna o marcador do parâmetro de posição index, começando em 1
+	Placeholder(index int) string
+	// QuoteIdentifier cita um identificador simples, sem separar esquema e tabela
+	QuoteIdentifier(name string) string
+	// Returning indica se INSERT suporta RETURNING; caso contrário, a chave gerada
+	// é obtida com sql.Result.LastInsertId
+	Returning() bool
+	// Upsert retorna a cláusula que, após um INSERT, atualiza as colunas de update quando
+	// houver conflito nas colunas de conflict; sem colunas de update o conflito é ignorado
+	Upsert(conflict, update []string) string
+	// Pagination retorna as cláusulas de limite e deslocamento; valores zero são omitidos
+	Pagination(limit, offset int) string
+}
+
+// PostgresDialect é o dialeto do PostgreSQL: parâmetros $n, aspas duplas, RETURNING e
+// ON CONFLICT
+type PostgresDialect struct{}
+
+// Name retorna "postgres"
+func (PostgresDialect) Name() string {
+	return "postgres"
+}
+
+// Placeholder retorna "$index"
+func (PostgresDialect) Placeholder(index int) string {
+	return fmt.Sprintf("$%d", index)
+}
+
+// QuoteIdentifier cita o identificador com aspas duplas
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	return QuoteIdentifier(name)
+}
+
+// Returning retorna true
+func (PostgresDialect) Returning() bool {
+	return true
+}
+
+// Upsert retorna "ON CONFLICT (...) DO UPDATE SET coluna = EXCLUDED.coluna" ou
+// "ON CONFLICT (...) DO NOTHING"
+func (d PostgresDialect) Upsert(conflict, update []string) string {
+	return ConflictUpsert(d, conflict, update, "EXCLUDED")
+}
+
+// Pagination retorna "LIMIT n OFFSET m"
+func (PostgresDialect) Pagination(limit, offset int) string {
+	return LimitOffset(limit, offset, "")
+}
+
+// ConflictUpsert monta a cláusula ON CONFLICT dos dialetos que seguem a sintaxe do
+// PostgreSQL; excluded é o nome da pseudo-tabela com a linha rejeitada
+func ConflictUpsert(d Dialect, conflict, update []string, excluded string) string {
+	target := ""
+	if len(conflict) > 0 {
+		target = " (" + strings.Join(quoteAll(conflict, d.QuoteIdentifier), ", ") + ")"
+	}
+	if len(update) == 0 {
+		return "ON CONFLICT" + target + " DO NOTHING"
+	}
+
+	assignments := make([]string, len(update))
+	for i, column := range update {
+		quoted := d.QuoteIdentifier(column)
+		assignments[i] = fmt.Sprintf("%s = %s.%s", quoted, excluded, quoted)
+	}
+	return "ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+// LimitOffset monta "LIMIT n OFFSET m"; unlimited é o limite escrito quando há apenas
+// deslocamento, nos bancos que não aceitam OFFSET sem LIMIT
+func LimitOffset(limit, offset int, unlimited string) string {
+	clauses := make([]string, 0, 2)
+	switch {
+	case limit > 0:
+		clauses = append(clauses, fmt.Sprintf("LIMIT %d", limit))
+	case offset > 0 && unlimited != "":
+		clauses = append(clauses, "LIMIT "+unlimited)
+	}
+	if offset > 0 {
+		clauses = append(clauses, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// TypeConverter é implementado pelos dialetos cujo banco não possui tipos nativos para
+// alguns valores de Go, como booleanos e datas no SQLite. O Mapper aplica as conversões
+// aos valores dos campos e aos destinos de Scan, inclusive no Preload.
+type TypeConverter interface {
+	// BindValue converte o valor de um campo antes de enviá-lo como parâmetro
+	BindValue(value interface{}) interface{}
+	// ScanDestination envolve o destino de Scan de um campo, convertendo o valor lido
+	ScanDestination(dest interface{}) interface{}
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownColumn indica que um identificador não corresponde a uma coluna mapeada do modelo
+var ErrUnknownColumn = errors.New("coluna desconhecida")
+
+// QuoteIdentifier envolve um identificador em aspas duplas, duplicando as aspas internas,
+// como pq.QuoteIdentifier. O identificador é truncado no primeiro caractere nulo.
+func QuoteIdentifier(name string) string {
+	if end := strings.IndexRune(name, 0); end > -1 {
+		name = name[:end]
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteQualified cita cada parte de um nome separado por pontos, como "billing.invoices"
+// → "billing"."invoices" ou "u.id" → "u"."id". Um "*" final é mantido sem aspas, como em "u.*".
+func QuoteQualified(name string) string {
+	return quoteQualified(QuoteIdentifier, name)
+}
+
+// quoteQualified cita cada parte de um nome qualificado com a função quote do dialeto
+func quoteQualified(quote func(string) string, name string) string {
+	if name == "*" {
+		return name
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 {
+			continue
+		}
+		parts[i] = quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// plainIdentifier reconhece nomes simples ou qualificados por pontos, como "id", "u.id"
+// ou "u.*", que podem ser citados sem mudar o seu significado
+var plainIdentifier = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_$]*(\.[\p{L}_][\p{L}\p{N}_$]*)*(\.\*)?$`)
+
+// quoteSelect cita um item de SELECT que seja um identificador simples ou qualificado;
+// expressões como "COUNT(*)", "lower(name)" ou "id AS x" são escritas como recebidas
+func quoteSelect(quote func(string) string, item string) string {
+	if item == "*" || !plainIdentifier.MatchString(item) {
+		return item
+	}
+	return quoteQualified(quote, item)
+}
+
+// plainName reconhece um nome simples ou qualificado, sem o "*" final aceito em SELECT
+func plainName(name string) bool {
+	return plainIdentifier.MatchString(name) && !strings.HasSuffix(name, "*")
+}
+
+// quoteTable cita uma referência de tabela com apelido opcional: "users u" e
+// "users AS u" viram "users" "u". Outras referências, como junções ou subconsultas,
+// são escritas como recebidas.
+func quoteTable(quote func(string) string, table string) string {
+	fields := strings.Fields(table)
+	switch {
+	case len(fields) == 1 && plainName(fields[0]):
+		return quoteQualified(quote, fields[0])
+	case len(fields) == 2 && plainName(fields[0]) && plainName(fields[1]) && !strings.Contains(fields[1], "."):
+		return quoteQualified(quote, fields[0]) + " " + quote(fields[1])
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS") && plainName(fields[0]) &&
+		plainName(fields[2]) && !strings.Contains(fields[2], "."):
+		return quoteQualified(quote, fields[0]) + " " + quote(fields[2])
+	}
+	return table
+}
+
+// quoteOrder cita a coluna de um item de ORDER BY, mantendo a direção e NULLS FIRST/LAST.
+// Expressões como "lower(name)" ou "created_at::date DESC" são escritas como recebidas.
+func quoteOrder(quote func(string) string, item string) string {
+	fields := strings.Fields(item)
+	if len(fields) == 0 || !plainName(fields[0]) {
+		return item
+	}
+
+	suffix := make([]string, 0, 3)
+	rest := fields[1:]
+	if len(rest) > 0 && (strings.EqualFold(rest[0], "ASC") || strings.EqualFold(rest[0], "DESC")) {
+		suffix = append(suffix, strings.ToUpper(rest[0]))
+		rest = rest[1:]
+	}
+	if len(rest) == 2 && strings.EqualFold(rest[0], "NULLS") &&
+		(strings.EqualFold(rest[1], "FIRST") || strings.EqualFold(rest[1], "LAST")) {
+		suffix = append(suffix, "NULLS", strings.ToUpper(rest[1]))
+		rest = nil
+	}
+	if len(rest) > 0 {
+		// Qualquer outro texto, como COLLATE, faz do item uma expressão
+		return item
+	}
+	return strings.Join(append([]string{quoteQualified(quote, fields[0])}, suffix...), " ")
+}
+
+// quoteAll aplica quote a cada nome
+func quoteAll(names []string, quote func(string) string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return quoted
+}
+
+// ValidateColumns verifica se as colunas informadas são colunas mapeadas do modelo
+// segundo DefaultMapper
+func ValidateColumns(model interface{}, columns ...string) error {
+	return DefaultMapper.ValidateColumns(model, columns...)
+}
+
+// ValidateColumns verifica se as colunas informadas são colunas mapeadas do modelo.
+// Use-a antes de passar ao QueryBuilder identificadores vindos do usuário, como a
+// coluna de ordenação de uma listagem.
+func (m *Mapper) ValidateColumns(model interface{}, columns ...string) error {
+	if model == nil {
+		return errors.New("modelo não pode ser nil")
+	}
+	typ := reflect.TypeOf(model)
+	fields := m.Fields(typ)
+	if fields == nil {
+		return fmt.Errorf("tipo %s não é uma estrutura", typ)
+	}
+	// Ao contrário de FindField, exige o nome exato da coluna
+	mapped := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		mapped[field.Column] = true
+	}
+	for _, column := range columns {
+		if !mapped[column] {
+			return fmt.Errorf("%w %q em %s", ErrUnknownColumn, column, typ)
+		}
+	}
+	return nil
+}
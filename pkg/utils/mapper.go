@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mapper resolve os nomes de tabelas, colunas e chaves primárias dos modelos segundo
+// uma estratégia de nomes. Cada ORM possui o seu; as funções do pacote usam DefaultMapper.
+type Mapper struct {
+	naming  NamingStrategy
+	dialect Dialect
+	schema  string
+	tables  sync.Map // map[reflect.Type]tableRef
+	fields  sync.Map // map[reflect.Type][]FieldInfo
+}
+
+// tableRef é o esquema e o nome de uma tabela
+type tableRef struct {
+	schema string
+	name   string
+}
+
+// DefaultMapper usa a estratégia SnakeCaseNaming
+var DefaultMapper = NewMapper(nil)
+
+// NewMapper cria um Mapper com a estratégia informada; nil usa SnakeCaseNaming
+func NewMapper(naming NamingStrategy) *Mapper {
+	if naming == nil {
+		naming = SnakeCaseNaming{}
+	}
+	return &Mapper{naming: naming, dialect: PostgresDialect{}}
+}
+
+// WithSchema retorna uma cópia do Mapper com o esquema padrão informado, usado pelos
+// modelos que não declaram um esquema
+func (m *Mapper) WithSchema(schema string) *Mapper {
+	return &Mapper{naming: m.naming, dialect: m.dialect, schema: schema}
+}
+
+// WithDialect retorna uma cópia do Mapper que monta as consultas com o dialeto informado
+func (m *Mapper) WithDialect(dialect Dialect) *Mapper {
+	return &Mapper{naming: m.naming, dialect: dialect, schema: m.schema}
+}
+
+// Dialect retorna o dialeto usado nas consultas montadas pelo Mapper
+func (m *Mapper) Dialect() Dialect {
+	return m.dialect
+}
+
+// NewQueryBuilder cria um construtor de consultas com o dialeto do Mapper
+func (m *Mapper) NewQueryBuilder() *QueryBuilder {
+	return NewQueryBuilderWith(m.dialect)
+}
+
+// Naming retorna a estratégia de nomes do Mapper
+func (m *Mapper) Naming() NamingStrategy {
+	return m.naming
+}
+
+// Schema retorna o esquema padrão do Mapper; vazio significa o search_path da conexão
+func (m *Mapper) Schema() string {
+	return m.schema
+}
+
+// TableName retorna o nome da tabela de um tipo de estrutura, qualificado pelo esquema
+// quando houver, como "billing.invoices". Veja TableSchema.
+func (m *Mapper) TableName(typ reflect.Type) (string, error) {
+	schema, name, err := m.TableSchema(typ)
+	return QualifiedName(schema, name), err
+}
+
+// TableSchema retorna o esquema e o nome da tabela de um tipo de estrutura. O nome vem
+// do método TableName, da tag "table" de um campo marcador (como _ struct{} `table:"users"`)
+// ou da estratégia de nomes aplicada ao nome da estrutura. O esquema vem de um nome
+// qualificado como "billing.invoices", do método Schema, da tag "schema" de um campo
+// marcador ou do esquema padrão do Mapper.
+func (m *Mapper) TableSchema(typ reflect.Type) (string, string, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return "", "", fmt.Errorf("tipo %s não é uma estrutura", typ)
+	}
+	return m.modelTable(reflect.New(typ).Interface(), typ)
+}
+
+// ModelTableName retorna o nome da tabela de um modelo, qualificado pelo esquema quando houver
+func (m *Mapper) ModelTableName(model interface{}) (string, error) {
+	schema, name, err := m.ModelTable(model)
+	return QualifiedName(schema, name), err
+}
+
+// ModelTable retorna o esquema e o nome da tabela de um modelo, consultando os métodos
+// TableName e Schema do próprio valor
+func (m *Mapper) ModelTable(model interface{}) (string, string, error) {
+	if model == nil {
+		return "", "", errors.New("modelo não pode ser nil")
+	}
+	typ := reflect.TypeOf(model)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return "", "", fmt.Errorf("tipo %s não é uma estrutura", typ)
+	}
+	return m.modelTable(model, typ)
+}
+
+// modelTable resolve a tabela de um modelo; apenas os nomes que não vêm de métodos
+// são guardados em cache
+func (m *Mapper) modelTable(model interface{}, typ reflect.Type) (string, string, error) {
+	// Métodos com receptor ponteiro são encontrados mesmo quando o modelo é um valor
+	pointer := reflect.New(typ).Interface()
+	named, hasName := model.(interface{ TableName() string })
+	if !hasName {
+		named, hasName = pointer.(interface{ TableName() string })
+	}
+	withSchema, hasSchema := model.(interface{ Schema() string })
+	if !hasSchema {
+		withSchema, hasSchema = pointer.(interface{ Schema() string })
+	}
+
+	var ref tableRef
+	if cached, ok := m.tables.Load(typ); ok {
+		ref = cached.(tableRef)
+	} else {
+		for i := 0; i < typ.NumField(); i++ {
+			tag := typ.Field(i).Tag
+			if table, ok := tag.Lookup("table"); ok && table != "" && ref.name == "" {
+				ref.name = table
+			}
+			if schema, ok := tag.Lookup("schema"); ok && schema != "" && ref.schema == "" {
+				ref.schema = schema
+			}
+		}
+		if ref.name == "" && !hasName {
+			if typ.Name() == "" {
+				return "", "", fmt.Errorf("não é possível derivar o nome da tabela de uma estrutura anônima")
+			}
+			ref.name = m.naming.TableName(typ.Name())
+		}
+		m.tables.Store(typ, ref)
+	}
+
+	if hasName {
+		ref.name = named.TableName()
+	}
+	if hasSchema {
+		ref.schema = withSchema.Schema()
+	}
+	if schema, name, ok := strings.Cut(ref.name, "."); ok {
+		return schema, name, nil
+	}
+	if ref.schema == "" {
+		ref.schema = m.schema
+	}
+	return ref.schema, ref.name, nil
+}
+
+// QualifiedName retorna o nome da tabela prefixado pelo esquema, quando houver
+func QualifiedName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// PrimaryKey retorna a coluna e o valor da chave primária de um modelo, usando os
+// métodos PrimaryKey e PrimaryKeyValue quando existirem ou GetPrimaryKeyField
+func (m *Mapper) PrimaryKey(model interface{}) (string, interface{}, error) {
+	if withKey, ok := model.(interface {
+		PrimaryKey() string
+		PrimaryKeyValue() interface{}
+	}); ok {
+		return withKey.PrimaryKey(), withKey.PrimaryKeyValue(), nil
+	}
+	return m.primaryKeyField(model)
+}
+
+// PrimaryKeyOf retorna a coluna de chave primária de um tipo de estrutura
+func (m *Mapper) PrimaryKeyOf(typ reflect.Type) (string, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	obj := reflect.New(typ).Interface()
+	if model, ok := obj.(interface{ PrimaryKey() string }); ok {
+		return model.PrimaryKey(), nil
+	}
+	column, _, err := m.primaryKeyField(obj)
+	return column, err
+}
+
+// JoinTable retorna a tabela de junção de uma relação many_to_many: a opção join,
+// quando declarada, ou o nome dado pela estratégia de nomes. Tabelas de junção sem
+// esquema ficam no esquema do modelo dono da relação.
+func (m *Mapper) JoinTable(relation Relation) string {
+	if relation.Owner == nil {
+		return relation.JoinTable
+	}
+	name := relation.JoinTable
+	if name == "" {
+		name = m.naming.JoinTableName(relation.Owner.Name(), relation.Target.Name())
+	}
+	if strings.Contains(name, ".") {
+		return name
+	}
+	schema, _, err := m.TableSchema(relation.Owner)
+	if err != nil {
+		return name
+	}
+	return QualifiedName(schema, name)
+}
+
+// KeyColumns retorna a coluna do modelo dono da relação e a coluna do modelo relacionado
+// usadas para associar os registros. Em many_to_many, são as colunas referenciadas
+// pelas chaves da tabela de junção.
+func (m *Mapper) KeyColumns(relation Relation, owner reflect.Type) (string, string, error) {
+	switch relation.Kind {
+	case BelongsTo:
+		if relation.References != "" {
+			return relation.ForeignKey, relation.References, nil
+		}
+		pk, err := m.PrimaryKeyOf(relation.Target)
+		return relation.ForeignKey, pk, err
+	case HasMany:
+		if relation.References != "" {
+			return relation.References, relation.ForeignKey, nil
+		}
+		pk, err := m.PrimaryKeyOf(owner)
+		return pk, relation.ForeignKey, err
+	default:
+		ownerColumn := relation.References
+		if ownerColumn == "" {
+			pk, err := m.PrimaryKeyOf(owner)
+			if err != nil {
+				return "", "", err
+			}
+			ownerColumn = pk
+		}
+		targetColumn, err := m.PrimaryKeyOf(relation.Target)
+		return ownerColumn, targetColumn, err
+	}
+}
+
+// Preload carrega as relações informadas nos modelos de dest, resolvendo as tabelas
+// relacionadas com a estratégia de nomes do Mapper
+func (m *Mapper) Preload(ctx context.Context, q Querier, dest interface{}, relations ...string) error {
+	if len(relations) == 0 {
+		return nil
+	}
+
+	return m.PreloadFrom(ctx, queryLoader{mapper: m, q: q}, dest, relations...)
+}
+
+// PreloadFrom carrega as relações informadas nos modelos de dest buscando os registros
+// relacionados com o loader, como faz Preload com o banco de dados
+func (m *Mapper) PreloadFrom(ctx context.Context, loader RelationLoader, dest interface{}, relations ...string) error {
+	if len(relations) == 0 {
+		return nil
+	}
+
+	models, typ, err := collectModels(dest)
+	if err != nil {
+		return err
+	}
+	return m.preloadModels(ctx, loader, typ, models, relations)
+}
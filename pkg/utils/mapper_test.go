@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type PlainUser struct {
+	ID    int64  `db:"id"`
+	Email string `db:"email"`
+}
+
+type TaggedInvoice struct {
+	_      struct{} `table:"billing_invoices"`
+	Number string   `db:"number,primary"`
+}
+
+type NamedOrder struct {
+	ID int `db:"id"`
+}
+
+func (o *NamedOrder) TableName() string { return "legacy_orders" }
+
+type BillingInvoice struct {
+	_  struct{} `schema:"billing"`
+	ID int64    `db:"id"`
+}
+
+type AuthSession struct {
+	ID    int64       `db:"id"`
+	Roles []NamingTag `rel:"many_to_many,fk=session_id,target_fk=tag_id"`
+}
+
+func (s AuthSession) Schema() string { return "auth" }
+
+type QualifiedOrder struct {
+	ID int64 `db:"id"`
+}
+
+func (o *QualifiedOrder) TableName() string { return "sales.orders" }
+
+type prefixNaming struct{ prefix string }
+
+func (n prefixNaming) TableName(structName string) string {
+	return n.prefix + SnakeCase(structName)
+}
+
+func (n prefixNaming) ColumnName(fieldName string) string {
+	return strings.ToUpper(SnakeCase(fieldName))
+}
+
+func (n prefixNaming) JoinTableName(ownerStruct, targetStruct string) string {
+	return n.prefix + SnakeCase(ownerStruct) + "_to_" + SnakeCase(targetStruct)
+}
+
+type NamingProfile struct {
+	ID        int64
+	CreatedAt string
+	UserID    int64       `db:",notnull"`
+	Nickname  string      `db:"nick"`
+	Tags      []NamingTag `rel:"many_to_many,fk=profile_id,target_fk=tag_id"`
+}
+
+type NamingTag struct {
+	ID int64 `db:"id"`
+}
+
+func TestMapperTableName(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapper   *Mapper
+		model    interface{}
+		expected string
+	}{
+		{"DefaultNaming", DefaultMapper, &PlainUser{}, "plain_users"},
+		{"TableTag", DefaultMapper, TaggedInvoice{}, "billing_invoices"},
+		{"TableNameMethod", DefaultMapper, &NamedOrder{}, "legacy_orders"},
+		{"CustomNaming", NewMapper(prefixNaming{"app_"}), &PlainUser{}, "app_plain_user"},
+		{"MethodPrecedence", NewMapper(prefixNaming{"app_"}), &NamedOrder{}, "legacy_orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := tt.mapper.ModelTableName(tt.model)
+			if err != nil {
+				t.Fatalf("ModelTableName returned error: %v", err)
+			}
+			if table != tt.expected {
+				t.Errorf("Expected table %q, got %q", tt.expected, table)
+			}
+		})
+	}
+
+	// Estruturas anônimas não possuem nome para derivar a tabela
+	if _, err := DefaultMapper.TableName(reflect.TypeOf(struct{ ID int }{})); err == nil {
+		t.Error("Expected error for anonymous struct")
+	}
+}
+
+func TestMapperPrimaryKey(t *testing.T) {
+	column, value, err := DefaultMapper.PrimaryKey(&PlainUser{ID: 7})
+	if err != nil {
+		t.Fatalf("PrimaryKey returned error: %v", err)
+	}
+	if column != "id" || value != int64(7) {
+		t.Errorf("Expected primary key id=7, got %s=%v", column, value)
+	}
+
+	column, value, err = DefaultMapper.PrimaryKey(&TaggedInvoice{Number: "A-1"})
+	if err != nil || column != "number" || value != "A-1" {
+		t.Errorf("Expected primary key number=A-1, got %s=%v (%v)", column, value, err)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	names := map[string]string{
+		"User":        "users",
+		"Category":    "categories",
+		"Day":         "days",
+		"Address":     "addresses",
+		"UserProfile": "user_profiles",
+		"HTTPLog":     "http_logs",
+	}
+	for structName, expected := range names {
+		if got := (SnakeCaseNaming{}).TableName(structName); got != expected {
+			t.Errorf("Expected TableName(%q) = %q, got %q", structName, expected, got)
+		}
+	}
+}
+
+func TestMapperColumns(t *testing.T) {
+	typ := reflect.TypeOf(NamingProfile{})
+	tests := []struct {
+		name     string
+		mapper   *Mapper
+		expected []string
+	}{
+		{"DefaultNaming", DefaultMapper, []string{"id", "created_at", "user_id", "nick"}},
+		{"LowerCaseColumns", NewMapper(SnakeCaseNaming{LowerCaseColumns: true}), []string{"id", "createdat", "userid", "nick"}},
+		{"CustomNaming", NewMapper(prefixNaming{"app_"}), []string{"ID", "CREATED_AT", "USER_ID", "nick"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := tt.mapper.Fields(typ)
+			columns := make([]string, len(fields))
+			for i, field := range fields {
+				columns[i] = field.Column
+			}
+			if !reflect.DeepEqual(columns, tt.expected) {
+				t.Errorf("Expected columns %v, got %v", tt.expected, columns)
+			}
+		})
+	}
+
+	// Os valores e a chave primária usam as colunas da estratégia do Mapper
+	mapper := NewMapper(prefixNaming{"app_"})
+	profile := &NamingProfile{ID: 3, CreatedAt: "hoje"}
+	values, err := mapper.StructFields(profile)
+	if err != nil {
+		t.Fatalf("StructFields returned error: %v", err)
+	}
+	if values["CREATED_AT"] != "hoje" {
+		t.Errorf("Expected CREATED_AT value, got %v", values)
+	}
+	// As colunas seguem a ordem de declaração, e as desconhecidas vêm ao final em ordem alfabética
+	values["EXTRA_B"], values["EXTRA_A"] = 1, 2
+	if columns := mapper.OrderedColumns(profile, values); !reflect.DeepEqual(columns, []string{"ID", "CREATED_AT", "USER_ID", "nick", "EXTRA_A", "EXTRA_B"}) {
+		t.Errorf("Expected columns in declaration order, got %v", columns)
+	}
+	if column, value, err := mapper.PrimaryKey(profile); err != nil || column != "ID" || value != int64(3) {
+		t.Errorf("Expected primary key ID=3, got %s=%v (%v)", column, value, err)
+	}
+	if err := mapper.SetField(profile, "USER_ID", int64(9)); err != nil || profile.UserID != 9 {
+		t.Errorf("Expected SetField to set UserID, got %d (%v)", profile.UserID, err)
+	}
+}
+
+func TestMapperJoinTable(t *testing.T) {
+	relation, err := GetRelation(reflect.TypeOf(NamingProfile{}), "Tags")
+	if err != nil {
+		t.Fatalf("GetRelation returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		mapper   *Mapper
+		expected string
+	}{
+		{"DefaultNaming", DefaultMapper, "naming_profile_naming_tags"},
+		{"TablePrefix", NewMapper(SnakeCaseNaming{TablePrefix: "app_"}), "app_naming_profile_naming_tags"},
+		{"CustomNaming", NewMapper(prefixNaming{"app_"}), "app_naming_profile_to_naming_tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mapper.JoinTable(relation); got != tt.expected {
+				t.Errorf("Expected join table %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// A opção join tem precedência sobre a estratégia
+	relation.JoinTable = "profile_tags"
+	if got := NewMapper(prefixNaming{"app_"}).JoinTable(relation); got != "profile_tags" {
+		t.Errorf("Expected join table profile_tags, got %q", got)
+	}
+}
+
+func TestSnakeCaseNamingOptions(t *testing.T) {
+	naming := SnakeCaseNaming{TablePrefix: "app_", SingularTables: true}
+	if got := naming.TableName("UserProfile"); got != "app_user_profile" {
+		t.Errorf("Expected app_user_profile, got %q", got)
+	}
+	if got := naming.JoinTableName("User", "Role"); got != "app_user_role" {
+		t.Errorf("Expected app_user_role, got %q", got)
+	}
+	if got := (SnakeCaseNaming{}).JoinTableName("User", "Role"); got != "user_roles" {
+		t.Errorf("Expected user_roles, got %q", got)
+	}
+}
+
+func TestFindField(t *testing.T) {
+	typ := reflect.TypeOf(NamingProfile{})
+
+	// Apenas a coluna mapeada pela estratégia encontra o campo
+	if _, ok := FindField(DefaultMapper.Fields(typ), "createdat"); ok {
+		t.Error("Expected createdat not to match created_at")
+	}
+	if _, ok := FindField(DefaultMapper.Fields(typ), "CreatedAt"); ok {
+		t.Error("Expected the field name not to match its column")
+	}
+	if field, ok := FindField(NewMapper(SnakeCaseNaming{LowerCaseColumns: true}).Fields(typ), "createdat"); !ok || field.Name != "CreatedAt" {
+		t.Errorf("Expected createdat to match CreatedAt with LowerCaseColumns, got %+v", field)
+	}
+}
+
+func TestMapperSchema(t *testing.T) {
+	withSchema := DefaultMapper.WithSchema("app")
+	tests := []struct {
+		name     string
+		mapper   *Mapper
+		model    interface{}
+		expected string
+	}{
+		{"NoSchema", DefaultMapper, &PlainUser{}, "plain_users"},
+		{"DefaultSchema", withSchema, &PlainUser{}, "app.plain_users"},
+		{"SchemaTag", withSchema, &BillingInvoice{}, "billing.billing_invoices"},
+		{"SchemaMethod", withSchema, AuthSession{}, "auth.auth_sessions"},
+		{"QualifiedTableName", withSchema, QualifiedOrder{}, "sales.orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := tt.mapper.ModelTableName(tt.model)
+			if err != nil {
+				t.Fatalf("ModelTableName returned error: %v", err)
+			}
+			if table != tt.expected {
+				t.Errorf("Expected table %q, got %q", tt.expected, table)
+			}
+		})
+	}
+
+	// O esquema padrão não altera o cache do Mapper original
+	if table, _ := DefaultMapper.TableName(reflect.TypeOf(PlainUser{})); table != "plain_users" {
+		t.Errorf("Expected plain_users, got %q", table)
+	}
+
+	// Tabelas de junção ficam no esquema do modelo dono
+	relation, err := GetRelation(reflect.TypeOf(AuthSession{}), "Roles")
+	if err != nil {
+		t.Fatalf("GetRelation returned error: %v", err)
+	}
+	if got := withSchema.JoinTable(relation); got != "auth.auth_session_naming_tags" {
+		t.Errorf("Expected join table auth.auth_session_naming_tags, got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy define como os nomes do banco de dados são derivados dos tipos Go
+// quando não são declarados explicitamente. Cada Mapper guarda em cache os nomes
+// derivados, então as implementações devem ser determinísticas.
+type NamingStrategy interface {
+	// TableName retorna o nome da tabela de uma estrutura sem o método TableName e sem a tag "table"
+	TableName(structName string) string
+	// ColumnName retorna o nome da coluna de um campo sem nome na tag "db"
+	ColumnName(fieldName string) string
+	// JoinTableName retorna o nome da tabela de junção de uma relação many_to_many sem a
+	// opção join, a partir dos nomes das estruturas dona e relacionada
+	JoinTableName(ownerStruct, targetStruct string) string
+}
+
+// SnakeCaseNaming é a estratégia padrão: tabelas em snake_case no plural
+// ("UserProfile" → "user_profiles"), colunas em snake_case ("CreatedAt" → "created_at")
+// e tabelas de junção com o dono no singular ("User", "Role" → "user_roles")
+type SnakeCaseNaming struct {
+	// TablePrefix é adicionado às tabelas e tabelas de junção derivadas; nomes declarados
+	// com o método TableName, a tag "table" ou a opção join não recebem o prefixo
+	TablePrefix string
+	// SingularTables mantém os nomes de tabelas derivados no singular
+	SingularTables bool
+	// LowerCaseColumns usa o nome do campo apenas em minúsculas ("CreatedAt" → "createdat"),
+	// como nas versões anteriores
+	LowerCaseColumns bool
+}
+
+// TableName retorna o nome da estrutura em snake_case, no plural e com o prefixo configurado
+func (n SnakeCaseNaming) TableName(structName string) string {
+	name := SnakeCase(structName)
+	if !n.SingularTables {
+		name = Pluralize(name)
+	}
+	return n.TablePrefix + name
+}
+
+// ColumnName retorna o nome do campo em snake_case ou em minúsculas
+func (n SnakeCaseNaming) ColumnName(fieldName string) string {
+	if n.LowerCaseColumns {
+		return strings.ToLower(fieldName)
+	}
+	return SnakeCase(fieldName)
+}
+
+// JoinTableName retorna o dono no singular seguido da tabela relacionada, como "user_roles"
+func (n SnakeCaseNaming) JoinTableName(ownerStruct, targetStruct string) string {
+	target := SnakeCase(targetStruct)
+	if !n.SingularTables {
+		target = Pluralize(target)
+	}
+	return n.TablePrefix + SnakeCase(ownerStruct) + "_" + target
+}
+
+// SnakeCase converte um identificador Go como "UserID" em "user_id"
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var out strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Separa palavras em "userID" e siglas seguidas de palavras em "HTTPLog"
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				out.WriteByte('_')
+			}
+			out.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// Pluralize retorna o plural em inglês de um nome em snake_case, seguindo as regras regulares
+func Pluralize(name string) string {
+	switch {
+	case len(name) > 1 && strings.HasSuffix(name, "y") && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
@@ -0,0 +1,321 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Querier executa consultas SQL; é implementado por *sql.DB e *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// RelationLoader busca os registros relacionados carregados pelo Preload. O Mapper
+// agrupa os registros, carrega os caminhos aninhados e preenche os campos das relações;
+// Mapper.Preload usa uma implementação que consulta o banco com um Querier.
+type RelationLoader interface {
+	// LoadRelated retorna as estruturas do tipo target cuja coluna tem uma das chaves
+	LoadRelated(ctx context.Context, target reflect.Type, column string, keys []interface{}) ([]reflect.Value, error)
+	// LoadJoined retorna as estruturas ligadas pela tabela de junção de uma relação
+	// many_to_many aos donos com as chaves informadas, junto com a chave do dono de cada
+	// estrutura na forma de KeyString. column é a coluna do modelo relacionado
+	// referenciada pela tabela de junção.
+	LoadJoined(ctx context.Context, relation Relation, column string, keys []interface{}) ([]reflect.Value, []string, error)
+}
+
+// queryLoader implementa RelationLoader com consultas SQL montadas pelo Mapper
+type queryLoader struct {
+	mapper *Mapper
+	q      Querier
+}
+
+// Preload carrega as relações informadas nos modelos de dest, que pode ser um ponteiro
+// para uma estrutura, um slice de estruturas ou um ponteiro para um slice.
+// Cada relação é carregada com uma única consulta "WHERE fk IN (...)", e caminhos
+// aninhados como "Items.Product" carregam as relações dos modelos já carregados.
+func Preload(ctx context.Context, q Querier, dest interface{}, relations ...string) error {
+	return DefaultMapper.Preload(ctx, q, dest, relations...)
+}
+
+// collectModels retorna os valores endereçáveis das estruturas contidas em dest
+func collectModels(dest interface{}) ([]reflect.Value, reflect.Type, error) {
+	if dest == nil {
+		return nil, nil, errors.New("destino não pode ser nil")
+	}
+
+	val := reflect.ValueOf(dest)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, nil, errors.New("destino não pode ser um ponteiro nil")
+		}
+		if val.Elem().Kind() == reflect.Struct {
+			return []reflect.Value{val.Elem()}, val.Elem().Type(), nil
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Slice {
+		return nil, nil, errors.New("destino deve ser um ponteiro para uma estrutura ou um slice de estruturas")
+	}
+
+	elemType := val.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, nil, errors.New("destino deve ser um slice de estruturas")
+	}
+
+	models := make([]reflect.Value, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		item := val.Index(i)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		if !item.CanAddr() {
+			return nil, nil, errors.New("os elementos do destino devem ser endereçáveis")
+		}
+		models = append(models, item)
+	}
+	return models, structType, nil
+}
+
+// preloadModels carrega as relações para um conjunto de estruturas do mesmo tipo
+func (m *Mapper) preloadModels(ctx context.Context, loader RelationLoader, typ reflect.Type, models []reflect.Value, paths []string) error {
+	names, nested := splitRelationPath(paths)
+	for _, name := range names {
+		relation, err := GetRelation(typ, name)
+		if err != nil {
+			return err
+		}
+
+		if err := m.loadRelation(ctx, loader, typ, models, relation, nested[name]); err != nil {
+			return fmt.Errorf("erro ao carregar a relação %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// loadRelation executa a consulta da relação, carrega as relações aninhadas e
+// atribui os resultados aos modelos
+func (m *Mapper) loadRelation(ctx context.Context, loader RelationLoader, typ reflect.Type, models []reflect.Value, relation Relation, nested []string) error {
+	ownerColumn, targetColumn, err := m.KeyColumns(relation, typ)
+	if err != nil {
+		return err
+	}
+	relation.JoinTable = m.JoinTable(relation)
+
+	ownerField, ok := FindField(m.Fields(typ), ownerColumn)
+	if !ok {
+		return fmt.Errorf("coluna %q não encontrada em %s", ownerColumn, typ)
+	}
+	targetFields := m.Fields(relation.Target)
+	targetField, ok := FindField(targetFields, targetColumn)
+	if !ok {
+		return fmt.Errorf("coluna %q não encontrada em %s", targetColumn, relation.Target)
+	}
+
+	// Coleta as chaves distintas dos modelos
+	keys := make([]interface{}, 0, len(models))
+	seen := make(map[string]bool, len(models))
+	for _, model := range models {
+		key, value, ok := RelationKey(model.FieldByIndex(ownerField.Index))
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, value)
+	}
+
+	// Em many_to_many, a chave do dono vem da tabela de junção e é lida junto com cada registro
+	related := make([]reflect.Value, 0)
+	relatedKeys := make([]string, 0)
+	if len(keys) > 0 {
+		if relation.Kind == ManyToMany {
+			related, relatedKeys, err = loader.LoadJoined(ctx, relation, targetField.Column, keys)
+		} else {
+			related, err = loader.LoadRelated(ctx, relation.Target, targetField.Column, keys)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Carrega as relações aninhadas antes da atribuição, para que cópias de valores
+	// também recebam os dados carregados
+	if len(nested) > 0 && len(related) > 0 {
+		if err := m.preloadModels(ctx, loader, relation.Target, related, nested); err != nil {
+			return err
+		}
+	}
+
+	// Agrupa os modelos relacionados pela chave
+	grouped := make(map[string][]reflect.Value, len(related))
+	for i, item := range related {
+		if relation.Kind == ManyToMany {
+			grouped[relatedKeys[i]] = append(grouped[relatedKeys[i]], item)
+			continue
+		}
+		key, _, ok := RelationKey(item.FieldByIndex(targetField.Index))
+		if ok {
+			grouped[key] = append(grouped[key], item)
+		}
+	}
+
+	for _, model := range models {
+		key, _, ok := RelationKey(model.FieldByIndex(ownerField.Index))
+		field := model.FieldByIndex(relation.Index)
+		if relation.IsSlice() {
+			slice := reflect.MakeSlice(relation.Type, 0, len(grouped[key]))
+			if ok {
+				for _, item := range grouped[key] {
+					slice = reflect.Append(slice, relationValue(item, relation.Type.Elem()))
+				}
+			}
+			field.Set(slice)
+			continue
+		}
+
+		if items := grouped[key]; ok && len(items) > 0 {
+			field.Set(relationValue(items[0], relation.Type))
+		} else {
+			field.Set(reflect.Zero(relation.Type))
+		}
+	}
+
+	return nil
+}
+
+// LoadRelated busca os registros relacionados cujas colunas correspondem às chaves
+func (l queryLoader) LoadRelated(ctx context.Context, target reflect.Type, column string, keys []interface{}) ([]reflect.Value, error) {
+	m := l.mapper
+	fields := m.Fields(target)
+	table, err := m.TableName(target)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.Column)
+	}
+
+	qb := m.NewQueryBuilder()
+	qb.WriteSelect(columns...).
+		WriteFrom(table).
+		WriteWhere(qb.In(column, keys))
+	query, args := qb.Build()
+
+	rows, err := l.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao executar a consulta: %w", err)
+	}
+	defer rows.Close()
+
+	related := make([]reflect.Value, 0)
+	for rows.Next() {
+		item := reflect.New(target).Elem()
+		if err := rows.Scan(m.ScanDestinations(item, columns)...); err != nil {
+			return nil, fmt.Errorf("erro ao ler os valores: %w", err)
+		}
+		related = append(related, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao percorrer os resultados: %w", err)
+	}
+	return related, nil
+}
+
+// LoadJoined busca os registros de uma relação many_to_many com uma única consulta
+// à tabela de junção, retornando também a chave do dono de cada registro
+func (l queryLoader) LoadJoined(ctx context.Context, relation Relation, column string, keys []interface{}) ([]reflect.Value, []string, error) {
+	m := l.mapper
+	fields := m.Fields(relation.Target)
+	table, err := m.TableName(relation.Target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([]string, 0, len(fields)+1)
+	selected := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		columns = append(columns, field.Column)
+		selected = append(selected, "t."+field.Column)
+	}
+	selected = append(selected, "j."+relation.ForeignKey)
+
+	qb := m.NewQueryBuilder()
+	qb.WriteSelect(selected...).
+		WriteFrom(table+" t").
+		WriteInnerJoin(relation.JoinTable+" j", fmt.Sprintf("%s = %s", qb.Quote("j."+relation.JoinForeignKey), qb.Quote("t."+column))).
+		WriteWhere(qb.In("j."+relation.ForeignKey, keys))
+	query, args := qb.Build()
+
+	rows, err := l.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao executar a consulta: %w", err)
+	}
+	defer rows.Close()
+
+	related := make([]reflect.Value, 0)
+	ownerKeys := make([]string, 0)
+	for rows.Next() {
+		item := reflect.New(relation.Target).Elem()
+		var ownerKey interface{}
+		destinations := append(m.ScanDestinations(item, columns), &ownerKey)
+		if err := rows.Scan(destinations...); err != nil {
+			return nil, nil, fmt.Errorf("erro ao ler os valores: %w", err)
+		}
+		related = append(related, item)
+		ownerKeys = append(ownerKeys, KeyString(ownerKey))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("erro ao percorrer os resultados: %w", err)
+	}
+	return related, ownerKeys, nil
+}
+
+// KeyString converte o valor de uma chave na forma usada para agrupar os registros
+// relacionados, de modo que 7, int64(7) e []byte("7") sejam a mesma chave
+func KeyString(value interface{}) string {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
+
+// RelationKey normaliza o valor de uma coluna de chave para comparação, retornando a
+// chave na forma de KeyString e o valor enviado ao banco; ponteiros nil e valores nulos
+// não têm chave
+func RelationKey(field reflect.Value) (string, interface{}, bool) {
+	for field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return "", nil, false
+		}
+		field = field.Elem()
+	}
+	if valuer, ok := field.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil || value == nil {
+			return "", nil, false
+		}
+		return KeyString(value), value, true
+	}
+	return KeyString(field.Interface()), field.Interface(), true
+}
+
+// relationValue adapta a estrutura carregada ao tipo do campo (valor ou ponteiro)
+func relationValue(item reflect.Value, typ reflect.Type) reflect.Value {
+	if typ.Kind() == reflect.Ptr {
+		return item.Addr()
+	}
+	return item
+}
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rodolfocoding/night-orm/pkg/sqltest"
+)
+
+type Buyer struct {
+	ID   int    `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+func (b *Buyer) TableName() string { return "buyers" }
+
+type Product struct {
+	ID   int    `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+func (p *Product) TableName() string { return "products" }
+
+type PurchaseItem struct {
+	ID         int      `db:"id,primary"`
+	PurchaseID int      `db:"purchase_id"`
+	ProductID  int      `db:"product_id"`
+	Product    *Product `rel:"belongs_to,fk=product_id"`
+}
+
+func (i *PurchaseItem) TableName() string { return "purchase_items" }
+
+type Label struct {
+	ID   int    `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+func (l *Label) TableName() string { return "labels" }
+
+type Purchase struct {
+	ID      int            `db:"id,primary"`
+	BuyerID *int           `db:"buyer_id"`
+	Buyer   *Buyer         `rel:"belongs_to,fk=buyer_id"`
+	Items   []PurchaseItem `rel:"has_many,fk=purchase_id"`
+	Labels  []*Label       `rel:"many_to_many,join=purchase_labels,fk=purchase_id,target_fk=label_id"`
+}
+
+func (p *Purchase) TableName() string { return "purchases" }
+
+// buyerID retorna um ponteiro para a chave do comprador
+func buyerID(id int) *int {
+	return &id
+}
+
+func TestPreload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("BelongsTo", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// As chaves repetidas e nulas são enviadas uma única vez
+		rec.ExpectQuery(sqltest.Exact(`SELECT "id", "name" FROM "buyers" WHERE "id" IN ($1, $2)`)).
+			WithArgs(1, 2).
+			WillReturnRows(sqltest.NewRows("id", "name").AddRow(1, "ana").AddRow(2, "bia"))
+
+		purchases := []Purchase{{ID: 10, BuyerID: buyerID(1)}, {ID: 11, BuyerID: buyerID(2)}, {ID: 12, BuyerID: buyerID(1)}, {ID: 13}}
+		if err := Preload(ctx, rec.DB(), &purchases, "Buyer"); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		for i, expected := range []string{"ana", "bia", "ana"} {
+			if buyer := purchases[i].Buyer; buyer == nil || buyer.Name != expected {
+				t.Errorf("Expected purchase %d bought by %s, got %+v", purchases[i].ID, expected, buyer)
+			}
+		}
+		if purchases[3].Buyer != nil {
+			t.Errorf("Expected no buyer for a null key, got %+v", purchases[3].Buyer)
+		}
+	})
+
+	t.Run("HasManyNested", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		rec.ExpectQuery(sqltest.Exact(`SELECT "id", "purchase_id", "product_id" FROM "purchase_items" WHERE "purchase_id" IN ($1, $2)`)).
+			WithArgs(10, 11).
+			WillReturnRows(sqltest.NewRows("id", "purchase_id", "product_id").
+				AddRow(100, 10, 7).
+				AddRow(101, 10, 8).
+				AddRow(102, 11, 7))
+		rec.ExpectQuery(sqltest.Exact(`SELECT "id", "name" FROM "products" WHERE "id" IN ($1, $2)`)).
+			WithArgs(7, 8).
+			WillReturnRows(sqltest.NewRows("id", "name").AddRow(7, "pen").AddRow(8, "ink"))
+
+		purchases := []*Purchase{{ID: 10}, {ID: 11}}
+		if err := Preload(ctx, rec.DB(), &purchases, "Items.Product"); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+
+		// Os itens são copiados por valor depois de receber os produtos aninhados
+		if items := purchases[0].Items; len(items) != 2 || items[0].Product == nil || items[0].Product.Name != "pen" || items[1].Product.Name != "ink" {
+			t.Errorf("Expected items with pen and ink, got %+v", items)
+		}
+		if items := purchases[1].Items; len(items) != 1 || items[0].ID != 102 || items[0].Product.Name != "pen" {
+			t.Errorf("Expected item 102 with pen, got %+v", items)
+		}
+	})
+
+	t.Run("ManyToMany", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		rec.ExpectQuery(sqltest.Exact(`SELECT "t"."id", "t"."name", "j"."purchase_id" FROM "labels" "t" `+
+			`INNER JOIN "purchase_labels" "j" ON "j"."label_id" = "t"."id" WHERE "j"."purchase_id" IN ($1, $2)`)).
+			WithArgs(10, 11).
+			WillReturnRows(sqltest.NewRows("id", "name", "purchase_id").
+				AddRow(1, "gift", 10).
+				AddRow(2, "urgent", 10).
+				AddRow(1, "gift", 11))
+
+		purchase := Purchase{ID: 10}
+		other := Purchase{ID: 11}
+		purchases := []*Purchase{&purchase, &other}
+		if err := Preload(ctx, rec.DB(), purchases, "Labels"); err != nil {
+			t.Fatal(err)
+		}
+		if len(purchase.Labels) != 2 || purchase.Labels[1].Name != "urgent" {
+			t.Errorf("Expected labels gift and urgent, got %+v", purchase.Labels)
+		}
+		if len(other.Labels) != 1 || other.Labels[0].Name != "gift" {
+			t.Errorf("Expected label gift, got %+v", other.Labels)
+		}
+	})
+
+	t.Run("EmptyKeys", func(t *testing.T) {
+		rec := sqltest.New()
+		defer rec.Close()
+
+		// Sem chaves, nenhuma consulta é executada e os campos ficam vazios
+		purchases := []Purchase{{ID: 10, Buyer: &Buyer{ID: 9}}}
+		if err := Preload(ctx, rec.DB(), &purchases, "Buyer"); err != nil {
+			t.Fatal(err)
+		}
+		if err := Preload(ctx, rec.DB(), &[]Purchase{}, "Items", "Labels"); err != nil {
+			t.Fatal(err)
+		}
+		if statements := rec.Statements(); len(statements) != 0 {
+			t.Errorf("Expected no statements, got %v", statements)
+		}
+		if purchases[0].Buyer != nil {
+			t.Errorf("Expected the buyer to be cleared, got %+v", purchases[0].Buyer)
+		}
+	})
+}
@@ -5,22 +5,42 @@ import (
 	"strings"
 )
 
-// QueryBuilder é um construtor de consultas SQL
+// QueryBuilder é um construtor de consultas SQL. Os nomes de tabelas e colunas passados
+// aos métodos Write* são citados segundo o dialeto; condições de WHERE, AND e OR são
+// escritas como recebidas e devem citar seus identificadores com Quote.
 type QueryBuilder struct {
 	query      strings.Builder
 	args       []interface{}
 	paramIndex int
+	dialect    Dialect
 }
 
-// NewQueryBuilder cria um novo construtor de consultas
+// NewQueryBuilder cria um novo construtor de consultas com o dialeto do PostgreSQL
 func NewQueryBuilder() *QueryBuilder {
+	return NewQueryBuilderWith(PostgresDialect{})
+}
+
+// NewQueryBuilderWith cria um novo construtor de consultas com o dialeto informado
+func NewQueryBuilderWith(dialect Dialect) *QueryBuilder {
 	return &QueryBuilder{
 		query:      strings.Builder{},
 		args:       make([]interface{}, 0),
 		paramIndex: 1,
+		dialect:    dialect,
 	}
 }
 
+// Dialect retorna o dialeto do construtor
+func (qb *QueryBuilder) Dialect() Dialect {
+	return qb.dialect
+}
+
+// Quote cita um nome de tabela ou coluna segundo o dialeto, separando as partes de nomes
+// qualificados como "billing.invoices", para uso em condições
+func (qb *QueryBuilder) Quote(name string) string {
+	return quoteQualified(qb.dialect.QuoteIdentifier, name)
+}
+
 // Reset limpa o construtor de consultas
 func (qb *QueryBuilder) Reset() {
 	qb.query.Reset()
@@ -31,11 +51,16 @@ func (qb *QueryBuilder) Reset() {
 // AddParam adiciona um parâmetro à consulta e retorna o placeholder
 func (qb *QueryBuilder) AddParam(value interface{}) string {
 	qb.args = append(qb.args, value)
-	placeholder := fmt.Sprintf("$%d", qb.paramIndex)
+	placeholder := qb.dialect.Placeholder(qb.paramIndex)
 	qb.paramIndex++
 	return placeholder
 }
 
+// AddArrayParam adiciona um slice como parâmetro de array (pq.Array) e retorna o placeholder
+func (qb *QueryBuilder) AddArrayParam(values interface{}) string {
+	return qb.AddParam(ArrayValue(values))
+}
+
 // Write adiciona texto à consulta
 func (qb *QueryBuilder) Write(s string) *QueryBuilder {
 	qb.query.WriteString(s)
@@ -52,21 +77,37 @@ func (qb *QueryBuilder) WriteWithParams(format string, args ...interface{}) *Que
 	return qb
 }
 
-// WriteSelect adiciona uma cláusula SELECT à consulta
+// WriteSelect adiciona uma cláusula SELECT com as colunas informadas. Identificadores
+// simples ou qualificados, como "id", "u.id" ou "u.*", são citados; expressões como
+// "COUNT(*)", "lower(name)" ou "id AS x" são escritas como recebidas e devem citar seus
+// identificadores com Quote.
 func (qb *QueryBuilder) WriteSelect(columns ...string) *QueryBuilder {
 	qb.Write("SELECT ")
 	if len(columns) == 0 {
 		qb.Write("*")
 	} else {
-		qb.Write(strings.Join(columns, ", "))
+		qb.Write(strings.Join(quoteAll(columns, func(column string) string {
+			return quoteSelect(qb.dialect.QuoteIdentifier, column)
+		}), ", "))
 	}
 	return qb
 }
 
-// WriteFrom adiciona uma cláusula FROM à consulta
+// WriteFrom adiciona uma cláusula FROM à consulta. A tabela pode ser qualificada pelo
+// esquema e seguida de um apelido, como "billing.invoices i"; outras referências, como
+// "users u JOIN orders o ON ...", são escritas como recebidas.
 func (qb *QueryBuilder) WriteFrom(table string) *QueryBuilder {
 	qb.Write(" FROM ")
-	qb.Write(table)
+	qb.Write(quoteTable(qb.dialect.QuoteIdentifier, table))
+	return qb
+}
+
+// WriteInnerJoin adiciona uma cláusula INNER JOIN à consulta
+func (qb *QueryBuilder) WriteInnerJoin(table, condition string) *QueryBuilder {
+	qb.Write(" INNER JOIN ")
+	qb.Write(quoteTable(qb.dialect.QuoteIdentifier, table))
+	qb.Write(" ON ")
+	qb.Write(condition)
 	return qb
 }
 
@@ -88,11 +129,42 @@ func (qb *QueryBuilder) WriteOr(condition string, args ...interface{}) *QueryBui
 	return qb.WriteWithParams(condition, args...)
 }
 
-// WriteOrderBy adiciona uma cláusula ORDER BY à consulta
+// In retorna a condição "coluna IN ($1, $2, ...)" para ser usada em WHERE, AND ou OR
+func (qb *QueryBuilder) In(column string, values []interface{}) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = qb.AddParam(value)
+	}
+	return fmt.Sprintf("%s IN (%s)", qb.Quote(column), strings.Join(placeholders, ", "))
+}
+
+// Any retorna a condição "coluna operador ANY($n)" para ser usada em WHERE, AND ou OR
+func (qb *QueryBuilder) Any(column, operator string, values interface{}) string {
+	return fmt.Sprintf("%s %s ANY(%s)", qb.Quote(column), operator, qb.AddArrayParam(values))
+}
+
+// All retorna a condição "coluna operador ALL($n)" para ser usada em WHERE, AND ou OR
+func (qb *QueryBuilder) All(column, operator string, values interface{}) string {
+	return fmt.Sprintf("%s %s ALL(%s)", qb.Quote(column), operator, qb.AddArrayParam(values))
+}
+
+// Overlaps retorna a condição "coluna && $n", verdadeira quando os arrays têm elementos em comum
+func (qb *QueryBuilder) Overlaps(column string, values interface{}) string {
+	return fmt.Sprintf("%s && %s", qb.Quote(column), qb.AddArrayParam(values))
+}
+
+// Contains retorna a condição "coluna @> $n", verdadeira quando o array da coluna contém todos os valores
+func (qb *QueryBuilder) Contains(column string, values interface{}) string {
+	return fmt.Sprintf("%s @> %s", qb.Quote(column), qb.AddArrayParam(values))
+}
+
+// WriteOrderBy adiciona uma cláusula ORDER BY à consulta. Os itens formados por uma
+// coluna seguida opcionalmente de ASC ou DESC e de NULLS FIRST ou NULLS LAST são
+// citados; expressões como "lower(name)" são escritas como recebidas.
 func (qb *QueryBuilder) WriteOrderBy(columns ...string) *QueryBuilder {
 	if len(columns) > 0 {
 		qb.Write(" ORDER BY ")
-		qb.Write(strings.Join(columns, ", "))
+		qb.Write(strings.Join(quoteAll(columns, qb.quoteOrder), ", "))
 	}
 	return qb
 }
@@ -113,10 +185,19 @@ func (qb *QueryBuilder) WriteOffset(offset int) *QueryBuilder {
 	return qb
 }
 
+// WritePagination adiciona as cláusulas de limite e deslocamento na sintaxe do dialeto;
+// valores zero são omitidos
+func (qb *QueryBuilder) WritePagination(limit, offset int) *QueryBuilder {
+	if pagination := qb.dialect.Pagination(limit, offset); pagination != "" {
+		qb.Write(" " + pagination)
+	}
+	return qb
+}
+
 // WriteInsert adiciona uma cláusula INSERT à consulta
 func (qb *QueryBuilder) WriteInsert(table string, columns []string, values []interface{}) *QueryBuilder {
-	qb.Write(fmt.Sprintf("INSERT INTO %s (", table))
-	qb.Write(strings.Join(columns, ", "))
+	qb.Write(fmt.Sprintf("INSERT INTO %s (", qb.Quote(table)))
+	qb.Write(strings.Join(quoteAll(columns, qb.dialect.QuoteIdentifier), ", "))
 	qb.Write(") VALUES (")
 
 	placeholders := make([]string, len(values))
@@ -130,32 +211,46 @@ func (qb *QueryBuilder) WriteInsert(table string, columns []string, values []int
 
 // WriteUpdate adiciona uma cláusula UPDATE à consulta
 func (qb *QueryBuilder) WriteUpdate(table string, columns []string, values []interface{}) *QueryBuilder {
-	qb.Write(fmt.Sprintf("UPDATE %s SET ", table))
+	qb.Write(fmt.Sprintf("UPDATE %s SET ", qb.Quote(table)))
 
 	for i := 0; i < len(columns); i++ {
 		if i > 0 {
 			qb.Write(", ")
 		}
-		qb.Write(fmt.Sprintf("%s = %s", columns[i], qb.AddParam(values[i])))
+		qb.Write(fmt.Sprintf("%s = %s", qb.dialect.QuoteIdentifier(columns[i]), qb.AddParam(values[i])))
 	}
 	return qb
 }
 
 // WriteDelete adiciona uma cláusula DELETE à consulta
 func (qb *QueryBuilder) WriteDelete(table string) *QueryBuilder {
-	qb.Write(fmt.Sprintf("DELETE FROM %s", table))
+	qb.Write(fmt.Sprintf("DELETE FROM %s", qb.Quote(table)))
+	return qb
+}
+
+// WriteUpsert adiciona a cláusula de conflito do dialeto após um INSERT, atualizando as
+// colunas de update quando a linha já existir; sem colunas de update o conflito é ignorado
+func (qb *QueryBuilder) WriteUpsert(conflict, update []string) *QueryBuilder {
+	qb.Write(" ")
+	qb.Write(qb.dialect.Upsert(conflict, update))
 	return qb
 }
 
-// WriteReturning adiciona uma cláusula RETURNING à consulta
+// WriteReturning adiciona uma cláusula RETURNING à consulta. Use apenas quando
+// Dialect().Returning() for verdadeiro; caso contrário, use sql.Result.LastInsertId.
 func (qb *QueryBuilder) WriteReturning(columns ...string) *QueryBuilder {
 	if len(columns) > 0 {
 		qb.Write(" RETURNING ")
-		qb.Write(strings.Join(columns, ", "))
+		qb.Write(strings.Join(quoteAll(columns, qb.dialect.QuoteIdentifier), ", "))
 	}
 	return qb
 }
 
+// quoteOrder cita um item de ORDER BY segundo o dialeto
+func (qb *QueryBuilder) quoteOrder(item string) string {
+	return quoteOrder(qb.dialect.QuoteIdentifier, item)
+}
+
 // Build retorna a consulta SQL e os argumentos
 func (qb *QueryBuilder) Build() (string, []interface{}) {
 	return qb.query.String(), qb.args
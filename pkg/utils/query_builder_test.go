@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/lib/pq"
 )
 
 func TestQueryBuilder(t *testing.T) {
@@ -9,7 +13,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect("id", "name", "email")
 		query, _ := qb.Build()
-		expected := "SELECT id, name, email"
+		expected := `SELECT "id", "name", "email"`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -29,7 +33,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect().WriteFrom("users")
 		query, _ := qb.Build()
-		expected := "SELECT * FROM users"
+		expected := `SELECT * FROM "users"`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -39,7 +43,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect().WriteFrom("users").WriteWhere("id = %s", 1)
 		query, args := qb.Build()
-		expected := "SELECT * FROM users WHERE id = $1"
+		expected := `SELECT * FROM "users" WHERE id = $1`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -54,7 +58,7 @@ func TestQueryBuilder(t *testing.T) {
 			WriteWhere("id = %s", 1).
 			WriteAnd("name = %s", "John")
 		query, args := qb.Build()
-		expected := "SELECT * FROM users WHERE id = $1 AND name = $2"
+		expected := `SELECT * FROM "users" WHERE id = $1 AND name = $2`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -69,7 +73,7 @@ func TestQueryBuilder(t *testing.T) {
 			WriteWhere("id = %s", 1).
 			WriteOr("id = %s", 2)
 		query, args := qb.Build()
-		expected := "SELECT * FROM users WHERE id = $1 OR id = $2"
+		expected := `SELECT * FROM "users" WHERE id = $1 OR id = $2`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -82,7 +86,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect().WriteFrom("users").WriteOrderBy("name", "id DESC")
 		query, _ := qb.Build()
-		expected := "SELECT * FROM users ORDER BY name, id DESC"
+		expected := `SELECT * FROM "users" ORDER BY "name", "id" DESC`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -92,7 +96,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect().WriteFrom("users").WriteLimit(10)
 		query, _ := qb.Build()
-		expected := "SELECT * FROM users LIMIT 10"
+		expected := `SELECT * FROM "users" LIMIT 10`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -102,7 +106,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect().WriteFrom("users").WriteLimit(10).WriteOffset(5)
 		query, _ := qb.Build()
-		expected := "SELECT * FROM users LIMIT 10 OFFSET 5"
+		expected := `SELECT * FROM "users" LIMIT 10 OFFSET 5`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -114,7 +118,7 @@ func TestQueryBuilder(t *testing.T) {
 		values := []interface{}{"John", "john@example.com"}
 		qb.WriteInsert("users", columns, values)
 		query, args := qb.Build()
-		expected := "INSERT INTO users (name, email) VALUES ($1, $2)"
+		expected := `INSERT INTO "users" ("name", "email") VALUES ($1, $2)`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -129,7 +133,7 @@ func TestQueryBuilder(t *testing.T) {
 		values := []interface{}{"John", "john@example.com"}
 		qb.WriteUpdate("users", columns, values).WriteWhere("id = %s", 1)
 		query, args := qb.Build()
-		expected := "UPDATE users SET name = $1, email = $2 WHERE id = $3"
+		expected := `UPDATE "users" SET "name" = $1, "email" = $2 WHERE id = $3`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -142,7 +146,7 @@ func TestQueryBuilder(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteDelete("users").WriteWhere("id = %s", 1)
 		query, args := qb.Build()
-		expected := "DELETE FROM users WHERE id = $1"
+		expected := `DELETE FROM "users" WHERE id = $1`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -156,12 +160,32 @@ func TestQueryBuilder(t *testing.T) {
 		qb.WriteInsert("users", []string{"name"}, []interface{}{"John"}).
 			WriteReturning("id", "created_at")
 		query, _ := qb.Build()
-		expected := "INSERT INTO users (name) VALUES ($1) RETURNING id, created_at"
+		expected := `INSERT INTO "users" ("name") VALUES ($1) RETURNING "id", "created_at"`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
 	})
 
+	t.Run("ArrayConditions", func(t *testing.T) {
+		qb := NewQueryBuilder()
+		qb.WriteSelect().WriteFrom("posts").
+			WriteWhere(qb.Any("id", "=", []int64{1, 2})).
+			WriteAnd(qb.All("score", ">", []int{10})).
+			WriteAnd(qb.Overlaps("tags", []string{"go"})).
+			WriteOr(qb.Contains("tags", []string{"go", "sql"}))
+		query, args := qb.Build()
+		expected := `SELECT * FROM "posts" WHERE "id" = ANY($1) AND "score" > ALL($2) AND "tags" && $3 OR "tags" @> $4`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+		if len(args) != 4 {
+			t.Fatalf("Expected 4 args, got %v", args)
+		}
+		if _, ok := args[2].(*pq.StringArray); !ok {
+			t.Errorf("Expected array args to be wrapped with pq.Array, got %T", args[2])
+		}
+	})
+
 	t.Run("Reset", func(t *testing.T) {
 		qb := NewQueryBuilder()
 		qb.WriteSelect().WriteFrom("users")
@@ -185,7 +209,7 @@ func TestQueryBuilder(t *testing.T) {
 			WriteLimit(10).
 			WriteOffset(20)
 		query, args := qb.Build()
-		expected := "SELECT u.id, u.name, u.email FROM users u WHERE u.active = $1 AND u.created_at > $2 ORDER BY u.name ASC LIMIT 10 OFFSET 20"
+		expected := `SELECT "u"."id", "u"."name", "u"."email" FROM "users" "u" WHERE u.active = $1 AND u.created_at > $2 ORDER BY "u"."name" ASC LIMIT 10 OFFSET 20`
 		if query != expected {
 			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
 		}
@@ -193,4 +217,175 @@ func TestQueryBuilder(t *testing.T) {
 			t.Errorf("Expected args to be [true, '2023-01-01'], got %v", args)
 		}
 	})
+
+	t.Run("QuotedIdentifiers", func(t *testing.T) {
+		qb := NewQueryBuilder()
+		qb.WriteSelect("order", "user").
+			WriteFrom("billing.invoices AS i").
+			WriteWhere(qb.In("i.status", []interface{}{"open"})).
+			WriteOrderBy("createdAt desc nulls last")
+		query, _ := qb.Build()
+		expected := `SELECT "order", "user" FROM "billing"."invoices" "i" WHERE "i"."status" IN ($1) ORDER BY "createdAt" DESC NULLS LAST`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+	})
+
+	t.Run("SelectExpressions", func(t *testing.T) {
+		qb := NewQueryBuilder()
+		qb.WriteSelect("u.*", "COUNT(*)", "id AS x", "lower(name)", "sum(o.total) AS total").
+			WriteFrom("users u")
+		query, _ := qb.Build()
+		expected := `SELECT "u".*, COUNT(*), id AS x, lower(name), sum(o.total) AS total FROM "users" "u"`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+	})
+
+	t.Run("OrderByExpressions", func(t *testing.T) {
+		qb := NewQueryBuilder()
+		qb.WriteSelect().WriteFrom("users").
+			WriteOrderBy("lower(name)", "created_at::date DESC", "u.id ASC", "name COLLATE \"C\"")
+		query, _ := qb.Build()
+		expected := `SELECT * FROM "users" ORDER BY lower(name), created_at::date DESC, "u"."id" ASC, name COLLATE "C"`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+	})
+
+	t.Run("JoinedFrom", func(t *testing.T) {
+		qb := NewQueryBuilder()
+		qb.WriteSelect("u.id", "o.total").
+			WriteFrom("users u JOIN orders o ON o.user_id = u.id").
+			WriteInnerJoin("(SELECT 1) s", "true")
+		query, _ := qb.Build()
+		expected := `SELECT "u"."id", "o"."total" FROM users u JOIN orders o ON o.user_id = u.id INNER JOIN (SELECT 1) s ON true`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+	})
+
+	t.Run("InjectedIdentifiers", func(t *testing.T) {
+		// Nomes passados a WriteInsert e Quote são sempre citados
+		qb := NewQueryBuilder()
+		qb.WriteInsert(`users"; DROP TABLE users; --`, []string{`name"; DELETE FROM users`}, []interface{}{"x"})
+		query, _ := qb.Build()
+		expected := `INSERT INTO "users""; DROP TABLE users; --" ("name""; DELETE FROM users") VALUES ($1)`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+	})
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	identifiers := map[string]string{
+		"users":        `"users"`,
+		"CreatedAt":    `"CreatedAt"`,
+		`my"table`:     `"my""table"`,
+		"name\x00tail": `"name"`,
+	}
+	for name, expected := range identifiers {
+		if got := QuoteIdentifier(name); got != expected {
+			t.Errorf("Expected QuoteIdentifier(%q) = %s, got %s", name, expected, got)
+		}
+	}
+
+	qualified := map[string]string{
+		"billing.invoices": `"billing"."invoices"`,
+		"u.*":              `"u".*`,
+		"*":                "*",
+	}
+	for name, expected := range qualified {
+		if got := QuoteQualified(name); got != expected {
+			t.Errorf("Expected QuoteQualified(%q) = %s, got %s", name, expected, got)
+		}
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	if err := ValidateColumns(&PlainUser{}, "id", "email"); err != nil {
+		t.Errorf("Expected mapped columns to be valid, got %v", err)
+	}
+
+	err := ValidateColumns(&PlainUser{}, "email", "password")
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("Expected ErrUnknownColumn, got %v", err)
+	}
+
+	// As colunas seguem a estratégia de nomes do Mapper
+	mapper := NewMapper(prefixNaming{"app_"})
+	if err := mapper.ValidateColumns(NamingProfile{}, "CREATED_AT"); err != nil {
+		t.Errorf("Expected CREATED_AT to be valid, got %v", err)
+	}
+	if err := mapper.ValidateColumns(NamingProfile{}, "created_at"); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("Expected ErrUnknownColumn for created_at, got %v", err)
+	}
+}
+
+// questionDialect imita um banco com parâmetros "?", crases e sem RETURNING
+type questionDialect struct{}
+
+func (questionDialect) Name() string                 { return "question" }
+func (questionDialect) Placeholder(index int) string { return "?" }
+func (questionDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+func (questionDialect) Returning() bool { return false }
+func (questionDialect) Upsert(conflict, update []string) string {
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(update, ", ")
+}
+func (questionDialect) Pagination(limit, offset int) string {
+	return LimitOffset(limit, offset, "18446744073709551615")
+}
+
+func TestDialect(t *testing.T) {
+	t.Run("Postgres", func(t *testing.T) {
+		qb := NewQueryBuilder()
+		qb.WriteInsert("users", []string{"id", "name"}, []interface{}{1, "John"}).
+			WriteUpsert([]string{"id"}, []string{"name"})
+		query, _ := qb.Build()
+		expected := `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+
+		dialect := PostgresDialect{}
+		if clause := dialect.Upsert([]string{"id"}, nil); clause != `ON CONFLICT ("id") DO NOTHING` {
+			t.Errorf("Expected DO NOTHING clause, got '%s'", clause)
+		}
+		if clause := dialect.Pagination(0, 5); clause != "OFFSET 5" {
+			t.Errorf("Expected OFFSET without LIMIT, got '%s'", clause)
+		}
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		qb := NewQueryBuilderWith(questionDialect{})
+		qb.WriteSelect("u.order").WriteFrom("shop.users u").
+			WriteWhere(qb.Quote("u.id")+" = %s", 1).
+			WriteAnd(qb.In("u.status", []interface{}{"a", "b"})).
+			WriteOrderBy("u.order DESC").
+			WritePagination(0, 20)
+		query, args := qb.Build()
+		expected := "SELECT `u`.`order` FROM `shop`.`users` `u` WHERE `u`.`id` = ? AND `u`.`status` IN (?, ?) " +
+			"ORDER BY `u`.`order` DESC LIMIT 18446744073709551615 OFFSET 20"
+		if query != expected {
+			t.Errorf("Expected query to be '%s', got '%s'", expected, query)
+		}
+		if len(args) != 3 {
+			t.Errorf("Expected 3 args, got %v", args)
+		}
+		if qb.Dialect().Returning() {
+			t.Error("Expected dialect without RETURNING")
+		}
+	})
+
+	t.Run("Mapper", func(t *testing.T) {
+		mapper := DefaultMapper.WithDialect(questionDialect{}).WithSchema("shop")
+		if _, ok := mapper.NewQueryBuilder().Dialect().(questionDialect); !ok {
+			t.Errorf("Expected mapper query builder to use its dialect")
+		}
+		if _, ok := DefaultMapper.Dialect().(PostgresDialect); !ok {
+			t.Errorf("Expected PostgresDialect as default, got %T", DefaultMapper.Dialect())
+		}
+	})
 }
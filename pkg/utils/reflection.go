@@ -3,15 +3,42 @@ package utils
 import (
 	"errors"
 	"reflect"
+	"sort"
 	"strings"
 )
 
 // GetStructFields retorna um mapa de campos da estrutura com seus nomes e valores
 func GetStructFields(obj interface{}) (map[string]interface{}, error) {
+	return DefaultMapper.StructFields(obj)
+}
+
+// StructFields retorna um mapa das colunas da estrutura com seus valores
+func (m *Mapper) StructFields(obj interface{}) (map[string]interface{}, error) {
 	if obj == nil {
 		return nil, errors.New("objeto não pode ser nil")
 	}
 
+	// Usa os acessores gerados, quando existirem, em vez de reflexão; os slices mapeados
+	// para arrays são envolvidos pela mesma regra dos campos lidos por reflexão
+	if accessor, ok := obj.(FieldAccessor); ok {
+		fields := accessor.FieldValues()
+		for _, info := range m.Fields(reflect.TypeOf(obj)) {
+			if value, ok := fields[info.Column]; ok && info.IsArray() {
+				array, err := m.arrayValue(value, info)
+				if err != nil {
+					return nil, err
+				}
+				fields[info.Column] = array
+			}
+		}
+		if converter, ok := m.dialect.(TypeConverter); ok {
+			for column, value := range fields {
+				fields[column] = converter.BindValue(value)
+			}
+		}
+		return fields, nil
+	}
+
 	val := reflect.ValueOf(obj)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
@@ -21,44 +48,54 @@ func GetStructFields(obj interface{}) (map[string]interface{}, error) {
 		return nil, errors.New("objeto deve ser uma estrutura ou um ponteiro para uma estrutura")
 	}
 
+	converter, convert := m.dialect.(TypeConverter)
 	fields := make(map[string]interface{})
-	typ := val.Type()
-
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-
-		// Ignora campos não exportados
-		if !fieldType.IsExported() {
-			continue
+	for _, info := range m.Fields(val.Type()) {
+		// Extrai o valor do campo, envolvendo slices com pq.Array nos dialetos com arrays
+		value, err := m.fieldValue(val.FieldByIndex(info.Index), info)
+		if err != nil {
+			return nil, err
 		}
-
-		// Verifica a tag "db" para o nome da coluna
-		tag := fieldType.Tag.Get("db")
-		if tag == "-" {
-			continue // Ignora campos marcados com db:"-"
+		if convert {
+			value = converter.BindValue(value)
 		}
+		fields[info.Column] = value
+	}
 
-		// Se não houver tag, usa o nome do campo em minúsculas
-		columnName := tag
-		if columnName == "" {
-			columnName = strings.ToLower(fieldType.Name)
-		}
+	return fields, nil
+}
 
-		// Extrai o valor do campo
-		var fieldValue interface{}
-		if field.CanInterface() {
-			fieldValue = field.Interface()
+// OrderedColumns retorna as colunas de um mapa retornado por StructFields na ordem de
+// declaração dos campos da estrutura, seguidas das demais em ordem alfabética, para que
+// as consultas geradas sejam determinísticas
+func (m *Mapper) OrderedColumns(obj interface{}, fields map[string]interface{}) []string {
+	columns := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, info := range m.Fields(reflect.TypeOf(obj)) {
+		if _, ok := fields[info.Column]; ok && !seen[info.Column] {
+			columns = append(columns, info.Column)
+			seen[info.Column] = true
 		}
-
-		fields[columnName] = fieldValue
 	}
 
-	return fields, nil
+	// Colunas fornecidas apenas pelos acessores gerados
+	rest := make([]string, 0)
+	for column := range fields {
+		if !seen[column] {
+			rest = append(rest, column)
+		}
+	}
+	sort.Strings(rest)
+	return append(columns, rest...)
 }
 
 // SetStructField define o valor de um campo em uma estrutura
 func SetStructField(obj interface{}, fieldName string, value interface{}) error {
+	return DefaultMapper.SetField(obj, fieldName, value)
+}
+
+// SetField define o valor do campo mapeado para a coluna informada
+func (m *Mapper) SetField(obj interface{}, fieldName string, value interface{}) error {
 	if obj == nil {
 		return errors.New("objeto não pode ser nil")
 	}
@@ -73,31 +110,29 @@ func SetStructField(obj interface{}, fieldName string, value interface{}) error
 		return errors.New("objeto deve ser um ponteiro para uma estrutura")
 	}
 
-	typ := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-
+	for _, info := range m.Fields(val.Type()) {
 		// Verifica se o campo corresponde ao nome fornecido
-		tag := fieldType.Tag.Get("db")
-		if tag == fieldName || (tag == "" && strings.ToLower(fieldType.Name) == fieldName) {
-			if !field.CanSet() {
-				return errors.New("campo não pode ser definido")
-			}
+		if info.Column != fieldName {
+			continue
+		}
 
-			fieldVal := reflect.ValueOf(value)
-			if field.Type() != fieldVal.Type() {
-				// Tenta converter o valor para o tipo do campo
-				if fieldVal.Type().ConvertibleTo(field.Type()) {
-					fieldVal = fieldVal.Convert(field.Type())
-				} else {
-					return errors.New("tipo de valor incompatível com o tipo do campo")
-				}
-			}
+		field := val.FieldByIndex(info.Index)
+		if !field.CanSet() {
+			return errors.New("campo não pode ser definido")
+		}
 
-			field.Set(fieldVal)
-			return nil
+		fieldVal := reflect.ValueOf(value)
+		if field.Type() != fieldVal.Type() {
+			// Tenta converter o valor para o tipo do campo
+			if fieldVal.Type().ConvertibleTo(field.Type()) {
+				fieldVal = fieldVal.Convert(field.Type())
+			} else {
+				return errors.New("tipo de valor incompatível com o tipo do campo")
+			}
 		}
+
+		field.Set(fieldVal)
+		return nil
 	}
 
 	return errors.New("campo não encontrado")
@@ -109,18 +144,23 @@ func GetTagName(structType reflect.Type, fieldName, tagName string) string {
 	if !ok {
 		return ""
 	}
-	
+
 	tag := field.Tag.Get(tagName)
 	if tag == "" {
 		return ""
 	}
-	
+
 	parts := strings.Split(tag, ",")
 	return parts[0]
 }
 
 // GetPrimaryKeyField retorna o nome e o valor do campo marcado como chave primária
 func GetPrimaryKeyField(obj interface{}) (string, interface{}, error) {
+	return DefaultMapper.primaryKeyField(obj)
+}
+
+// primaryKeyField retorna a coluna e o valor do campo com a opção "primary" ou do campo ID
+func (m *Mapper) primaryKeyField(obj interface{}) (string, interface{}, error) {
 	if obj == nil {
 		return "", nil, errors.New("objeto não pode ser nil")
 	}
@@ -134,50 +174,60 @@ func GetPrimaryKeyField(obj interface{}) (string, interface{}, error) {
 		return "", nil, errors.New("objeto deve ser uma estrutura ou um ponteiro para uma estrutura")
 	}
 
-	typ := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-
+	fields := m.Fields(val.Type())
+	for _, info := range fields {
 		// Verifica a tag "db" para identificar a chave primária
-		tag := fieldType.Tag.Get("db")
-		if strings.Contains(tag, ",primary") {
-			// Extrai o nome da coluna da tag
-			columnName := strings.Split(tag, ",")[0]
-			if columnName == "" {
-				columnName = strings.ToLower(fieldType.Name)
-			}
-
-			// Extrai o valor do campo
-			var fieldValue interface{}
-			if field.CanInterface() {
-				fieldValue = field.Interface()
-			}
-
-			return columnName, fieldValue, nil
+		if info.IsPrimary() {
+			return info.Column, FieldValue(val.FieldByIndex(info.Index), info), nil
 		}
 	}
 
 	// Se não encontrar uma tag de chave primária, procura por um campo chamado "ID" ou "Id"
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-
-		if strings.ToLower(fieldType.Name) == "id" {
-			tag := fieldType.Tag.Get("db")
-			columnName := tag
-			if columnName == "" || columnName == "-" {
-				columnName = "id"
-			}
-
-			var fieldValue interface{}
-			if field.CanInterface() {
-				fieldValue = field.Interface()
-			}
-
-			return columnName, fieldValue, nil
+	for _, info := range fields {
+		if strings.ToLower(info.Name) == "id" {
+			return info.Column, FieldValue(val.FieldByIndex(info.Index), info), nil
 		}
 	}
 
 	return "", nil, errors.New("chave primária não encontrada")
 }
+
+// ScanDestinations retorna os destinos de Scan de uma estrutura para as colunas informadas.
+// Colunas sem campo correspondente são descartadas.
+func ScanDestinations(val reflect.Value, columns []string) []interface{} {
+	return DefaultMapper.ScanDestinations(val, columns)
+}
+
+// ScanDestinations retorna os destinos de Scan de uma estrutura para as colunas
+// informadas, resolvendo os campos com a estratégia de nomes do Mapper
+func (m *Mapper) ScanDestinations(val reflect.Value, columns []string) []interface{} {
+	accessor, hasAccessor := fieldAccessor(val)
+	converter, convert := m.dialect.(TypeConverter)
+	fields := m.Fields(val.Type())
+	destinations := make([]interface{}, len(columns))
+	for i, column := range columns {
+		var destination interface{}
+		info, found := FindField(fields, column)
+		if hasAccessor {
+			destination = accessor.FieldPointer(column)
+		}
+		if destination == nil && found {
+			destination = val.FieldByIndex(info.Index).Addr().Interface()
+		}
+		if destination != nil && found && info.IsArray() {
+			destination = m.arrayDestination(destination, info)
+		}
+		if destination == nil {
+			// Usa um destino descartável se o campo não for encontrado
+			var discard interface{}
+			destinations[i] = &discard
+			continue
+		}
+
+		if convert {
+			destination = converter.ScanDestination(destination)
+		}
+		destinations[i] = destination
+	}
+	return destinations
+}
@@ -1,16 +1,20 @@
 package utils
 
 import (
+	"database/sql"
+	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/lib/pq"
 )
 
 type TestStruct struct {
-	ID        int    `db:"id,primary"`
-	Name      string `db:"name"`
-	Email     string `db:"email"`
-	Ignored   string `db:"-"`
-	NoTag     string
+	ID         int    `db:"id,primary"`
+	Name       string `db:"name"`
+	Email      string `db:"email"`
+	Ignored    string `db:"-"`
+	NoTag      string
 	unexported string
 }
 
@@ -47,8 +51,8 @@ func TestGetStructFields(t *testing.T) {
 		t.Errorf("Expected field 'email' with value 'test@example.com', got %v", email)
 	}
 
-	if notag, ok := fields["notag"]; !ok || notag != "No tag field" {
-		t.Errorf("Expected field 'notag' with value 'No tag field', got %v", notag)
+	if notag, ok := fields["no_tag"]; !ok || notag != "No tag field" {
+		t.Errorf("Expected field 'no_tag' with value 'No tag field', got %v", notag)
 	}
 
 	// Verifica se os campos ignorados não estão presentes
@@ -78,7 +82,7 @@ func TestSetStructField(t *testing.T) {
 	}
 
 	// Testa definir um campo sem tag
-	err = SetStructField(testStruct, "notag", "Updated NoTag")
+	err = SetStructField(testStruct, "no_tag", "Updated NoTag")
 	if err != nil {
 		t.Fatalf("SetStructField returned error: %v", err)
 	}
@@ -156,3 +160,134 @@ func TestGetTagName(t *testing.T) {
 		t.Errorf("Expected tag name to be empty, got '%s'", tagName)
 	}
 }
+
+type ArrayStruct struct {
+	ID     int      `db:"id,primary"`
+	Tags   []string `db:"tags"`
+	Scores [3]int   `db:"scores,array"`
+	Data   []byte   `db:"data"`
+}
+
+func TestArrayFields(t *testing.T) {
+	arrayStruct := ArrayStruct{ID: 1, Tags: []string{"a", "b"}, Data: []byte("raw")}
+
+	fields, err := GetStructFields(arrayStruct)
+	if err != nil {
+		t.Fatalf("GetStructFields returned error: %v", err)
+	}
+
+	// Slices são envolvidos automaticamente com pq.Array
+	if _, ok := fields["tags"].(*pq.StringArray); !ok {
+		t.Errorf("Expected field 'tags' to be wrapped with pq.Array, got %T", fields["tags"])
+	}
+
+	// A opção "array" força o uso de pq.Array
+	if _, ok := fields["scores"].(pq.GenericArray); !ok {
+		t.Errorf("Expected field 'scores' to be wrapped with pq.Array, got %T", fields["scores"])
+	}
+
+	// Slices de bytes são mantidos como estão
+	if _, ok := fields["data"].([]byte); !ok {
+		t.Errorf("Expected field 'data' to remain []byte, got %T", fields["data"])
+	}
+
+	// Os destinos de Scan também são envolvidos
+	val := reflect.ValueOf(&arrayStruct).Elem()
+	destinations := ScanDestinations(val, []string{"tags", "unknown"})
+	if _, ok := destinations[0].(*pq.StringArray); !ok {
+		t.Errorf("Expected scan destination for 'tags' to be wrapped with pq.Array, got %T", destinations[0])
+	}
+	if _, ok := destinations[1].(*interface{}); !ok {
+		t.Errorf("Expected a disposable destination for unknown column, got %T", destinations[1])
+	}
+
+	t.Run("UnsupportedDialect", func(t *testing.T) {
+		// Dialetos sem arrays rejeitam os campos em vez de gravar literais do PostgreSQL
+		mapper := NewMapper(nil).WithDialect(questionDialect{})
+		if _, err := mapper.StructFields(arrayStruct); !errors.Is(err, ErrArrayUnsupported) {
+			t.Errorf("Expected ErrArrayUnsupported, got %v", err)
+		}
+
+		destinations := mapper.ScanDestinations(val, []string{"tags", "data"})
+		scanner, ok := destinations[0].(sql.Scanner)
+		if !ok {
+			t.Fatalf("Expected a failing scanner for 'tags', got %T", destinations[0])
+		}
+		if err := scanner.Scan("{a,b}"); !errors.Is(err, ErrArrayUnsupported) {
+			t.Errorf("Expected ErrArrayUnsupported, got %v", err)
+		}
+		if destinations[1] != &arrayStruct.Data {
+			t.Errorf("Expected 'data' scanned into the field, got %T", destinations[1])
+		}
+	})
+}
+
+func TestParseTag(t *testing.T) {
+	name, options := ParseTag("price, type=numeric(10,2), notnull ,default=0")
+	if name != "price" {
+		t.Errorf("Expected column name to be 'price', got '%s'", name)
+	}
+	if options.Get("type") != "numeric(10,2)" {
+		t.Errorf("Expected type option to be 'numeric(10,2)', got '%s'", options.Get("type"))
+	}
+	if !options.Has("notnull") || options.Get("default") != "0" {
+		t.Errorf("Unexpected options: %v", options)
+	}
+
+	name, options = ParseTag("")
+	if name != "" || len(options) != 0 {
+		t.Errorf("Expected empty tag to have no name and options, got '%s' %v", name, options)
+	}
+}
+
+type AccessorStruct struct {
+	ID   int      `db:"id,primary"`
+	Name string   `db:"name"`
+	Tags []string `db:"tags"`
+}
+
+func (a *AccessorStruct) FieldValues() map[string]interface{} {
+	return map[string]interface{}{"id": a.ID, "name": "accessor:" + a.Name, "tags": a.Tags}
+}
+
+func (a *AccessorStruct) FieldPointer(column string) interface{} {
+	switch column {
+	case "id":
+		return &a.ID
+	case "tags":
+		return &a.Tags
+	}
+	return nil
+}
+
+func TestFieldAccessor(t *testing.T) {
+	model := &AccessorStruct{ID: 1, Name: "John"}
+
+	// Os acessores gerados substituem a leitura por reflexão
+	fields, err := GetStructFields(model)
+	if err != nil {
+		t.Fatalf("GetStructFields returned error: %v", err)
+	}
+	if fields["name"] != "accessor:John" {
+		t.Errorf("Expected value from FieldValues, got %v", fields["name"])
+	}
+
+	// Colunas sem acessor recorrem à reflexão
+	destinations := ScanDestinations(reflect.ValueOf(model).Elem(), []string{"id", "name"})
+	if destinations[0] != &model.ID {
+		t.Errorf("Expected scan destination from FieldPointer, got %v", destinations[0])
+	}
+	if destinations[1] != &model.Name {
+		t.Errorf("Expected reflective scan destination for 'name', got %v", destinations[1])
+	}
+
+	// Os slices dos acessores são envolvidos como os campos lidos por reflexão
+	arrayType := reflect.TypeOf(pq.Array(&model.Tags))
+	if got := reflect.TypeOf(fields["tags"]); got != arrayType {
+		t.Errorf("Expected tags bound as %v, got %v", arrayType, got)
+	}
+	destinations = ScanDestinations(reflect.ValueOf(model).Elem(), []string{"tags"})
+	if got := reflect.TypeOf(destinations[0]); got != arrayType {
+		t.Errorf("Expected tags scanned into %v, got %v", arrayType, got)
+	}
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RelationKind identifica o tipo de uma relação entre modelos
+type RelationKind string
+
+const (
+	// BelongsTo indica que o modelo guarda a chave estrangeira do modelo relacionado
+	BelongsTo RelationKind = "belongs_to"
+	// HasMany indica que os modelos relacionados guardam a chave estrangeira do modelo
+	HasMany RelationKind = "has_many"
+	// ManyToMany indica que os modelos são ligados por uma tabela de junção
+	ManyToMany RelationKind = "many_to_many"
+)
+
+// Relation descreve uma relação declarada com a tag "rel"
+type Relation struct {
+	// Name é o nome do campo da relação na estrutura
+	Name string
+	// Kind é o tipo da relação
+	Kind RelationKind
+	// Index é o índice do campo, usado com reflect.Value.FieldByIndex
+	Index []int
+	// Type é o tipo Go do campo
+	Type reflect.Type
+	// Owner é o tipo da estrutura que declara a relação
+	Owner reflect.Type
+	// Target é o tipo da estrutura relacionada
+	Target reflect.Type
+	// ForeignKey é a coluna da chave estrangeira
+	ForeignKey string
+	// References é a coluna referenciada pela chave estrangeira
+	References string
+	// JoinTable é a tabela de junção declarada com a opção join; quando vazia, o nome vem
+	// da estratégia de nomes (veja Mapper.JoinTable)
+	JoinTable string
+	// JoinForeignKey é a coluna da tabela de junção que referencia o modelo relacionado
+	JoinForeignKey string
+}
+
+// IsSlice indica se o campo da relação é um slice
+func (r Relation) IsSlice() bool {
+	return r.Type.Kind() == reflect.Slice
+}
+
+var relationsCache sync.Map // map[reflect.Type][]Relation
+
+// GetRelations retorna as relações declaradas em um tipo de estrutura
+func GetRelations(typ reflect.Type) ([]Relation, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tipo %s não é uma estrutura", typ)
+	}
+
+	if cached, ok := relationsCache.Load(typ); ok {
+		return cached.([]Relation), nil
+	}
+
+	relations := make([]Relation, 0)
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		tag, ok := fieldType.Tag.Lookup("rel")
+		if !ok || !fieldType.IsExported() {
+			continue
+		}
+
+		relation, err := parseRelation(typ, fieldType, tag)
+		if err != nil {
+			return nil, fmt.Errorf("relação %s.%s: %w", typ.Name(), fieldType.Name, err)
+		}
+		relations = append(relations, relation)
+	}
+
+	cached, _ := relationsCache.LoadOrStore(typ, relations)
+	return cached.([]Relation), nil
+}
+
+// GetRelation retorna a relação declarada no campo informado
+func GetRelation(typ reflect.Type, name string) (Relation, error) {
+	relations, err := GetRelations(typ)
+	if err != nil {
+		return Relation{}, err
+	}
+	for _, relation := range relations {
+		if relation.Name == name {
+			return relation, nil
+		}
+	}
+	return Relation{}, fmt.Errorf("relação %q não encontrada em %s", name, typ)
+}
+
+// parseRelation interpreta uma tag "rel" como "has_many,fk=order_id,references=id" ou
+// "many_to_many,join=user_roles,fk=user_id,target_fk=role_id"; em many_to_many a opção join
+// é opcional
+func parseRelation(owner reflect.Type, field reflect.StructField, tag string) (Relation, error) {
+	kind, options := ParseTag(tag)
+	relation := Relation{
+		Name:           field.Name,
+		Owner:          owner,
+		Kind:           RelationKind(kind),
+		Index:          field.Index,
+		Type:           field.Type,
+		ForeignKey:     options.Get("fk"),
+		References:     options.Get("references"),
+		JoinTable:      options.Get("join"),
+		JoinForeignKey: options.Get("target_fk"),
+	}
+
+	target := field.Type
+	if target.Kind() == reflect.Slice {
+		target = target.Elem()
+	}
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return relation, fmt.Errorf("o campo deve ser uma estrutura, um ponteiro ou um slice de estruturas")
+	}
+	relation.Target = target
+
+	if relation.ForeignKey == "" {
+		return relation, fmt.Errorf("a opção fk é obrigatória")
+	}
+
+	switch relation.Kind {
+	case BelongsTo:
+		if relation.IsSlice() {
+			return relation, fmt.Errorf("belongs_to não pode ser declarada em um slice")
+		}
+	case HasMany:
+		if !relation.IsSlice() {
+			return relation, fmt.Errorf("has_many deve ser declarada em um slice")
+		}
+	case ManyToMany:
+		if !relation.IsSlice() {
+			return relation, fmt.Errorf("many_to_many deve ser declarada em um slice")
+		}
+		if relation.JoinForeignKey == "" {
+			return relation, fmt.Errorf("a opção target_fk é obrigatória em many_to_many")
+		}
+	default:
+		return relation, fmt.Errorf("tipo de relação desconhecido %q", kind)
+	}
+
+	return relation, nil
+}
+
+// KeyColumns retorna a coluna do modelo dono da relação e a coluna do modelo relacionado
+// segundo DefaultMapper
+func (r Relation) KeyColumns(owner reflect.Type) (string, string, error) {
+	return DefaultMapper.KeyColumns(r, owner)
+}
+
+// TableNameOf retorna o nome da tabela de um tipo de estrutura segundo DefaultMapper
+func TableNameOf(typ reflect.Type) (string, error) {
+	return DefaultMapper.TableName(typ)
+}
+
+// PrimaryKeyOf retorna a coluna de chave primária de um tipo de estrutura
+func PrimaryKeyOf(typ reflect.Type) (string, error) {
+	return DefaultMapper.PrimaryKeyOf(typ)
+}
+
+// splitRelationPath separa caminhos como "Items.Product" em uma árvore por relação
+func splitRelationPath(paths []string) ([]string, map[string][]string) {
+	names := make([]string, 0, len(paths))
+	nested := make(map[string][]string)
+	for _, path := range paths {
+		name, rest, _ := strings.Cut(strings.TrimSpace(path), ".")
+		if name == "" {
+			continue
+		}
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = nil
+		}
+		if rest != "" {
+			nested[name] = append(nested[name], rest)
+		}
+	}
+	return names, nested
+}
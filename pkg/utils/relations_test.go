@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Customer struct {
+	ID   int    `db:"id,primary"`
+	Name string `db:"name"`
+}
+
+func (c *Customer) TableName() string { return "customers" }
+
+type OrderItem struct {
+	ID      int `db:"id,primary"`
+	OrderID int `db:"order_id"`
+}
+
+func (i *OrderItem) TableName() string { return "order_items" }
+
+type Order struct {
+	ID         int          `db:"id,primary"`
+	CustomerID int          `db:"customer_id"`
+	Customer   *Customer    `db:"-" rel:"belongs_to,fk=customer_id"`
+	Items      []*OrderItem `rel:"has_many,fk=order_id"`
+}
+
+func TestGetRelations(t *testing.T) {
+	relations, err := GetRelations(reflect.TypeOf(&Order{}))
+	if err != nil {
+		t.Fatalf("GetRelations returned error: %v", err)
+	}
+
+	if len(relations) != 2 {
+		t.Fatalf("Expected 2 relations, got %d", len(relations))
+	}
+
+	customer := relations[0]
+	if customer.Name != "Customer" || customer.Kind != BelongsTo || customer.ForeignKey != "customer_id" {
+		t.Errorf("Unexpected belongs_to relation: %+v", customer)
+	}
+	if customer.Target != reflect.TypeOf(Customer{}) {
+		t.Errorf("Expected target to be Customer, got %v", customer.Target)
+	}
+
+	items := relations[1]
+	if items.Kind != HasMany || !items.IsSlice() || items.Target != reflect.TypeOf(OrderItem{}) {
+		t.Errorf("Unexpected has_many relation: %+v", items)
+	}
+
+	// Campos de relação não são mapeados como colunas
+	for _, field := range GetFields(reflect.TypeOf(Order{})) {
+		if field.Name == "Items" || field.Name == "Customer" {
+			t.Errorf("Relation field %s should not be mapped as a column", field.Name)
+		}
+	}
+
+	// Relação inexistente
+	if _, err := GetRelation(reflect.TypeOf(Order{}), "Missing"); err == nil {
+		t.Errorf("Expected error for unknown relation, got nil")
+	}
+}
+
+func TestManyToManyRelation(t *testing.T) {
+	type Role struct {
+		ID int `db:"id,primary"`
+	}
+	type User struct {
+		ID    int     `db:"id,primary"`
+		Roles []*Role `rel:"many_to_many,join=user_roles,fk=user_id,target_fk=role_id"`
+	}
+
+	relation, err := GetRelation(reflect.TypeOf(User{}), "Roles")
+	if err != nil {
+		t.Fatalf("GetRelation returned error: %v", err)
+	}
+	if relation.Kind != ManyToMany || relation.JoinTable != "user_roles" || relation.ForeignKey != "user_id" || relation.JoinForeignKey != "role_id" {
+		t.Errorf("Unexpected many_to_many relation: %+v", relation)
+	}
+
+	ownerColumn, targetColumn, err := relation.KeyColumns(reflect.TypeOf(User{}))
+	if err != nil {
+		t.Fatalf("KeyColumns returned error: %v", err)
+	}
+	if ownerColumn != "id" || targetColumn != "id" {
+		t.Errorf("Expected key columns (id, id), got (%s, %s)", ownerColumn, targetColumn)
+	}
+
+	type MissingJoin struct {
+		Roles []Role `rel:"many_to_many,fk=user_id"`
+	}
+	if _, err := GetRelations(reflect.TypeOf(MissingJoin{})); err == nil {
+		t.Errorf("Expected error for many_to_many without join table, got nil")
+	}
+}
+
+func TestInvalidRelations(t *testing.T) {
+	type MissingFK struct {
+		Items []OrderItem `rel:"has_many"`
+	}
+	if _, err := GetRelations(reflect.TypeOf(MissingFK{})); err == nil {
+		t.Errorf("Expected error for relation without fk, got nil")
+	}
+
+	type WrongKind struct {
+		Item OrderItem `rel:"has_many,fk=order_id"`
+	}
+	if _, err := GetRelations(reflect.TypeOf(WrongKind{})); err == nil {
+		t.Errorf("Expected error for has_many on a non-slice field, got nil")
+	}
+}
+
+func TestSplitRelationPath(t *testing.T) {
+	names, nested := splitRelationPath([]string{"Items.Product", "Customer", "Items.Product.Vendor", "Items"})
+
+	if !reflect.DeepEqual(names, []string{"Items", "Customer"}) {
+		t.Errorf("Expected names [Items Customer], got %v", names)
+	}
+	if !reflect.DeepEqual(nested["Items"], []string{"Product", "Product.Vendor"}) {
+		t.Errorf("Expected nested paths [Product Product.Vendor], got %v", nested["Items"])
+	}
+	if len(nested["Customer"]) != 0 {
+		t.Errorf("Expected no nested paths for Customer, got %v", nested["Customer"])
+	}
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TagOptions representa as opções declaradas em uma tag "db" após o nome da coluna
+type TagOptions map[string]string
+
+// Has verifica se a opção está presente na tag
+func (o TagOptions) Has(name string) bool {
+	_, ok := o[name]
+	return ok
+}
+
+// Get retorna o valor de uma opção no formato "chave=valor"
+func (o TagOptions) Get(name string) string {
+	return o[name]
+}
+
+// ParseTag separa uma tag "db" no nome da coluna e nas suas opções.
+// Vírgulas dentro de parênteses, como em "type=numeric(10,2)", não separam opções.
+func ParseTag(tag string) (string, TagOptions) {
+	parts := splitTag(tag)
+	options := make(TagOptions, len(parts)-1)
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(parts[0]), options
+}
+
+// splitTag separa a tag por vírgulas que não estejam entre parênteses
+func splitTag(tag string) []string {
+	parts := make([]string, 0, 1)
+	depth, start := 0, 0
+	for i, r := range tag {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// FieldInfo descreve o mapeamento de um campo da estrutura para uma coluna
+type FieldInfo struct {
+	// Name é o nome do campo na estrutura
+	Name string
+	// Column é o nome da coluna no banco de dados
+	Column string
+	// Index é o índice do campo, usado com reflect.Value.FieldByIndex
+	Index []int
+	// Type é o tipo Go do campo
+	Type reflect.Type
+	// Options são as opções declaradas na tag "db"
+	Options TagOptions
+}
+
+// IsPrimary indica se o campo foi marcado como chave primária
+func (f FieldInfo) IsPrimary() bool {
+	return f.Options.Has("primary")
+}
+
+// IsSensitive indica se o campo foi marcado com a opção "sensitive", cujos valores não
+// aparecem nos registros das instruções
+func (f FieldInfo) IsSensitive() bool {
+	return f.Options.Has("sensitive")
+}
+
+// IsArray indica se o campo deve ser tratado como um array do PostgreSQL
+func (f FieldInfo) IsArray() bool {
+	return f.Options.Has("array") || isArrayType(f.Type)
+}
+
+// GetFields retorna os campos mapeados de um tipo de estrutura, na ordem de declaração,
+// nomeando as colunas sem tag segundo DefaultMapper
+func GetFields(typ reflect.Type) []FieldInfo {
+	return DefaultMapper.Fields(typ)
+}
+
+// Fields retorna os campos mapeados de um tipo de estrutura, na ordem de declaração.
+// Campos sem nome na tag "db" recebem o nome dado pela estratégia de nomes do Mapper.
+func (m *Mapper) Fields(typ reflect.Type) []FieldInfo {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if cached, ok := m.fields.Load(typ); ok {
+		return cached.([]FieldInfo)
+	}
+
+	fields := make([]FieldInfo, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+
+		// Ignora campos não exportados
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		// Ignora campos marcados com db:"-" e campos de relação
+		tag := fieldType.Tag.Get("db")
+		if _, isRelation := fieldType.Tag.Lookup("rel"); tag == "-" || isRelation {
+			continue
+		}
+
+		// Se não houver nome na tag, usa a estratégia de nomes
+		columnName, options := ParseTag(tag)
+		if columnName == "" {
+			columnName = m.naming.ColumnName(fieldType.Name)
+		}
+
+		fields = append(fields, FieldInfo{
+			Name:    fieldType.Name,
+			Column:  columnName,
+			Index:   fieldType.Index,
+			Type:    fieldType.Type,
+			Options: options,
+		})
+	}
+
+	cached, _ := m.fields.LoadOrStore(typ, fields)
+	return cached.([]FieldInfo)
+}
+
+// FindField procura o campo mapeado para a coluna informada. Apenas o nome exato da
+// coluna é aceito, de modo que uma coluna "createdat" não é lida em CreatedAt quando a
+// estratégia de nomes o mapeia para "created_at".
+func FindField(fields []FieldInfo, column string) (FieldInfo, bool) {
+	for _, field := range fields {
+		if field.Column == column {
+			return field, true
+		}
+	}
+	return FieldInfo{}, false
+}